@@ -0,0 +1,23 @@
+//go:build linux && !readall_purego
+// +build linux,!readall_purego
+
+package readall
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// setAffinity restricts the calling OS thread to cpu.
+func setAffinity(cpu int) error {
+	var mask [16]uint64
+	if cpu < 0 || cpu >= len(mask)*64 {
+		return syscall.EINVAL
+	}
+	mask[cpu/64] |= 1 << uint(cpu%64)
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
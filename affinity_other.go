@@ -0,0 +1,12 @@
+//go:build !linux || readall_purego
+// +build !linux readall_purego
+
+package readall
+
+import "errors"
+
+// setAffinity is not supported outside Linux or in readall_purego builds;
+// workers are only locked to threads.
+func setAffinity(cpu int) error {
+	return errors.New("readall: CPU affinity not supported")
+}
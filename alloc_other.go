@@ -0,0 +1,9 @@
+//go:build readall_purego || !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+// +build readall_purego !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package readall
+
+// mapAnon is not available; MmapAllocator uses the heap instead.
+func mapAnon(n int) ([]byte, func(), error) {
+	return nil, nil, ErrMmapUnsupported
+}
@@ -0,0 +1,20 @@
+//go:build (linux || darwin || dragonfly || freebsd || netbsd || openbsd) && !readall_purego
+// +build linux darwin dragonfly freebsd netbsd openbsd
+// +build !readall_purego
+
+package readall
+
+import (
+	"sync"
+	"syscall"
+)
+
+// mapAnon maps n bytes of private anonymous memory.
+func mapAnon(n int) ([]byte, func(), error) {
+	data, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return data[:0], func() { once.Do(func() { syscall.Munmap(data) }) }, nil
+}
@@ -0,0 +1,28 @@
+//go:build windows && !readall_purego
+// +build windows,!readall_purego
+
+package readall
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// mapAnon maps n bytes of memory backed by the paging file.
+func mapAnon(n int) ([]byte, func(), error) {
+	size := uint64(n)
+	h, err := syscall.CreateFileMapping(syscall.InvalidHandle, nil, syscall.PAGE_READWRITE, uint32(size>>32), uint32(size), nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_WRITE, 0, 0, uintptr(n))
+	syscall.CloseHandle(h)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	data := unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), n)
+	var once sync.Once
+	return data[:0], func() { once.Do(func() { syscall.UnmapViewOfFile(addr) }) }, nil
+}
@@ -0,0 +1,131 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// mmapAllocMin is the buffer size from which MmapAllocator maps memory;
+// smaller buffers are not worth a system call and a page each.
+const mmapAllocMin = 1 << 20
+
+// Allocator provides the buffers of ReadAllResult. Alloc returns a buffer
+// of length 0 and capacity at least n, and the function freeing it,
+// which is called exactly once.
+type Allocator interface {
+	Alloc(n int) (buf []byte, free func(), err error)
+}
+
+// HeapAllocator allocates buffers on the Go heap; free does nothing and the
+// garbage collector reclaims them.
+var HeapAllocator Allocator = heapAllocator{}
+
+// MmapAllocator maps buffers of 1MiB and more as anonymous memory outside
+// the Go heap, on Linux, the BSDs, macOS and Windows, unless built with
+// the readall_purego tag. The garbage collector neither scans nor frees such
+// a buffer: free unmaps it at once, and it must not be used afterwards.
+// Smaller buffers, and all buffers elsewhere, come from the heap.
+var MmapAllocator Allocator = mmapAllocator{}
+
+type heapAllocator struct{}
+
+func (heapAllocator) Alloc(n int) ([]byte, func(), error) {
+	return make([]byte, 0, n), func() {}, nil
+}
+
+type mmapAllocator struct{}
+
+func (mmapAllocator) Alloc(n int) ([]byte, func(), error) {
+	if n < mmapAllocMin {
+		return heapAllocator{}.Alloc(n)
+	}
+	buf, free, err := mapAnon(n)
+	if err == ErrMmapUnsupported {
+		return heapAllocator{}.Alloc(n)
+	}
+	return buf, free, err
+}
+
+// WithAllocator makes ReadAllResult take its buffer, and every larger one it
+// grows into, from a, handing the free function of the final buffer out as
+// Result.Release. Other ReadAll variants cannot hand out a free function
+// and ignore it.
+func WithAllocator(a Allocator) Option {
+	return func(o *options) {
+		o.allocator = a
+	}
+}
+
+// ReadAllResult is ReadAll returning a Result, so the buffer can come from
+// the Allocator of WithAllocator: Release frees it and must be called once
+// Data is no longer used, also when err is set. Without an allocator it is
+// ReadAll and Release is nil. WithLimit, WithLabel and the growth options
+// apply.
+func ReadAllResult(r io.Reader, opts ...Option) (_ Result, err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts)
+	if o.allocator == nil {
+		data, err := readAllCap(r, o.capacity(r), o)
+		return Result{Data: data}, err
+	}
+	return o.allocRead(r)
+}
+
+// allocRead reads r to EOF into buffers from the allocator of o.
+func (o *options) allocRead(r io.Reader) (Result, error) {
+	t := o.track(r)
+	defer t.done()
+	defer o.watch(t)()
+	var tr io.Reader = trackedReader{r, t}
+	if n := o.readLimit(); n > 0 {
+		tr = newLimitReader(tr, n)
+	}
+	a := o.allocator
+	buf, free, err := a.Alloc(int(o.capacity(r)))
+	if err != nil {
+		return Result{}, err
+	}
+	for {
+		if len(buf) == cap(buf) {
+			n := 2 * cap(buf)
+			if o.growth.factor > 1 {
+				n = int(float64(cap(buf)) * o.growth.factor)
+			}
+			if n < cap(buf)+bytes.MinRead {
+				n = cap(buf) + bytes.MinRead
+			}
+			if m := o.growth.max; m > 0 && int64(n) > m+1 {
+				n = int(m + 1)
+			}
+			nb, nfree, aerr := a.Alloc(n)
+			if aerr != nil {
+				err = aerr
+				break
+			}
+			nb = append(nb[:0], buf...)
+			free()
+			buf, free = nb, nfree
+		}
+		p := buf[len(buf):cap(buf)]
+		if o.growth.chunk > 0 && len(p) > o.growth.chunk {
+			p = p[:o.growth.chunk]
+		}
+		n, rerr := tr.Read(p)
+		buf = buf[:len(buf)+n]
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			err = rerr
+			break
+		}
+	}
+	if t.isKilled() {
+		free()
+		return Result{}, t.err()
+	}
+	observeSize(o.label, int64(len(buf)))
+	var once sync.Once
+	return Result{Data: buf, Release: func() { once.Do(free) }}, o.partial(buf, o.truncated(err, nil))
+}
@@ -0,0 +1,69 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countingAllocator heap-allocates and counts outstanding buffers.
+type countingAllocator struct {
+	allocs, live int
+}
+
+func (a *countingAllocator) Alloc(n int) ([]byte, func(), error) {
+	a.allocs++
+	a.live++
+	return make([]byte, 0, n), func() { a.live-- }, nil
+}
+
+func TestWithAllocator(t *testing.T) {
+	body := strings.Repeat("a", 10000)
+	var a countingAllocator
+	res, err := ReadAllResult(unsized{strings.NewReader(body)}, WithAllocator(&a))
+	if err != nil || string(res.Data) != body || a.allocs < 2 || a.live != 1 {
+		t.Fatalf("len:%v, allocs:%v, live:%v, err:%v", len(res.Data), a.allocs, a.live, err)
+	}
+	res.Release()
+	res.Release()
+	if a.live != 0 {
+		t.Errorf("live after release:%v", a.live)
+	}
+
+	a = countingAllocator{}
+	res, err = ReadAllResult(strings.NewReader(body), WithAllocator(&a))
+	if err != nil || a.allocs != 1 || cap(res.Data) != 10001 {
+		t.Errorf("sized allocs:%v, cap:%v, err:%v", a.allocs, cap(res.Data), err)
+	}
+	res.Release()
+
+	res, err = ReadAllResult(strings.NewReader(body), WithAllocator(&a), WithLimit(100))
+	if !errors.Is(err, ErrLimitExceeded) || res.Release == nil {
+		t.Errorf("limit err:%v", err)
+	} else {
+		res.Release()
+	}
+	if a.live != 0 {
+		t.Errorf("leaked buffers:%v", a.live)
+	}
+
+	if res, err := ReadAllResult(strings.NewReader("heap")); err != nil || string(res.Data) != "heap" || res.Release != nil {
+		t.Errorf("default result:%+v, err:%v", res, err)
+	}
+}
+
+func TestMmapAllocator(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789abcdef"), 300000)
+	res, err := ReadAllResult(unsized{bytes.NewReader(body)}, WithAllocator(MmapAllocator))
+	if err != nil || !bytes.Equal(res.Data, body) {
+		t.Fatalf("len:%v, err:%v", len(res.Data), err)
+	}
+	res.Release()
+
+	buf, free, err := MmapAllocator.Alloc(10)
+	if err != nil || len(buf) != 0 || cap(buf) < 10 {
+		t.Errorf("small alloc cap:%v, err:%v", cap(buf), err)
+	}
+	free()
+}
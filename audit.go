@@ -0,0 +1,38 @@
+package readall
+
+import (
+	"hash"
+	"time"
+)
+
+// AuditEvent records one completed read for WithAudit. Source is redacted
+// like SourceError.Source.
+type AuditEvent struct {
+	Source   string
+	Label    string
+	Tenant   string // from ContextMeta
+	Priority int    // from ContextMeta
+	Start    time.Time
+	Duration time.Duration
+	Bytes    int64
+	// Digest is the sum of the first hash stage, if the read computed one.
+	Digest []byte
+	Err    error
+}
+
+func emitAudit(o *options, src interface{}, start time.Time, n int64, digest hash.Hash, err error) {
+	ev := AuditEvent{
+		Source:   redact(sourceName(src)),
+		Label:    o.label,
+		Tenant:   o.tenant,
+		Priority: o.priority,
+		Start:    start,
+		Duration: time.Since(start),
+		Bytes:    n,
+		Err:      err,
+	}
+	if digest != nil {
+		ev.Digest = digest.Sum(nil)
+	}
+	o.audit(ev)
+}
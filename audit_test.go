@@ -0,0 +1,36 @@
+package readall
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audited")
+	ioutil.WriteFile(path, []byte("audited content"), 0644)
+	var events []AuditEvent
+	audit := WithAudit(func(ev AuditEvent) { events = append(events, ev) })
+
+	if _, err := ReadURL(context.Background(), path, audit, WithLabel("importer")); err != nil {
+		t.Errorf("read err:%v", err)
+		return
+	}
+	_, err := From(FileSource(path)).Hash(sha256.New()).Options(audit, WithLimit(3)).Bytes()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limited read err:%v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("events:%v, want:2", len(events))
+		return
+	}
+	if ev := events[0]; ev.Source != path || ev.Label != "importer" || ev.Bytes != 15 || ev.Err != nil || ev.Digest != nil {
+		t.Errorf("event:%+v", ev)
+	}
+	if ev := events[1]; !errors.Is(ev.Err, ErrLimitExceeded) || ev.Bytes != 3 || len(ev.Digest) != sha256.Size {
+		t.Errorf("event:%+v", ev)
+	}
+}
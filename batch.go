@@ -0,0 +1,195 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrorPolicy selects how batch reads such as ReadSmallFiles handle a
+// failing item.
+type ErrorPolicy int
+
+const (
+	// FailFast stops the batch at the first failure and returns it.
+	FailFast ErrorPolicy = iota
+	// CollectAll reads every item, returning what succeeded together with a
+	// *BatchError listing the failures.
+	CollectAll
+)
+
+// WithErrorPolicy sets the ErrorPolicy of a batch read; the default is
+// FailFast.
+func WithErrorPolicy(p ErrorPolicy) Option {
+	return func(o *options) {
+		o.errPolicy = p
+	}
+}
+
+// BatchError holds the failures of a batch read under CollectAll, in item
+// order. errors.Is and errors.As match any of them.
+type BatchError struct {
+	Errs []error
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("readall: %d reads failed: %s", len(e.Errs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the collected errors.
+func (e *BatchError) Unwrap() []error {
+	return e.Errs
+}
+
+// Is reports whether any of the collected errors matches target. Go 1.20
+// and later find them through Unwrap as well; Is and As keep errors.Is and
+// errors.As working on older releases.
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first collected error that matches target, like errors.As.
+func (e *BatchError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// batchErrors gathers the failures of a batch according to a policy.
+type batchErrors struct {
+	policy ErrorPolicy
+	errs   []error
+}
+
+// add records err and reports whether the batch should stop.
+func (b *batchErrors) add(err error) bool {
+	if err == nil {
+		return false
+	}
+	b.errs = append(b.errs, err)
+	return b.policy == FailFast
+}
+
+func (b *batchErrors) err() error {
+	switch {
+	case len(b.errs) == 0:
+		return nil
+	case b.policy == FailFast:
+		return b.errs[0]
+	}
+	return &BatchError{Errs: b.errs}
+}
+
+// BatchResult is the outcome of reading one file of a Batch.
+type BatchResult struct {
+	// Index is the position of Path in the paths passed to Batch.
+	Index int
+	Path  string
+	Data  []byte
+	Err   error
+}
+
+// Batch reads every file in paths with at most workers (GOMAXPROCS if not
+// positive) reads running at once and returns one result per path, in the
+// order of paths. Each file is read like ReadAllContext with opts; pass
+// WithBudget to bound the memory of the reads in flight. Files not read
+// when ctx is done carry ctx.Err().
+func Batch(ctx context.Context, paths []string, workers int, opts ...Option) []BatchResult {
+	results := make([]BatchResult, len(paths))
+	read := make([]bool, len(paths))
+	BatchFunc(ctx, paths, workers, func(res BatchResult) error {
+		results[res.Index], read[res.Index] = res, true
+		return nil
+	}, opts...)
+	for i, ok := range read {
+		if !ok {
+			results[i] = BatchResult{Index: i, Path: paths[i], Err: ctxErr(ctx)}
+		}
+	}
+	return results
+}
+
+// BatchFunc is Batch calling fn with each result as soon as its file has been
+// read, so in completion order. fn is never called concurrently. If fn
+// returns an error the remaining reads are canceled and BatchFunc returns that
+// error; otherwise it returns ctx.Err() if ctx was done before every file was
+// read, and nil once all were.
+func BatchFunc(ctx context.Context, paths []string, workers int, fn func(BatchResult) error, opts ...Option) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	jobs := make(chan int)
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := BatchResult{Index: i, Path: paths[i]}
+				res.Data, res.Err = readBatchFile(ctx, paths[i], opts)
+				results <- res
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	var err error
+	n := 0
+	for res := range results {
+		if err != nil {
+			continue
+		}
+		n++
+		if err = fn(res); err != nil {
+			cancel()
+		}
+	}
+	if err == nil && n < len(paths) {
+		err = ctxErr(parent)
+	}
+	return err
+}
+
+func readBatchFile(ctx context.Context, path string, opts []Option) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAllContext(ctx, f, opts...)
+}
@@ -0,0 +1,75 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func batchFiles(t *testing.T, n int) []string {
+	dir := t.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i))
+		ioutil.WriteFile(paths[i], []byte(strings.Repeat("x", i*100)), 0644)
+	}
+	return paths
+}
+
+func TestBatch(t *testing.T) {
+	paths := batchFiles(t, 20)
+	paths[5] += ".missing"
+	results := Batch(context.Background(), paths, 4, WithBudget(NewBudget(1<<20)))
+	if len(results) != len(paths) {
+		t.Fatalf("results:%v, want:%v", len(results), len(paths))
+	}
+	for i, res := range results {
+		if res.Index != i || res.Path != paths[i] {
+			t.Errorf("result %v index:%v, path:%v", i, res.Index, res.Path)
+		}
+		if i == 5 {
+			if !errors.Is(res.Err, os.ErrNotExist) {
+				t.Errorf("missing err:%v, want:%v", res.Err, os.ErrNotExist)
+			}
+			continue
+		}
+		if res.Err != nil || len(res.Data) != i*100 {
+			t.Errorf("result %v len:%v, err:%v", i, len(res.Data), res.Err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, res := range Batch(ctx, paths, 2) {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("canceled %v err:%v", res.Index, res.Err)
+		}
+	}
+}
+
+func TestBatchFunc(t *testing.T) {
+	paths := batchFiles(t, 50)
+	seen := make(map[int]bool)
+	err := BatchFunc(context.Background(), paths, 3, func(res BatchResult) error {
+		seen[res.Index] = true
+		return res.Err
+	})
+	if err != nil || len(seen) != len(paths) {
+		t.Errorf("seen:%v, err:%v", len(seen), err)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = BatchFunc(context.Background(), paths, 3, func(res BatchResult) error {
+		calls++
+		return errStop
+	})
+	if err != errStop || calls != 1 {
+		t.Errorf("calls:%v, err:%v, want 1 and %v", calls, err, errStop)
+	}
+}
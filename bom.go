@@ -0,0 +1,55 @@
+package readall
+
+import "bytes"
+
+// Encoding describes what byte-order-mark handling found in the data, so a
+// caller can restore the original bytes when writing them back.
+type Encoding struct {
+	// Charset is the encoding the BOM announces ("UTF-8", "UTF-16LE",
+	// "UTF-16BE", "UTF-32LE", "UTF-32BE"), or empty without a BOM.
+	Charset string
+	// BOM reports whether the data started with a byte-order mark.
+	BOM bool
+	// Stripped is the number of bytes removed from the start of Data.
+	Stripped int
+}
+
+// WithStripBOM removes a leading byte-order mark from the data and reports it
+// in Result.Encoding. The data itself is not transcoded.
+func WithStripBOM() Option {
+	return func(o *options) {
+		o.stripBOM = true
+	}
+}
+
+// boms is ordered so UTF-32LE is checked before its UTF-16LE prefix.
+var boms = []struct {
+	charset string
+	mark    []byte
+}{
+	{"UTF-8", []byte{0xef, 0xbb, 0xbf}},
+	{"UTF-32LE", []byte{0xff, 0xfe, 0, 0}},
+	{"UTF-32BE", []byte{0, 0, 0xfe, 0xff}},
+	{"UTF-16LE", []byte{0xff, 0xfe}},
+	{"UTF-16BE", []byte{0xfe, 0xff}},
+}
+
+// detectBOM returns the encoding announced by a BOM at the start of b.
+func detectBOM(b []byte) Encoding {
+	for _, bom := range boms {
+		if bytes.HasPrefix(b, bom.mark) {
+			return Encoding{Charset: bom.charset, BOM: true, Stripped: len(bom.mark)}
+		}
+	}
+	return Encoding{}
+}
+
+// applyBOM strips the BOM of res.Data if o asks for it.
+func (o *options) applyBOM(res Result) Result {
+	if !o.stripBOM {
+		return res
+	}
+	res.Encoding = detectBOM(res.Data)
+	res.Data = res.Data[res.Encoding.Stripped:]
+	return res
+}
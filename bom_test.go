@@ -0,0 +1,35 @@
+package readall
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestStripBOM(t *testing.T) {
+	for _, c := range []struct {
+		data, want, charset string
+	}{
+		{"\xef\xbb\xbfutf8", "utf8", "UTF-8"},
+		{"\xff\xfeh\x00", "h\x00", "UTF-16LE"},
+		{"\xff\xfe\x00\x00h\x00\x00\x00", "h\x00\x00\x00", "UTF-32LE"},
+		{"plain", "plain", ""},
+	} {
+		path := filepath.Join(t.TempDir(), "bom")
+		ioutil.WriteFile(path, []byte(c.data), 0644)
+		res, err := ReadFile(path, WithStripBOM())
+		enc := res.Encoding
+		if err != nil || string(res.Data) != c.want || enc.Charset != c.charset || enc.BOM != (c.charset != "") || enc.Stripped != len(c.data)-len(c.want) {
+			t.Errorf("%q data:%q, encoding:%+v, err:%v", c.data, res.Data, enc, err)
+		}
+	}
+	if data, err := ReadURL(context.Background(), "literal:\xef\xbb\xbfurl", WithStripBOM()); err != nil || string(data) != "url" {
+		t.Errorf("url data:%q, err:%v", data, err)
+	}
+	path := filepath.Join(t.TempDir(), "kept")
+	ioutil.WriteFile(path, []byte("\xef\xbb\xbfkept"), 0644)
+	if res, err := ReadFile(path); err != nil || len(res.Data) != 7 || res.Encoding != (Encoding{}) {
+		t.Errorf("without option len:%v, encoding:%+v, err:%v", len(res.Data), res.Encoding, err)
+	}
+}
@@ -0,0 +1,193 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when a read needs more memory than its Budget
+// can grant: at once with WithBudgetFailFast, or always when the read alone
+// needs more than the whole budget.
+var ErrBudgetExceeded = errors.New("readall: memory budget exceeded")
+
+// Budget bounds the buffer memory held by concurrent reads. Reads made with
+// WithBudget or WithBudgetFailFast acquire their buffer capacity from the
+// budget before allocating it, including every growth step, and give it back
+// when they return. The budget thus limits memory in flight, not the results
+// callers keep. Waiting reads are served in arrival order, and a read gives
+// back what it holds before waiting to grow, so reads holding parts of the
+// budget cannot wait on each other forever.
+type Budget struct {
+	mu      sync.Mutex
+	limit   int64
+	used    int64
+	waiters []*budgetWaiter
+
+	closed  chan struct{} // set by Shutdown
+	drained chan struct{} // closed when nothing is used after Shutdown
+}
+
+type budgetWaiter struct {
+	n     int64
+	ready chan struct{}
+	err   error // set before ready is closed without a grant
+}
+
+// NewBudget returns a Budget of limit bytes.
+func NewBudget(limit int64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// WithBudget makes the read acquire its buffers from b, waiting for other
+// reads to return memory when the budget is exhausted. ReadAllContext stops
+// waiting when its context is done.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget, o.budgetWait = b, true
+	}
+}
+
+// WithBudgetFailFast is WithBudget failing with ErrBudgetExceeded instead of
+// waiting.
+func WithBudgetFailFast(b *Budget) Option {
+	return func(o *options) {
+		o.budget, o.budgetWait = b, false
+	}
+}
+
+// InUse returns the bytes currently acquired.
+func (b *Budget) InUse() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Acquire takes n bytes from the budget, waiting until they are available or
+// ctx is done.
+func (b *Budget) Acquire(ctx context.Context, n int64) error {
+	b.mu.Lock()
+	if b.closed != nil {
+		b.mu.Unlock()
+		return ErrShutdown
+	}
+	if n > b.limit {
+		b.mu.Unlock()
+		return ErrBudgetExceeded
+	}
+	if len(b.waiters) == 0 && b.used+n <= b.limit {
+		b.used += n
+		b.mu.Unlock()
+		return nil
+	}
+	w := &budgetWaiter{n: n, ready: make(chan struct{})}
+	b.waiters = append(b.waiters, w)
+	b.mu.Unlock()
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Granted while being cancelled; keep the grant.
+			return w.err
+		default:
+		}
+		for i, o := range b.waiters {
+			if o == w {
+				b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+				break
+			}
+		}
+		b.notify()
+		return ctxErr(ctx)
+	}
+}
+
+// TryAcquire takes n bytes from the budget if they are available right away.
+func (b *Budget) TryAcquire(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed != nil || len(b.waiters) > 0 || b.used+n > b.limit {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+// Release returns n bytes to the budget.
+func (b *Budget) Release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	b.notify()
+	b.checkDrained()
+}
+
+// notify grants waiters in order while their requests fit.
+func (b *Budget) notify() {
+	for len(b.waiters) > 0 {
+		w := b.waiters[0]
+		if b.used+w.n > b.limit {
+			return
+		}
+		b.used += w.n
+		b.waiters = b.waiters[1:]
+		close(w.ready)
+	}
+}
+
+func (b *Budget) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed != nil
+}
+
+// budgetUse tracks what one read has acquired from its budget.
+type budgetUse struct {
+	ctx  context.Context
+	b    *Budget
+	wait bool
+	held int64
+}
+
+func (o *options) useBudget(ctx context.Context) *budgetUse {
+	if o.budget == nil {
+		return nil
+	}
+	return &budgetUse{ctx: ctx, b: o.budget, wait: o.budgetWait}
+}
+
+// grow acquires the memory for growing the buffer to capacity n.
+func (u *budgetUse) grow(n int64) error {
+	if u == nil || n <= u.held {
+		return nil
+	}
+	need := n - u.held
+	if u.wait {
+		if !u.b.TryAcquire(need) {
+			// Waiting while holding memory could deadlock with other
+			// growing reads, so wait for the whole size holding nothing.
+			u.release()
+			if err := u.b.Acquire(u.ctx, n); err != nil {
+				return err
+			}
+		}
+	} else if need > u.b.limit || !u.b.TryAcquire(need) {
+		if u.b.isClosed() {
+			return ErrShutdown
+		}
+		return ErrBudgetExceeded
+	}
+	u.held = n
+	return nil
+}
+
+func (u *budgetUse) release() {
+	if u != nil && u.held > 0 {
+		u.b.Release(u.held)
+		u.held = 0
+	}
+}
@@ -0,0 +1,100 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(100)
+	if _, err := ReadAll(strings.NewReader(strings.Repeat("x", 200)), WithBudget(b)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("oversized err:%v", err)
+	}
+	if err := b.Acquire(context.Background(), 90); err != nil {
+		t.Errorf("acquire err:%v", err)
+	}
+	data := strings.Repeat("y", 50)
+	if _, err := ReadAll(strings.NewReader(data), WithBudgetFailFast(b)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("fail fast err:%v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		got, err := ReadAll(strings.NewReader(data), WithBudget(b))
+		if err == nil && string(got) != data {
+			err = errors.New("wrong data")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Errorf("read did not wait for the budget, err:%v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.Release(90)
+	if err := <-done; err != nil {
+		t.Errorf("budgeted read err:%v", err)
+	}
+	if n := b.InUse(); n != 0 {
+		t.Errorf("in use after reads:%v", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	b.Acquire(context.Background(), 100)
+	if err := b.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("cancelled acquire err:%v", err)
+	}
+	b.Release(100)
+	if !b.TryAcquire(100) {
+		t.Errorf("cancelled waiter still queued")
+	}
+}
+
+func TestBudgetContention(t *testing.T) {
+	b := NewBudget(1 << 20)
+	data := strings.Repeat("z", 600<<10)
+	// Both reads hold part of the budget before either needs to grow again.
+	var half sync.WaitGroup
+	half.Add(2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			r := io.MultiReader(strings.NewReader(data[:400<<10]), barrierReader{&half}, strings.NewReader(data[400<<10:]))
+			got, err := ReadAll(unsized{r}, WithBudget(b))
+			if err == nil && len(got) != len(data) {
+				err = errors.New("wrong data")
+			}
+			done <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("contended read err:%v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("contended reads deadlocked, in use:%v", b.InUse())
+		}
+	}
+	if n := b.InUse(); n != 0 {
+		t.Errorf("in use after reads:%v", n)
+	}
+}
+
+// barrierReader waits for every reader of a group to reach it.
+type barrierReader struct {
+	wg *sync.WaitGroup
+}
+
+func (b barrierReader) Read(p []byte) (int, error) {
+	b.wg.Done()
+	b.wg.Wait()
+	return 0, io.EOF
+}
@@ -0,0 +1,178 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+var errBufferSeek = errors.New("readall: Buffer seek to negative position")
+
+// Buffer holds the result of a read and serves it as an io.ReaderAt,
+// io.ReadSeeker and io.WriterTo, for consumers such as archive/zip that
+// want one of those rather than a []byte. It is also an io.Writer and
+// io.ReaderFrom appending at the end, growing the way ReadAll's buffer
+// does, for code producing the data itself. The zero Buffer is empty and
+// ready to use.
+type Buffer struct {
+	data []byte
+	off  int64
+}
+
+// NewBuffer returns an empty Buffer. A positive sizeHint allocates exactly
+// that capacity up front, so data of the expected size is written without
+// growing; more data still grows the buffer.
+func NewBuffer(sizeHint int64) *Buffer {
+	b := new(Buffer)
+	if sizeHint > 0 && sizeHint < maxInt {
+		b.data = make([]byte, 0, sizeHint)
+	}
+	return b
+}
+
+// ReadAllBuffer is ReadAll returning the data as a Buffer. The Buffer is
+// never nil and holds what was read even when err is set.
+func ReadAllBuffer(r io.Reader, opts ...Option) (*Buffer, error) {
+	data, err := ReadAll(r, opts...)
+	return &Buffer{data: data}, err
+}
+
+// ReadAllBufferContext is ReadAllContext returning the data as a Buffer.
+func ReadAllBufferContext(ctx context.Context, r io.Reader, opts ...Option) (*Buffer, error) {
+	data, err := ReadAllContext(ctx, r, opts...)
+	return &Buffer{data: data}, err
+}
+
+// Buffer returns a Buffer over res.Data, e.g. for the result of ReadFile or
+// a Strategy. It shares Data, so call Release only once the Buffer is no
+// longer used.
+func (res Result) Buffer() *Buffer {
+	return &Buffer{data: res.Data}
+}
+
+// Bytes returns all of the data, regardless of the read offset. It shares
+// the memory of b.
+func (b *Buffer) Bytes() []byte {
+	return b.data
+}
+
+// Size returns the length of the data, the size an io.ReaderAt user such as
+// zip.NewReader asks for.
+func (b *Buffer) Size() int64 {
+	return int64(len(b.data))
+}
+
+// Len returns the number of bytes not yet read by Read.
+func (b *Buffer) Len() int {
+	if b.off >= int64(len(b.data)) {
+		return 0
+	}
+	return int(int64(len(b.data)) - b.off)
+}
+
+// Read implements io.Reader.
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.off >= int64(len(b.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b.data[b.off:])
+	b.off += int64(n)
+	return n, nil
+}
+
+// ReadAt implements io.ReaderAt. It does not move the read offset and is
+// safe to call concurrently.
+func (b *Buffer) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errBufferSeek
+	}
+	if off >= int64(len(b.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek implements io.Seeker. Seeking past the end is allowed; Read then
+// returns io.EOF.
+func (b *Buffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.off
+	case io.SeekEnd:
+		offset += int64(len(b.data))
+	default:
+		return 0, errors.New("readall: Buffer seek with invalid whence")
+	}
+	if offset < 0 {
+		return 0, errBufferSeek
+	}
+	b.off = offset
+	return offset, nil
+}
+
+// WriteTo implements io.WriterTo, writing the unread data to w.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	if b.off >= int64(len(b.data)) {
+		return 0, nil
+	}
+	p := b.data[b.off:]
+	n, err := w.Write(p)
+	b.off += int64(n)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// Write implements io.Writer, appending p to the data. It never fails.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.reserve(len(p))
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+// ReadFrom implements io.ReaderFrom, appending r until EOF to the data. A
+// size r reveals, as ReadAll uses it, grows the buffer once to fit.
+func (b *Buffer) ReadFrom(r io.Reader) (int64, error) {
+	start := len(b.data)
+	if n, ok := sizeHint(r); ok && n < maxInt-int64(start) {
+		// One spare byte lets the final Read report EOF without growing.
+		b.reserve(int(n) + 1)
+	}
+	var g growth
+	data, err := g.appendGrow(b.data, r, nil, nil)
+	b.data = data
+	return int64(len(data) - start), err
+}
+
+// Reset empties the Buffer, keeping its capacity for reuse. Slices
+// returned by Bytes before are overwritten by later writes.
+func (b *Buffer) Reset() {
+	b.data = b.data[:0]
+	b.off = 0
+}
+
+// reserve makes room for n more bytes, at least doubling the capacity
+// when it grows so appends stay amortized.
+func (b *Buffer) reserve(n int) {
+	need := len(b.data) + n
+	if need <= cap(b.data) {
+		return
+	}
+	size := 2 * cap(b.data)
+	if size < cap(b.data)+bytes.MinRead {
+		size = cap(b.data) + bytes.MinRead
+	}
+	if size < need {
+		size = need
+	}
+	nb := make([]byte, len(b.data), size)
+	copy(nb, b.data)
+	b.data = nb
+}
@@ -0,0 +1,116 @@
+package readall
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var (
+	_ io.ReaderAt   = (*Buffer)(nil)
+	_ io.ReadSeeker = (*Buffer)(nil)
+	_ io.WriterTo   = (*Buffer)(nil)
+)
+
+func TestReadAllBuffer(t *testing.T) {
+	b, err := ReadAllBuffer(strings.NewReader("hello, world"))
+	if err != nil || string(b.Bytes()) != "hello, world" || b.Size() != 12 {
+		t.Fatalf("buffer:%q, err:%v", b.Bytes(), err)
+	}
+	p := make([]byte, 5)
+	if n, err := b.ReadAt(p, 7); n != 5 || err != nil || string(p) != "world" {
+		t.Errorf("ReadAt:%q, n:%v, err:%v", p, n, err)
+	}
+	if n, err := b.ReadAt(p, 10); n != 2 || err != io.EOF {
+		t.Errorf("ReadAt past end n:%v, err:%v", n, err)
+	}
+	if off, err := b.Seek(-5, io.SeekEnd); off != 7 || err != nil {
+		t.Errorf("Seek off:%v, err:%v", off, err)
+	}
+	if b.Len() != 5 {
+		t.Errorf("Len:%v, want:5", b.Len())
+	}
+	var w bytes.Buffer
+	if n, err := b.WriteTo(&w); n != 5 || err != nil || w.String() != "world" {
+		t.Errorf("WriteTo:%q, n:%v, err:%v", w.String(), n, err)
+	}
+	if n, err := b.Read(p); n != 0 || err != io.EOF {
+		t.Errorf("Read at end n:%v, err:%v", n, err)
+	}
+	b.Seek(0, io.SeekStart)
+	if all, err := ioutil.ReadAll(b); err != nil || string(all) != "hello, world" {
+		t.Errorf("ReadAll:%q, err:%v", all, err)
+	}
+	if _, err := b.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("negative seek succeeded")
+	}
+}
+
+func TestBufferZip(t *testing.T) {
+	data := buildZip(t, map[string]string{"a.txt": "zipped"}, zip.Deflate)
+	b, err := ReadAllBuffer(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read err:%v", err)
+	}
+	zr, err := zip.NewReader(b, b.Size())
+	if err != nil || len(zr.File) != 1 {
+		t.Fatalf("zip err:%v", err)
+	}
+	rc, _ := zr.File[0].Open()
+	body, err := ioutil.ReadAll(rc)
+	if err != nil || string(body) != "zipped" {
+		t.Errorf("entry:%q, err:%v", body, err)
+	}
+}
+
+func TestResultBuffer(t *testing.T) {
+	res := Result{Data: []byte("result")}
+	if b := res.Buffer(); string(b.Bytes()) != "result" || b.Len() != 6 {
+		t.Errorf("buffer:%q", b.Bytes())
+	}
+}
+
+var (
+	_ io.Writer     = (*Buffer)(nil)
+	_ io.ReaderFrom = (*Buffer)(nil)
+)
+
+func TestNewBuffer(t *testing.T) {
+	b := NewBuffer(10)
+	if cap(b.Bytes()) != 10 {
+		t.Errorf("cap:%v, want:10", cap(b.Bytes()))
+	}
+	b.Write([]byte("hello"))
+	b.Write([]byte("world"))
+	if string(b.Bytes()) != "helloworld" || cap(b.Bytes()) != 10 {
+		t.Errorf("data:%q, cap:%v", b.Bytes(), cap(b.Bytes()))
+	}
+	b.Write([]byte("!"))
+	if string(b.Bytes()) != "helloworld!" || cap(b.Bytes()) < 20 {
+		t.Errorf("grown data:%q, cap:%v", b.Bytes(), cap(b.Bytes()))
+	}
+	p := make([]byte, 5)
+	if n, _ := b.Read(p); n != 5 || string(p) != "hello" {
+		t.Errorf("read:%q", p)
+	}
+
+	b.Reset()
+	if b.Len() != 0 || b.Size() != 0 || cap(b.Bytes()) < 20 {
+		t.Errorf("reset len:%v, cap:%v", b.Len(), cap(b.Bytes()))
+	}
+	body := strings.Repeat("r", 5000)
+	if n, err := b.ReadFrom(strings.NewReader(body)); n != 5000 || err != nil || string(b.Bytes()) != body || cap(b.Bytes()) != 5001 {
+		t.Errorf("ReadFrom n:%v, cap:%v, err:%v", n, cap(b.Bytes()), err)
+	}
+	if n, err := b.ReadFrom(unsized{strings.NewReader(body)}); n != 5000 || err != nil || b.Size() != 10000 {
+		t.Errorf("unsized ReadFrom n:%v, size:%v, err:%v", n, b.Size(), err)
+	}
+
+	var zero Buffer
+	if n, err := io.Copy(&zero, strings.NewReader("copied")); n != 6 || err != nil || string(zero.Bytes()) != "copied" {
+		t.Errorf("copy:%q, err:%v", zero.Bytes(), err)
+	}
+}
@@ -0,0 +1,159 @@
+package readall
+
+import (
+	"io"
+)
+
+// DefaultBundleSlab is the slab size used by a Bundle created with a
+// non-positive slab size.
+const DefaultBundleSlab = 4 << 20
+
+// bundleRef locates one entry inside a Bundle. It holds no pointers, so the
+// index costs the garbage collector nothing beyond the keys themselves.
+type bundleRef struct {
+	slab uint32
+	off  int
+	len  int
+}
+
+// Bundle holds many named blobs (typically file contents loaded at startup)
+// in a few large slabs plus an index, instead of one []byte per entry. The
+// heap then has a handful of pointer-free objects to scan rather than
+// thousands.
+//
+// Add and Put must not run concurrently with each other or with Get;
+// concurrent Gets on a populated Bundle are safe.
+type Bundle struct {
+	slabSize int
+	slabs    [][]byte
+	index    map[string]int
+	refs     []bundleRef
+	size     int64
+}
+
+// NewBundle returns an empty Bundle that allocates slabs of slabSize bytes.
+// Entries larger than a slab get a slab of their own.
+func NewBundle(slabSize int) *Bundle {
+	if slabSize <= 0 {
+		slabSize = DefaultBundleSlab
+	}
+	return &Bundle{slabSize: slabSize, index: make(map[string]int)}
+}
+
+// Put copies data into the bundle under name, replacing any previous entry.
+// The space of a replaced entry is not reclaimed.
+func (b *Bundle) Put(name string, data []byte) {
+	slab, off := b.reserve(len(data))
+	b.slabs[slab] = append(b.slabs[slab], data...)
+	b.set(name, bundleRef{slab: slab, off: off, len: len(data)})
+}
+
+// Add reads r until EOF and stores the data under name. When r reports its
+// size the data is read straight into slab space; otherwise it is read into
+// the current slab, moving to a new slab when it runs out of room.
+func (b *Bundle) Add(name string, r io.Reader) (err error) {
+	defer annotate(&err, r)
+	want := int64(0)
+	if n, ok := sizeHint(r); ok {
+		want = n
+	}
+	return b.add(name, r, want)
+}
+
+// add reads r into the bundle, reserving want bytes up front.
+func (b *Bundle) add(name string, r io.Reader, want int64) error {
+	if want < 0 || want > int64(^uint(0)>>1) {
+		want = 0
+	}
+	slab, off := b.reserve(int(want))
+	buf := b.slabs[slab]
+	for {
+		var n int
+		var er error
+		if len(buf) < cap(buf) {
+			n, er = r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+		} else {
+			// Out of room, which is expected when want was exact: probe for
+			// EOF before moving the entry to a bigger slab.
+			var probe [1]byte
+			if n, er = r.Read(probe[:]); n > 0 {
+				got := len(buf) - off
+				nb := make([]byte, got, b.slabLen(2*got+1))
+				copy(nb, buf[off:])
+				b.slabs[slab] = b.slabs[slab][:off]
+				b.slabs = append(b.slabs, nb)
+				slab, off, buf = uint32(len(b.slabs)-1), 0, append(nb, probe[0])
+			}
+		}
+		if er == io.EOF {
+			break
+		}
+		if er != nil {
+			b.slabs[slab] = buf[:off]
+			return er
+		}
+	}
+	b.slabs[slab] = buf
+	b.set(name, bundleRef{slab: slab, off: off, len: len(buf) - off})
+	return nil
+}
+
+// Get returns the data stored under name. The slice aliases slab memory and
+// must not be modified; its capacity is clipped so appends copy.
+func (b *Bundle) Get(name string) ([]byte, bool) {
+	i, ok := b.index[name]
+	if !ok {
+		return nil, false
+	}
+	ref := b.refs[i]
+	return b.slabs[ref.slab][ref.off : ref.off+ref.len : ref.off+ref.len], true
+}
+
+// Len returns the number of entries.
+func (b *Bundle) Len() int {
+	return len(b.index)
+}
+
+// Size returns the total length of the live entries.
+func (b *Bundle) Size() int64 {
+	return b.size
+}
+
+// Names calls fn for every entry name in unspecified order.
+func (b *Bundle) Names(fn func(name string)) {
+	for name := range b.index {
+		fn(name)
+	}
+}
+
+// reserve returns a slab with at least n free bytes and the offset at which
+// the free space starts.
+func (b *Bundle) reserve(n int) (uint32, int) {
+	if last := len(b.slabs) - 1; last >= 0 {
+		s := b.slabs[last]
+		if cap(s)-len(s) >= n {
+			return uint32(last), len(s)
+		}
+	}
+	b.slabs = append(b.slabs, make([]byte, 0, b.slabLen(n)))
+	return uint32(len(b.slabs) - 1), 0
+}
+
+func (b *Bundle) slabLen(n int) int {
+	if n > b.slabSize {
+		return n
+	}
+	return b.slabSize
+}
+
+func (b *Bundle) set(name string, ref bundleRef) {
+	if i, ok := b.index[name]; ok {
+		b.size -= int64(b.refs[i].len)
+		b.refs[i] = ref
+	} else {
+		b.index[name] = len(b.refs)
+		b.refs = append(b.refs, ref)
+	}
+	b.size += int64(ref.len)
+}
@@ -0,0 +1,72 @@
+package readall
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBundle(t *testing.T) {
+	b := NewBundle(1024)
+	want := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("asset-%d", i)
+		body := strings.Repeat(string(rune('a'+i%26)), i*3)
+		var err error
+		switch i % 3 {
+		case 0:
+			b.Put(name, []byte(body))
+		case 1:
+			err = b.Add(name, strings.NewReader(body))
+		case 2:
+			err = b.Add(name, iotest.OneByteReader(strings.NewReader(body)))
+		}
+		if err != nil {
+			t.Errorf("add %v err:%v", name, err)
+			return
+		}
+		want[name] = body
+	}
+	if b.Len() != len(want) {
+		t.Errorf("len:%v, want:%v", b.Len(), len(want))
+	}
+	var total int64
+	for name, body := range want {
+		got, ok := b.Get(name)
+		if !ok || string(got) != body {
+			t.Errorf("get %v:%q, ok:%v, want:%q", name, got, ok, body)
+		}
+		total += int64(len(body))
+	}
+	if b.Size() != total {
+		t.Errorf("size:%v, want:%v", b.Size(), total)
+	}
+	if len(b.slabs) > 10 {
+		t.Errorf("slabs:%v for %v entries", len(b.slabs), len(want))
+	}
+
+	b.Put("asset-1", []byte("replaced"))
+	if got, _ := b.Get("asset-1"); string(got) != "replaced" {
+		t.Errorf("replaced:%q", got)
+	}
+	got, _ := b.Get("asset-2")
+	if cap(got) != len(got) {
+		t.Errorf("cap:%v, len:%v", cap(got), len(got))
+	}
+	if _, ok := b.Get("missing"); ok {
+		t.Errorf("missing entry found")
+	}
+}
+
+func TestBundleAddError(t *testing.T) {
+	b := NewBundle(0)
+	r := iotest.TimeoutReader(bytes.NewReader(make([]byte, 10)))
+	if err := b.Add("x", iotest.OneByteReader(r)); err == nil {
+		t.Errorf("add err:%v", err)
+	}
+	if _, ok := b.Get("x"); ok || b.Size() != 0 {
+		t.Errorf("failed entry stored, size:%v", b.Size())
+	}
+}
@@ -0,0 +1,76 @@
+package readall
+
+import (
+	"context"
+	"errors"
+)
+
+// StopReason classifies why a read ended before EOF, so dashboards can break
+// aborted reads down by cause.
+type StopReason string
+
+const (
+	// StopNone means the read completed or failed for another reason.
+	StopNone StopReason = ""
+	// StopCanceled means the context of the read was canceled.
+	StopCanceled StopReason = "canceled"
+	// StopDeadline means the deadline of the read's context passed.
+	StopDeadline StopReason = "deadline"
+	// StopStalled means the source made no progress (ErrStalled).
+	StopStalled StopReason = "stalled"
+	// StopBudget means the memory budget shed the read (ErrBudgetExceeded).
+	StopBudget StopReason = "budget"
+	// StopKilled means an operator killed the read (ErrReadKilled).
+	StopKilled StopReason = "killed"
+	// StopShutdown means Shutdown aborted the read (ErrShutdown).
+	StopShutdown StopReason = "shutdown"
+)
+
+// Reason returns why the read that returned err stopped. The cause a context
+// was canceled with (context.WithCancelCause and friends, Go 1.20 and later)
+// is part of the errors of the context-aware reads, so canceling with, say,
+// ErrStalled is reported as StopStalled rather than StopCanceled.
+func Reason(err error) StopReason {
+	switch {
+	case err == nil:
+		return StopNone
+	case errors.Is(err, ErrShutdown):
+		return StopShutdown
+	case errors.Is(err, ErrReadKilled):
+		return StopKilled
+	case errors.Is(err, ErrStalled):
+		return StopStalled
+	case errors.Is(err, ErrBudgetExceeded):
+		return StopBudget
+	case errors.Is(err, context.DeadlineExceeded):
+		return StopDeadline
+	case errors.Is(err, context.Canceled):
+		return StopCanceled
+	}
+	return StopNone
+}
+
+// causeError is a context error together with the cause the context was
+// canceled with. It matches both.
+type causeError struct {
+	err   error
+	cause error
+}
+
+func (e *causeError) Error() string {
+	return e.err.Error() + ": " + e.cause.Error()
+}
+
+func (e *causeError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
+
+// ctxErr returns ctx.Err(), joined with the cause of the cancellation when
+// one other than ctx.Err() itself was given.
+func ctxErr(ctx context.Context) error {
+	err := ctx.Err()
+	if cause := contextCause(ctx); cause != nil && cause != err {
+		return &causeError{err: err, cause: cause}
+	}
+	return err
+}
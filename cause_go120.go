@@ -0,0 +1,9 @@
+//go:build go1.20
+
+package readall
+
+import "context"
+
+func contextCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
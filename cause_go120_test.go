@@ -0,0 +1,31 @@
+//go:build go1.20
+
+package readall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadAllContextCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	f := Go(ctx, pr)
+	cancel(ErrStalled)
+	err := f.Wait()
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrStalled) {
+		t.Errorf("err:%v, want %v and %v", err, context.Canceled, ErrStalled)
+	}
+	if r := Reason(err); r != StopStalled {
+		t.Errorf("reason:%q, want:%q", r, StopStalled)
+	}
+
+	ctx, cancel = context.WithCancelCause(context.Background())
+	cancel(nil)
+	if _, err := ReadAllContext(ctx, pr); err != context.Canceled {
+		t.Errorf("no cause err:%v, want:%v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !go1.20
+
+package readall
+
+import "context"
+
+// contextCause has nothing to report before Go 1.20, which added
+// context.Cause.
+func contextCause(ctx context.Context) error {
+	return nil
+}
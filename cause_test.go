@@ -0,0 +1,28 @@
+package readall
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestReason(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want StopReason
+	}{
+		{nil, StopNone},
+		{ErrChecksumMismatch, StopNone},
+		{context.Canceled, StopCanceled},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), StopDeadline},
+		{withSource("f", ErrStalled), StopStalled},
+		{ErrBudgetExceeded, StopBudget},
+		{ErrReadKilled, StopKilled},
+		{ErrShutdown, StopShutdown},
+		{&causeError{err: context.Canceled, cause: ErrReadKilled}, StopKilled},
+	} {
+		if got := Reason(c.err); got != c.want {
+			t.Errorf("reason of %v:%q, want:%q", c.err, got, c.want)
+		}
+	}
+}
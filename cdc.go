@@ -0,0 +1,148 @@
+package readall
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// ErrInvalidCDC is returned when CDCOptions sizes are not 0 < Min <= Avg <= Max.
+var ErrInvalidCDC = errors.New("readall: invalid content-defined chunk sizes")
+
+// CDCOptions configures the content-defined chunker. Zero values select
+// 2KiB/8KiB/64KiB for Min/Avg/Max.
+type CDCOptions struct {
+	Min int
+	Avg int
+	Max int
+}
+
+// CDCChunk describes one content-defined chunk of a stream.
+type CDCChunk struct {
+	Offset int64
+	Length int
+	Sum    [sha256.Size]byte
+}
+
+// gear is the FastCDC rolling hash table, filled from a fixed splitmix64
+// sequence so chunk boundaries are stable across processes and releases.
+var gear [256]uint64
+
+func init() {
+	var x uint64 = 0x9e3779b97f4a7c15
+	for i := range gear {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+type cdc struct {
+	min, avg, max int
+	maskS, maskL  uint64
+}
+
+func newCDC(opt CDCOptions) (*cdc, error) {
+	if opt.Min == 0 && opt.Avg == 0 && opt.Max == 0 {
+		opt = CDCOptions{Min: 2 << 10, Avg: 8 << 10, Max: 64 << 10}
+	}
+	if opt.Min <= 0 || opt.Min > opt.Avg || opt.Avg > opt.Max {
+		return nil, ErrInvalidCDC
+	}
+	b := bits.Len(uint(opt.Avg)) - 1
+	if b < 2 {
+		b = 2
+	}
+	// Normalized chunking: a stricter mask before Avg and a looser one after
+	// it pulls chunk sizes towards Avg. Masks use the high bits, which depend
+	// on the last 64 bytes rather than only the most recent few.
+	return &cdc{
+		min:   opt.Min,
+		avg:   opt.Avg,
+		max:   opt.Max,
+		maskS: ^uint64(0) << uint(64-(b+1)),
+		maskL: ^uint64(0) << uint(64-(b-1)),
+	}, nil
+}
+
+// cut returns the length of the first chunk of p. When p is shorter than
+// max and more input may follow, the caller must not trust a cut at len(p).
+func (c *cdc) cut(p []byte) int {
+	n := len(p)
+	if n <= c.min {
+		return n
+	}
+	if n > c.max {
+		n = c.max
+	}
+	normal := c.avg
+	if normal > n {
+		normal = n
+	}
+	var fp uint64
+	i := c.min
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gear[p[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[p[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// ChunkCDC splits r into content-defined chunks (FastCDC) and calls fn for
+// each one in order. data is only valid until fn returns, so at most Max
+// bytes of the stream are held in memory at a time.
+func ChunkCDC(r io.Reader, opt CDCOptions, fn func(c CDCChunk, data []byte) error) (err error) {
+	defer annotate(&err, r)
+	c, err := newCDC(opt)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, 2*c.max)
+	var off int64
+	eof := false
+	for {
+		for !eof && len(buf) < c.max {
+			n, er := r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if er == io.EOF {
+				eof = true
+			} else if er != nil {
+				return er
+			}
+		}
+		if len(buf) == 0 {
+			return nil
+		}
+		n := c.cut(buf)
+		chunk := CDCChunk{Offset: off, Length: n, Sum: sha256.Sum256(buf[:n])}
+		if err := fn(chunk, buf[:n]); err != nil {
+			return err
+		}
+		off += int64(n)
+		buf = buf[:copy(buf, buf[n:])]
+	}
+}
+
+// ReadAllCDC reads r until EOF and returns the data together with its
+// content-defined chunk list.
+func ReadAllCDC(r io.Reader, opt CDCOptions) ([]byte, []CDCChunk, error) {
+	var data []byte
+	var chunks []CDCChunk
+	err := ChunkCDC(r, opt, func(c CDCChunk, p []byte) error {
+		data = append(data, p...)
+		chunks = append(chunks, c)
+		return nil
+	})
+	return data, chunks, err
+}
@@ -0,0 +1,66 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+)
+
+func TestReadAllCDC(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	opt := CDCOptions{Min: 1 << 10, Avg: 4 << 10, Max: 16 << 10}
+	got, chunks, err := ReadAllCDC(bytes.NewReader(data), opt)
+	if err != nil {
+		t.Errorf("cdc err:%v", err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("data mismatch, len:%v, want:%v", len(got), len(data))
+		return
+	}
+	var off int64
+	for i, c := range chunks {
+		if c.Offset != off {
+			t.Errorf("chunk %v offset:%v, want:%v", i, c.Offset, off)
+			return
+		}
+		if c.Length > opt.Max || (c.Length < opt.Min && i != len(chunks)-1) {
+			t.Errorf("chunk %v length:%v out of range", i, c.Length)
+		}
+		if c.Sum != sha256.Sum256(data[off:off+int64(c.Length)]) {
+			t.Errorf("chunk %v sum mismatch", i)
+		}
+		off += int64(c.Length)
+	}
+
+	// Boundaries are content defined, so a prefix insertion only disturbs
+	// the chunks around it.
+	shifted := append([]byte("inserted prefix"), data...)
+	_, moved, err := ReadAllCDC(bytes.NewReader(shifted), opt)
+	if err != nil {
+		t.Errorf("cdc err:%v", err)
+		return
+	}
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, c := range chunks {
+		seen[c.Sum] = true
+	}
+	var shared int
+	for _, c := range moved {
+		if seen[c.Sum] {
+			shared++
+		}
+	}
+	if shared < len(chunks)-2 {
+		t.Errorf("shared chunks:%v of %v", shared, len(chunks))
+	}
+}
+
+func TestChunkCDCInvalid(t *testing.T) {
+	err := ChunkCDC(bytes.NewReader(nil), CDCOptions{Min: 8, Avg: 4, Max: 16}, nil)
+	if err != ErrInvalidCDC {
+		t.Errorf("cdc err:%v, want:%v", err, ErrInvalidCDC)
+	}
+}
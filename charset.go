@@ -0,0 +1,230 @@
+package readall
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedCharset is returned for a charset without a registered
+// decoder.
+var ErrUnsupportedCharset = errors.New("readall: unsupported charset")
+
+var (
+	charsetMu sync.RWMutex
+	charsets  = map[string]func(io.Reader) io.Reader{
+		"utf-8":      identityCharset,
+		"utf8":       identityCharset,
+		"us-ascii":   identityCharset,
+		"iso-8859-1": latin1Decoder,
+		"latin1":     latin1Decoder,
+		"utf-16":     utf16Decoder(false),
+		"utf-16be":   utf16Decoder(false),
+		"utf-16le":   utf16Decoder(true),
+		"utf-32":     utf32Decoder(false),
+		"utf-32be":   utf32Decoder(false),
+		"utf-32le":   utf32Decoder(true),
+	}
+)
+
+// RegisterCharset makes ReadAllCharset and ReadResponseCharset transcode
+// charset to UTF-8 with fn, which returns a reader of the UTF-8 text of r.
+// With golang.org/x/text that is a new decoder per call, as decoders are
+// not safe for concurrent use:
+//
+//	readall.RegisterCharset("gbk", func(r io.Reader) io.Reader {
+//		return simplifiedchinese.GBK.NewDecoder().Reader(r)
+//	})
+//
+// UTF-8, US-ASCII, ISO-8859-1, UTF-16 and UTF-32 are built in. It panics if
+// charset is empty or already registered.
+func RegisterCharset(charset string, fn func(io.Reader) io.Reader) {
+	charset = strings.ToLower(charset)
+	charsetMu.Lock()
+	defer charsetMu.Unlock()
+	if charset == "" || fn == nil {
+		panic("readall: invalid charset registration " + charset)
+	}
+	if _, dup := charsets[charset]; dup {
+		panic("readall: RegisterCharset called twice for " + charset)
+	}
+	charsets[charset] = fn
+}
+
+// ReadAllCharset reads r, text in charset, transcoding it to UTF-8 as it
+// goes, so the undecoded text is never held in full next to the result. A
+// byte-order mark overrides charset and is removed; an empty charset
+// without one means UTF-8. WithLimit bounds the UTF-8 output.
+func ReadAllCharset(r io.Reader, charset string, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	dr, err := charsetReader(r, charset)
+	if err != nil {
+		return nil, err
+	}
+	o := readOptions(opts)
+	return readAllCap(dr, o.capacity(dr), o)
+}
+
+// ReadResponseCharset is ReadResponse transcoding the body to UTF-8 from
+// the charset of its Content-Type header or, without one, of its
+// byte-order mark, as ReadAllCharset does. Content-Length and integrity
+// checks apply to the body as sent; WithLimit bounds the UTF-8 output.
+func ReadResponseCharset(rsp *http.Response, opts ...Option) ([]byte, error) {
+	return readResponse(rsp, -1, false, true, opts)
+}
+
+// contentCharset returns the charset parameter of the Content-Type of h.
+func contentCharset(h http.Header) string {
+	_, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// charsetReader returns a reader of r transcoded from charset to UTF-8.
+func charsetReader(r io.Reader, charset string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	if enc := detectBOM(head); enc.BOM {
+		charset = enc.Charset
+		br.Discard(enc.Stripped)
+	}
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" {
+		charset = "utf-8"
+	}
+	charsetMu.RLock()
+	fn, ok := charsets[charset]
+	charsetMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCharset, charset)
+	}
+	return wrappedReader{Reader: fn(br), src: r}, nil
+}
+
+func identityCharset(r io.Reader) io.Reader {
+	return r
+}
+
+func latin1Decoder(r io.Reader) io.Reader {
+	return newRuneDecoder(r, func(br *bufio.Reader) (rune, error) {
+		b, err := br.ReadByte()
+		return rune(b), err
+	})
+}
+
+func utf16Decoder(little bool) func(io.Reader) io.Reader {
+	unit := func(br *bufio.Reader) (rune, error) {
+		var b [2]byte
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return 0, err
+		}
+		if little {
+			return rune(b[0]) | rune(b[1])<<8, nil
+		}
+		return rune(b[0])<<8 | rune(b[1]), nil
+	}
+	return func(r io.Reader) io.Reader {
+		return newRuneDecoder(r, func(br *bufio.Reader) (rune, error) {
+			r1, err := unit(br)
+			if err != nil || !utf16.IsSurrogate(r1) {
+				return r1, err
+			}
+			// Only a high surrogate followed by a low one is valid; peek
+			// so a lone surrogate does not swallow the next unit.
+			next, err := br.Peek(2)
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+			if len(next) < 2 || r1 >= 0xdc00 {
+				return utf8.RuneError, nil
+			}
+			var r2 rune
+			if little {
+				r2 = rune(next[0]) | rune(next[1])<<8
+			} else {
+				r2 = rune(next[0])<<8 | rune(next[1])
+			}
+			r := utf16.DecodeRune(r1, r2)
+			if r != utf8.RuneError {
+				br.Discard(2)
+			}
+			return r, nil
+		})
+	}
+}
+
+func utf32Decoder(little bool) func(io.Reader) io.Reader {
+	return func(r io.Reader) io.Reader {
+		return newRuneDecoder(r, func(br *bufio.Reader) (rune, error) {
+			var b [4]byte
+			if _, err := io.ReadFull(br, b[:]); err != nil {
+				return 0, err
+			}
+			var u uint32
+			if little {
+				u = uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+			} else {
+				u = uint32(b[3]) | uint32(b[2])<<8 | uint32(b[1])<<16 | uint32(b[0])<<24
+			}
+			if u > utf8.MaxRune || !utf8.ValidRune(rune(u)) {
+				return utf8.RuneError, nil
+			}
+			return rune(u), nil
+		})
+	}
+}
+
+// runeDecoder encodes the runes returned by next as UTF-8. A rune cut
+// short by the end of the input becomes U+FFFD.
+type runeDecoder struct {
+	br   *bufio.Reader
+	next func(*bufio.Reader) (rune, error)
+	pend []byte
+	buf  [utf8.UTFMax]byte
+	err  error
+}
+
+func newRuneDecoder(r io.Reader, next func(*bufio.Reader) (rune, error)) *runeDecoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &runeDecoder{br: br, next: next}
+}
+
+func (d *runeDecoder) Read(p []byte) (int, error) {
+	n := copy(p, d.pend)
+	d.pend = d.pend[n:]
+	for n < len(p) && d.err == nil {
+		r, err := d.next(d.br)
+		switch err {
+		case nil:
+		case io.ErrUnexpectedEOF:
+			r, d.err = utf8.RuneError, io.EOF
+		default:
+			d.err = err
+			continue
+		}
+		if len(p)-n >= utf8.UTFMax {
+			n += utf8.EncodeRune(p[n:], r)
+			continue
+		}
+		w := utf8.EncodeRune(d.buf[:], r)
+		c := copy(p[n:], d.buf[:w])
+		n += c
+		d.pend = d.buf[c:w]
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, d.err
+}
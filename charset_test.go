@@ -0,0 +1,101 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, little bool) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if little {
+			b = append(b, byte(u), byte(u>>8))
+		} else {
+			b = append(b, byte(u>>8), byte(u))
+		}
+	}
+	return b
+}
+
+func TestReadAllCharset(t *testing.T) {
+	text := "héllo, 世界 😀"
+	cases := []struct {
+		name    string
+		data    []byte
+		charset string
+		want    string
+	}{
+		{"utf8", []byte(text), "", text},
+		{"utf8bom", append([]byte{0xef, 0xbb, 0xbf}, text...), "UTF-8", text},
+		{"latin1", []byte{'c', 'a', 'f', 0xe9}, "ISO-8859-1", "café"},
+		{"utf16le", utf16Bytes(text, true), "utf-16le", text},
+		{"utf16be", utf16Bytes(text, false), "UTF-16BE", text},
+		{"utf16bom", append([]byte{0xff, 0xfe}, utf16Bytes(text, true)...), "", text},
+		{"bomwins", append([]byte{0xfe, 0xff}, utf16Bytes(text, false)...), "iso-8859-1", text},
+		{"utf32le", []byte{0xff, 0xfe, 0, 0, 0x16, 0x4e, 0, 0}, "", "世"},
+		{"lonesurrogate", []byte{0xd8, 0x3d, 0, 'a'}, "utf-16be", "�a"},
+		{"oddtail", []byte{'a', 0, 'b'}, "utf-16le", "a�"},
+	}
+	for _, c := range cases {
+		got, err := ReadAllCharset(iotest.OneByteReader(bytes.NewReader(c.data)), c.charset)
+		if err != nil || string(got) != c.want {
+			t.Errorf("%v got:%q, err:%v, want:%q", c.name, got, err, c.want)
+		}
+	}
+}
+
+func TestReadAllCharsetErrors(t *testing.T) {
+	if _, err := ReadAllCharset(strings.NewReader("x"), "klingon"); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("unknown charset err:%v, want:%v", err, ErrUnsupportedCharset)
+	}
+	latin := bytes.Repeat([]byte{0xe9}, 100)
+	if _, err := ReadAllCharset(bytes.NewReader(latin), "latin1", WithLimit(150)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("output limit err:%v, want:%v", err, ErrLimitExceeded)
+	}
+}
+
+func TestRegisterCharset(t *testing.T) {
+	RegisterCharset("X-Upper-Test", func(r io.Reader) io.Reader {
+		b, _ := ioutil.ReadAll(r)
+		return strings.NewReader(strings.ToUpper(string(b)))
+	})
+	got, err := ReadAllCharset(strings.NewReader("shout"), "x-upper-test")
+	if err != nil || string(got) != "SHOUT" {
+		t.Errorf("got:%q, err:%v", got, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate registration did not panic")
+		}
+	}()
+	RegisterCharset("x-upper-test", identityCharset)
+}
+
+func TestReadResponseCharset(t *testing.T) {
+	body := []byte{'c', 'a', 'f', 0xe9}
+	rsp := &http.Response{
+		Header:        http.Header{"Content-Type": {`text/plain; charset="ISO-8859-1"`}},
+		ContentLength: int64(len(body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+	}
+	got, err := ReadResponseCharset(rsp)
+	if err != nil || string(got) != "café" {
+		t.Errorf("got:%q, err:%v", got, err)
+	}
+
+	rsp = &http.Response{
+		Header:        http.Header{"Content-Type": {"text/plain"}},
+		ContentLength: 10,
+		Body:          ioutil.NopCloser(bytes.NewReader(append([]byte{0xff, 0xfe}, utf16Bytes("hi", true)...))),
+	}
+	if got, err := ReadResponseCharset(rsp); !errors.Is(err, ErrShortBody) || string(got) != "hi" {
+		t.Errorf("short body got:%q, err:%v", got, err)
+	}
+}
@@ -0,0 +1,215 @@
+// Command readallbench compares ways of reading a file into memory under
+// concurrency, replacing the constants of the comparison tests:
+//
+//	go run ./cmd/readallbench -file X -concurrency 10 -iters 100 -strategy readall,copy,mmap,parallel
+//
+// For every strategy it reports p50, p95 and max latency, throughput and
+// allocations per read, as a table or, with -format json, as JSON.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"readall"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// strategies maps a -strategy name to a read of the whole file at path.
+var strategies = map[string]func(path string) (int, error){
+	"readall": func(path string) (int, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		b, err := readall.ReadAll(f)
+		return len(b), err
+	},
+	"ioutil": func(path string) (int, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		return len(b), err
+	},
+	"copy": func(path string) (int, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, f)
+		return int(n), err
+	},
+	"mmap": func(path string) (int, error) {
+		res, err := readall.ReadFile(path, readall.WithMmap(readall.MmapAlways))
+		if err != nil {
+			return 0, err
+		}
+		n := len(res.Data)
+		if res.Release != nil {
+			res.Release()
+		}
+		return n, nil
+	},
+	"parallel": func(path string) (int, error) {
+		b, err := readall.ParallelReadFile(path, 0, 0)
+		return len(b), err
+	},
+}
+
+// Result is the report of one strategy.
+type Result struct {
+	Strategy    string        `json:"strategy"`
+	Iters       int           `json:"iters"`
+	Concurrency int           `json:"concurrency"`
+	Bytes       int64         `json:"bytes"`
+	P50         time.Duration `json:"p50_ns"`
+	P95         time.Duration `json:"p95_ns"`
+	Max         time.Duration `json:"max_ns"`
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	MBPerSec    float64       `json:"mb_per_sec"`
+	AllocsPerOp uint64        `json:"allocs_per_op"`
+	BytesPerOp  uint64        `json:"bytes_per_op"`
+}
+
+func main() {
+	file := flag.String("file", "", "file to read (required)")
+	concurrency := flag.Int("concurrency", 10, "reads in flight at a time")
+	iters := flag.Int("iters", 100, "reads per strategy")
+	strategy := flag.String("strategy", "readall,copy", "comma-separated strategies: "+strategyNames())
+	format := flag.String("format", "table", "output format: table or json")
+	flag.Parse()
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "readallbench: -file is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	var results []Result
+	for _, name := range strings.Split(*strategy, ",") {
+		res, err := run(strings.TrimSpace(name), *file, *iters, *concurrency)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "readallbench:", err)
+			os.Exit(1)
+		}
+		results = append(results, res)
+	}
+	if err := write(os.Stdout, *format, results); err != nil {
+		fmt.Fprintln(os.Stderr, "readallbench:", err)
+		os.Exit(1)
+	}
+}
+
+func strategyNames() string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// run reads path iters times with strategy name, concurrency reads at a
+// time.
+func run(name, path string, iters, concurrency int) (Result, error) {
+	read, ok := strategies[name]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown strategy %q, want one of %s", name, strategyNames())
+	}
+	if iters < 1 || concurrency < 1 {
+		return Result{}, errors.New("iters and concurrency must be positive")
+	}
+	res := Result{Strategy: name, Iters: iters, Concurrency: concurrency}
+	lat := make([]time.Duration, iters)
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	next := make(chan int)
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				t := time.Now()
+				n, err := read(path)
+				lat[i] = time.Since(t)
+				mu.Lock()
+				res.Bytes += int64(n)
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", name, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < iters; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+	runtime.ReadMemStats(&after)
+	if firstErr != nil {
+		return res, firstErr
+	}
+
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	res.P50 = percentile(lat, 50)
+	res.P95 = percentile(lat, 95)
+	res.Max = lat[len(lat)-1]
+	if s := res.Elapsed.Seconds(); s > 0 {
+		res.MBPerSec = float64(res.Bytes) / s / 1e6
+	}
+	res.AllocsPerOp = (after.Mallocs - before.Mallocs) / uint64(iters)
+	res.BytesPerOp = (after.TotalAlloc - before.TotalAlloc) / uint64(iters)
+	return res, nil
+}
+
+// percentile returns the p-th percentile of the sorted lat, nearest rank.
+func percentile(lat []time.Duration, p int) time.Duration {
+	rank := (len(lat)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return lat[rank-1]
+}
+
+func write(w io.Writer, format string, results []Result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "STRATEGY\tP50\tP95\tMAX\tMB/S\tALLOCS/OP\tBYTES/OP\t")
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%.1f\t%d\t%d\t\n", r.Strategy,
+				r.P50.Round(time.Microsecond), r.P95.Round(time.Microsecond), r.Max.Round(time.Microsecond),
+				r.MBPerSec, r.AllocsPerOp, r.BytesPerOp)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown format %q, want table or json", format)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	ioutil.WriteFile(path, bytes.Repeat([]byte("x"), 1<<16), 0644)
+	var results []Result
+	for name := range strategies {
+		res, err := run(name, path, 8, 3)
+		if err != nil || res.Bytes != 8<<16 || res.P50 > res.P95 || res.P95 > res.Max {
+			t.Errorf("%v result:%+v, err:%v", name, res, err)
+		}
+		results = append(results, res)
+	}
+	if _, err := run("bogus", path, 1, 1); err == nil {
+		t.Errorf("unknown strategy accepted")
+	}
+	if _, err := run("readall", filepath.Join(t.TempDir(), "missing"), 1, 1); err == nil {
+		t.Errorf("missing file accepted")
+	}
+
+	var out bytes.Buffer
+	if err := write(&out, "table", results); err != nil || !strings.Contains(out.String(), "readall") {
+		t.Errorf("table:\n%s, err:%v", out.String(), err)
+	}
+	out.Reset()
+	var decoded []Result
+	if err := write(&out, "json", results); err != nil || json.Unmarshal(out.Bytes(), &decoded) != nil || len(decoded) != len(results) {
+		t.Errorf("json:\n%s, err:%v", out.String(), err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	lat := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if p := percentile(lat, 50); p != 5 {
+		t.Errorf("p50:%v, want:5", p)
+	}
+	if p := percentile(lat, 95); p != 10 {
+		t.Errorf("p95:%v, want:10", p)
+	}
+}
@@ -0,0 +1,74 @@
+package readall
+
+import (
+	"io"
+	"time"
+)
+
+// WithCoalesce batches the tiny Reads of chatty sources such as
+// line-buffered pipes: a Read of the source returning fewer than min bytes
+// is followed by more Reads into the same buffer until min bytes have
+// arrived, the source fails or ends, or maxDelay has passed since the first
+// byte. Progress, rate limiting, stats and tracking then see one larger read
+// instead of many small ones. On sources with a SetReadDeadline method
+// (net.Conn, *os.File pipes) maxDelay interrupts a blocked Read, unless
+// WithIdleTimeout already uses the deadline; elsewhere it is checked between
+// Reads.
+func WithCoalesce(min int, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.coalesceMin = min
+		o.coalesceDelay = maxDelay
+	}
+}
+
+// withCoalesce wraps src if o coalesces reads.
+func (o *options) withCoalesce(src io.Reader) io.Reader {
+	if o.coalesceMin <= 1 || o.coalesceDelay <= 0 {
+		return src
+	}
+	c := &coalesceReader{r: src, min: o.coalesceMin, delay: o.coalesceDelay}
+	if d, ok := src.(deadliner); ok && o.idle <= 0 {
+		c.c = d
+	}
+	return c
+}
+
+type coalesceReader struct {
+	r     io.Reader
+	c     deadliner // nil if the delay is only checked between Reads
+	min   int
+	delay time.Duration
+}
+
+func (c *coalesceReader) Read(p []byte) (int, error) {
+	want := c.min
+	if want > len(p) {
+		want = len(p)
+	}
+	n, err := c.r.Read(p)
+	if n == 0 || n >= want || err != nil {
+		return n, err
+	}
+	deadline := time.Now().Add(c.delay)
+	timed := c.c != nil && c.c.SetReadDeadline(deadline) == nil
+	if timed {
+		defer c.c.SetReadDeadline(time.Time{})
+	}
+	for n < want && time.Now().Before(deadline) {
+		m, err := c.r.Read(p[n:])
+		n += m
+		if isTimeout(err) {
+			// The bytes so far go up now; a deadline other than ours
+			// fails the next Read again.
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (c *coalesceReader) Unwrap() io.Reader {
+	return c.r
+}
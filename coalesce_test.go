@@ -0,0 +1,60 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestWithCoalesce(t *testing.T) {
+	data := strings.Repeat("line\n", 2000)
+	var s Stats
+	got, err := ReadAll(iotest.OneByteReader(strings.NewReader(data)), WithCoalesce(1000, time.Second), WithStats(&s))
+	if err != nil || string(got) != data {
+		t.Fatalf("readall len:%v, err:%v", len(got), err)
+	}
+	if s.Reads > 30 {
+		t.Errorf("reads:%v, want coalesced", s.Reads)
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe err:%v", err)
+	}
+	defer pr.Close()
+	go func() {
+		pw.Write([]byte("first"))
+		time.Sleep(200 * time.Millisecond)
+		pw.Write([]byte("second"))
+		pw.Close()
+	}()
+	var reads []int
+	c := (&options{coalesceMin: 1 << 10, coalesceDelay: 20 * time.Millisecond}).withCoalesce(pr)
+	buf := make([]byte, 4096)
+	start := time.Now()
+	for {
+		n, err := c.Read(buf)
+		if n > 0 {
+			reads = append(reads, n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read err:%v", err)
+		}
+	}
+	if len(reads) != 2 || reads[0] != 5 || reads[1] != 6 {
+		t.Errorf("reads:%v, want [5 6]", reads)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("coalescing held data too long")
+	}
+	if got, _ := ReadAll(bytes.NewReader(nil), WithCoalesce(100, time.Second)); len(got) != 0 {
+		t.Errorf("empty len:%v", len(got))
+	}
+}
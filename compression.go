@@ -0,0 +1,110 @@
+package readall
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// CompressionStat aggregates the reads of one source that went through a
+// Decompress stage.
+type CompressionStat struct {
+	// Reads counts completed reads; Compressed counts those whose data was
+	// gzip or zlib. Reads-Compressed is data served uncompressed.
+	Reads      int64
+	Compressed int64
+	// BytesIn is read from the source, BytesOut is produced by the stage.
+	BytesIn  int64
+	BytesOut int64
+}
+
+// Ratio returns BytesOut/BytesIn, or 0 when nothing was read.
+func (s CompressionStat) Ratio() float64 {
+	if s.BytesIn == 0 {
+		return 0
+	}
+	return float64(s.BytesOut) / float64(s.BytesIn)
+}
+
+type compressionCounter struct {
+	reads, compressed, in, out int64
+}
+
+var (
+	compression      sync.Map // key -> *compressionCounter
+	compressionCount int64
+)
+
+// CompressionStats reports, per source, how reads through Decompress
+// compressed since the process started, to find endpoints that would benefit
+// from enabling compression upstream. Sources are keyed by WithLabel, or by
+// their redacted name when the read has no label; like SizeDistribution, at
+// most 1000 keys are tracked.
+func CompressionStats() map[string]CompressionStat {
+	stats := make(map[string]CompressionStat)
+	compression.Range(func(k, v interface{}) bool {
+		c := v.(*compressionCounter)
+		stats[k.(string)] = CompressionStat{
+			Reads:      atomic.LoadInt64(&c.reads),
+			Compressed: atomic.LoadInt64(&c.compressed),
+			BytesIn:    atomic.LoadInt64(&c.in),
+			BytesOut:   atomic.LoadInt64(&c.out),
+		}
+		return true
+	})
+	return stats
+}
+
+// decompressRun counts the bytes around one Decompress stage of a pipeline run.
+type decompressRun struct {
+	in, out    int64
+	compressed bool
+}
+
+// stage returns a Transform decompressing like decompress and counting into d.
+func (d *decompressRun) stage(r io.Reader) (io.Reader, error) {
+	*d = decompressRun{}
+	dr, err := decompress(&countingReader{r: r, n: &d.in})
+	if err != nil {
+		return nil, err
+	}
+	_, plain := dr.(wrappedReader).Reader.(*bufio.Reader)
+	d.compressed = !plain
+	return &countingReader{r: dr, n: &d.out}, nil
+}
+
+func (d *decompressRun) observe(key string) {
+	v, ok := compression.Load(key)
+	if !ok {
+		if atomic.LoadInt64(&compressionCount) >= maxSizeLabels {
+			return
+		}
+		var loaded bool
+		if v, loaded = compression.LoadOrStore(key, new(compressionCounter)); !loaded {
+			atomic.AddInt64(&compressionCount, 1)
+		}
+	}
+	c := v.(*compressionCounter)
+	atomic.AddInt64(&c.reads, 1)
+	if d.compressed {
+		atomic.AddInt64(&c.compressed, 1)
+	}
+	atomic.AddInt64(&c.in, d.in)
+	atomic.AddInt64(&c.out, d.out)
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) Unwrap() io.Reader {
+	return c.r
+}
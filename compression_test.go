@@ -0,0 +1,32 @@
+package readall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+func TestCompressionStats(t *testing.T) {
+	plain := strings.Repeat("compressible ", 1000)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(plain))
+	zw.Close()
+
+	for _, src := range [][]byte{gz.Bytes(), []byte(plain)} {
+		data, err := From(BytesSource(src)).Options(WithLabel("compression-test")).Decompress().Bytes()
+		if err != nil || string(data) != plain {
+			t.Errorf("read len:%v, err:%v", len(data), err)
+			return
+		}
+	}
+	st := CompressionStats()["compression-test"]
+	want := CompressionStat{Reads: 2, Compressed: 1, BytesIn: int64(gz.Len() + len(plain)), BytesOut: int64(2 * len(plain))}
+	if st != want {
+		t.Errorf("stat:%+v, want:%+v", st, want)
+	}
+	if r := st.Ratio(); r <= 1 {
+		t.Errorf("ratio:%v", r)
+	}
+}
@@ -0,0 +1,83 @@
+package readall
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Copy copies src to dst until EOF, taking the same options as ReadAll so a
+// transfer can switch between buffering and streaming without changing its
+// option set: WithLimit (the first n bytes are written, then a *LimitError
+// is returned), WithProgress, WithHash, WithEstimator, WithRateLimit,
+// WithIdleTimeout, WithSoftLimit, WithNewlines, WithTruncationPolicy,
+// WithLabel and WithStats. Without options that need to see the data, src.WriteTo or
+// dst.ReadFrom moves it directly, which between files and TCP or Unix
+// sockets lets the kernel copy it (copy_file_range, sendfile or splice on
+// Linux, sendfile on the BSDs and macOS), limited or not; otherwise a pooled
+// buffer is used. A direct copy reports its bytes to InFlight only once it
+// is done, and KillRead interrupts it only through the read deadline of
+// src; it fails with ErrReadKilled when it returns.
+func Copy(dst io.Writer, src io.Reader, opts ...Option) (n int64, err error) {
+	defer annotate(&err, src)
+	o := readOptions(opts)
+	st := o.startStats()
+	defer st.finish()
+	t := o.track(src)
+	defer t.done()
+	defer o.adviseSequential(src)()
+	defer o.watch(t)()
+	defer func() { observeSize(o.label, n) }()
+	if o.progress == nil && o.rate == nil && o.idle == 0 && o.softFn == nil && o.newline == KeepNewlines && len(o.hashes) == 0 && o.estimator == nil && o.coalesceMin <= 1 {
+		direct := true
+		wt, ok := src.(io.WriterTo)
+		switch limit := o.readLimit(); {
+		case kernelCopy(dst, src):
+			n, err = copyKernel(dst, src, limit)
+		case ok && limit == 0:
+			n, err = wt.WriteTo(dst)
+		default:
+			direct = false
+		}
+		if direct {
+			atomic.AddInt64(&t.n, n)
+			if t.isKilled() {
+				return n, t.err()
+			}
+			if st != nil {
+				st.Bytes = n
+			}
+			return n, o.truncated(err, st)
+		}
+	}
+	r := o.withNewlines(trackedReader{o.withRate(o.withIdle(o.withProgress(o.withCoalesce(src)), src)), t})
+	if n := o.readLimit(); n > 0 {
+		r = newLimitReader(r, n)
+	}
+	r = st.reader(o.withHash(o.withSoftLimit(r)))
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	n, err = io.CopyBuffer(dst, onlyReader{r}, *bp)
+	if t.isKilled() {
+		return n, t.err()
+	}
+	return n, o.truncated(err, st)
+}
+
+// copyKernel copies src to dst, for which kernelCopy holds, failing with a
+// *LimitError after limit bytes if limit is positive. The limit is applied
+// with an *io.LimitedReader, which the standard library still copies inside
+// the kernel, and exceeding it is detected by reading one more byte.
+func copyKernel(dst io.Writer, src io.Reader, limit int64) (int64, error) {
+	if limit <= 0 {
+		return io.Copy(dst, src)
+	}
+	n, err := io.Copy(dst, &io.LimitedReader{R: src, N: limit})
+	if err != nil || n < limit {
+		return n, err
+	}
+	var b [1]byte
+	if m, _ := src.Read(b[:]); m > 0 {
+		return n, &LimitError{Limit: limit, Read: limit + 1}
+	}
+	return n, nil
+}
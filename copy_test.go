@@ -0,0 +1,115 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+type writerToSpy struct {
+	*bytes.Reader
+	used bool
+}
+
+func (w *writerToSpy) WriteTo(dst io.Writer) (int64, error) {
+	w.used = true
+	return w.Reader.WriteTo(dst)
+}
+
+func TestCopy(t *testing.T) {
+	data := bytes.Repeat([]byte("copy"), 20000)
+	var buf bytes.Buffer
+	spy := &writerToSpy{Reader: bytes.NewReader(data)}
+	if n, err := Copy(&buf, spy); err != nil || n != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) || !spy.used {
+		t.Errorf("fast copy n:%v, err:%v, WriteTo used:%v", n, err, spy.used)
+	}
+
+	buf.Reset()
+	h := sha256.New()
+	var s Stats
+	var progressed int64
+	spy = &writerToSpy{Reader: bytes.NewReader(data)}
+	n, err := Copy(&buf, spy, WithHash(h), WithStats(&s), WithProgress(func(read, total int64) { progressed = read }))
+	sum := sha256.Sum256(data)
+	if err != nil || n != int64(len(data)) || spy.used || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Errorf("hashed copy n:%v, err:%v, WriteTo used:%v", n, err, spy.used)
+	}
+	if s.Bytes != n || s.Reads == 0 || progressed != n {
+		t.Errorf("stats:%+v, progress:%v", s, progressed)
+	}
+
+	buf.Reset()
+	n, err = Copy(&buf, iotest.HalfReader(bytes.NewReader(data)), WithLimit(1000))
+	if !errors.Is(err, ErrLimitExceeded) || n != 1000 || buf.Len() != 1000 {
+		t.Errorf("limited n:%v, err:%v", n, err)
+	}
+}
+
+func TestWithHash(t *testing.T) {
+	data := bytes.Repeat([]byte("hash"), 5000)
+	a, b := sha256.New(), sha256.New()
+	got, err := ReadAll(bytes.NewReader(data), WithHash(a), WithHash(b))
+	sum := sha256.Sum256(data)
+	if err != nil || !bytes.Equal(got, data) || !bytes.Equal(a.Sum(nil), sum[:]) || !bytes.Equal(b.Sum(nil), sum[:]) {
+		t.Errorf("readall len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestCopyFiles(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("kernel"), 100000)
+	src := filepath.Join(dir, "src")
+	ioutil.WriteFile(src, data, 0644)
+	for _, limit := range []int64{0, int64(len(data)), 1000} {
+		in, _ := os.Open(src)
+		out, _ := os.Create(filepath.Join(dir, "dst"))
+		var s Stats
+		var opts []Option
+		if limit > 0 {
+			opts = append(opts, WithLimit(limit))
+		}
+		n, err := Copy(out, in, append(opts, WithStats(&s))...)
+		in.Close()
+		out.Close()
+		want := int64(len(data))
+		if limit == 1000 {
+			want = 1000
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("limit %v err:%v, want:%v", limit, err, ErrLimitExceeded)
+			}
+		} else if err != nil {
+			t.Errorf("limit %v err:%v", limit, err)
+		}
+		got, _ := ioutil.ReadFile(filepath.Join(dir, "dst"))
+		if n != want || !bytes.Equal(got, data[:want]) || s.Bytes != want || s.Reads != 0 {
+			t.Errorf("limit %v n:%v, len:%v, stats:%+v", limit, n, len(got), s)
+		}
+	}
+}
+
+// killingWriterTo kills its own read from within WriteTo.
+type killingWriterTo struct {
+	*bytes.Reader
+}
+
+func (killingWriterTo) String() string { return "killing-writer-to" }
+
+func (k killingWriterTo) WriteTo(dst io.Writer) (int64, error) {
+	if rd, ok := findInFlight("killing-writer-to"); ok {
+		KillRead(rd.ID)
+	}
+	return k.Reader.WriteTo(dst)
+}
+
+func TestCopyKillDirect(t *testing.T) {
+	InFlight() // register the copy below
+	if _, err := Copy(ioutil.Discard, killingWriterTo{bytes.NewReader([]byte("data"))}); !errors.Is(err, ErrReadKilled) {
+		t.Errorf("killed copy err:%v, want:%v", err, ErrReadKilled)
+	}
+}
@@ -0,0 +1,55 @@
+package readall
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	maxDatagramSize = 64 << 10
+	datagramSegment = 1 << 20
+)
+
+// Datagram is one packet collected by ReadDatagrams.
+type Datagram struct {
+	Addr net.Addr
+	Data []byte
+}
+
+// ReadDatagrams collects packets from pc until no packet arrives for idle or
+// at least maxTotal bytes have been received. The packet that crosses
+// maxTotal is kept, so the total may exceed it by one datagram. Packet
+// payloads are packed into shared 1MiB segments rather than allocated one by
+// one, and each Datagram keeps its own boundaries. An idle timeout is a normal
+// end and is not reported as an error. The read deadline of pc is cleared on
+// return.
+func ReadDatagrams(pc net.PacketConn, maxTotal int64, idle time.Duration) (_ []Datagram, err error) {
+	defer annotate(&err, pc)
+	defer pc.SetReadDeadline(time.Time{})
+	buf := make([]byte, maxDatagramSize)
+	var seg []byte
+	var out []Datagram
+	var total int64
+	for maxTotal <= 0 || total < maxTotal {
+		if err := pc.SetReadDeadline(time.Now().Add(idle)); err != nil {
+			return out, err
+		}
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				return out, nil
+			}
+			return out, err
+		}
+		if cap(seg)-len(seg) < n {
+			seg = make([]byte, 0, datagramSegment)
+		}
+		start := len(seg)
+		seg = append(seg, buf[:n]...)
+		out = append(out, Datagram{Addr: addr, Data: seg[start:len(seg):len(seg)]})
+		total += int64(n)
+	}
+	return out, nil
+}
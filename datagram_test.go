@@ -0,0 +1,41 @@
+package readall
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadDatagrams(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen err:%v", err)
+	}
+	defer pc.Close()
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Errorf("dial err:%v", err)
+		return
+	}
+	defer conn.Close()
+	for _, p := range []string{"one", "two", "three"} {
+		conn.Write([]byte(p))
+	}
+
+	got, err := ReadDatagrams(pc, 0, 100*time.Millisecond)
+	if err != nil {
+		t.Errorf("datagrams err:%v", err)
+		return
+	}
+	if len(got) != 3 || string(got[0].Data) != "one" || string(got[2].Data) != "three" {
+		t.Errorf("datagrams:%v", got)
+	}
+
+	for _, p := range []string{"four", "five", "six"} {
+		conn.Write([]byte(p))
+	}
+	got, err = ReadDatagrams(pc, 5, time.Second)
+	if err != nil || len(got) != 2 {
+		t.Errorf("capped datagrams:%v, err:%v", len(got), err)
+	}
+}
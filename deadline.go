@@ -0,0 +1,76 @@
+package readall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// minDeadlineChunk is the smallest read size used when a deadline is close.
+const minDeadlineChunk = 4 << 10
+
+// copyContext copies r to w like io.CopyBuffer but checks ctx between reads.
+// When ctx has a deadline, each read is sized from the throughput seen so far
+// to take about a quarter of the time that is left, so a reader that fills
+// the whole buffer (a file, a fast socket) cannot run far past the deadline.
+// On expiry the error wraps ctx.Err() and reports progress and throughput.
+func copyContext(ctx context.Context, w io.Writer, r io.Reader, buf []byte) (int64, error) {
+	deadline, hasDeadline := ctx.Deadline()
+	start := time.Now()
+	var n int64
+	for {
+		if ctx.Err() != nil {
+			return n, deadlineErr(ctxErr(ctx), n, time.Since(start))
+		}
+		p := buf
+		if hasDeadline {
+			p = buf[:deadlineChunk(len(buf), n, time.Since(start), time.Until(deadline))]
+		}
+		m, err := r.Read(p)
+		if m > 0 {
+			wm, werr := w.Write(p[:m])
+			n += int64(wm)
+			if werr != nil {
+				return n, werr
+			}
+			if wm != m {
+				return n, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// deadlineChunk returns the read size expected to take a quarter of left at
+// the rate of n bytes in elapsed, clamped to [minDeadlineChunk, max]. Before
+// any data has arrived the rate is unknown and the minimum is used.
+func deadlineChunk(max int, n int64, elapsed, left time.Duration) int {
+	want := 0.0
+	if n > 0 && elapsed > 0 {
+		want = float64(n) / elapsed.Seconds() * left.Seconds() / 4
+	}
+	switch {
+	case want >= float64(max):
+		return max
+	case want < minDeadlineChunk:
+		if max < minDeadlineChunk {
+			return max
+		}
+		return minDeadlineChunk
+	}
+	return int(want)
+}
+
+func deadlineErr(err error, n int64, elapsed time.Duration) error {
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(n) / elapsed.Seconds() / 1e6
+	}
+	return fmt.Errorf("%w after %d bytes at %.2f MB/s", err, n, rate)
+}
@@ -0,0 +1,56 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowReader fills every read, sleeping in proportion to its size.
+type slowReader struct {
+	perByte time.Duration
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Duration(len(p)) * s.perByte)
+	return len(p), nil
+}
+
+func TestCopyContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// 32KiB reads would take ~330ms each; adapted reads stop close to the deadline.
+	n, err := copyContext(ctx, io.Discard, slowReader{10 * time.Microsecond}, make([]byte, 32<<10))
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "bytes at") {
+		t.Errorf("copy n:%v, err:%v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("copy overran deadline by %v", elapsed-100*time.Millisecond)
+	}
+}
+
+func TestPipelineContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var buf bytes.Buffer
+	n, err := From(BytesSource("hello")).Context(ctx).To(&buf)
+	if err != nil || n != 5 || buf.String() != "hello" {
+		t.Errorf("to n:%v, err:%v, data:%q", n, err, buf.String())
+	}
+	cancel()
+	if _, err := From(BytesSource("hello")).Context(ctx).To(&buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled err:%v", err)
+	}
+
+	if got := deadlineChunk(32<<10, 1<<20, time.Second, 40*time.Millisecond); got != 10485 {
+		t.Errorf("chunk:%v", got)
+	}
+	if got := deadlineChunk(32<<10, 1, time.Second, time.Millisecond); got != minDeadlineChunk {
+		t.Errorf("min chunk:%v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package readall
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedEncoding is returned for a content encoding without a
+// registered decoder.
+var ErrUnsupportedEncoding = errors.New("readall: unsupported content encoding")
+
+var (
+	decoderMu sync.RWMutex
+	decoders  = map[string]func(io.Reader) (io.Reader, error){
+		"identity": func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip":     gzipDecoder,
+		"x-gzip":   gzipDecoder,
+		"deflate":  deflateDecoder,
+	}
+)
+
+// RegisterDecoder makes ReadAllDecompress and ReadResponseDecompress decode
+// encoding with fn, e.g. "zstd" or "br" with a third-party decoder. It panics
+// if encoding is empty or already registered.
+func RegisterDecoder(encoding string, fn func(io.Reader) (io.Reader, error)) {
+	encoding = strings.ToLower(encoding)
+	decoderMu.Lock()
+	defer decoderMu.Unlock()
+	if encoding == "" || fn == nil {
+		panic("readall: invalid decoder registration " + encoding)
+	}
+	if _, dup := decoders[encoding]; dup {
+		panic("readall: RegisterDecoder called twice for " + encoding)
+	}
+	decoders[encoding] = fn
+}
+
+func gzipDecoder(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateDecoder accepts zlib-wrapped data as HTTP specifies and raw deflate
+// as some servers send.
+func deflateDecoder(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0]&0x0f == 8 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeReader returns r decoded according to encoding, a Content-Encoding
+// list such as "gzip" or "deflate, gzip" applied in order. An empty encoding
+// detects gzip and zlib by their magic bytes.
+func decodeReader(r io.Reader, encoding string) (io.Reader, error) {
+	if strings.TrimSpace(encoding) == "" {
+		return decompress(r)
+	}
+	codings := strings.Split(encoding, ",")
+	decoderMu.RLock()
+	defer decoderMu.RUnlock()
+	for i := len(codings) - 1; i >= 0; i-- {
+		name := strings.ToLower(strings.TrimSpace(codings[i]))
+		fn, ok := decoders[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, name)
+		}
+		dr, err := fn(r)
+		if err != nil {
+			return nil, err
+		}
+		r = wrappedReader{Reader: dr, src: r}
+	}
+	return r, nil
+}
+
+// ReadAllDecompress reads r decoded according to encoding, a Content-Encoding
+// value: gzip, deflate, identity, a comma-separated list of them, or anything
+// registered with RegisterDecoder. An empty encoding detects gzip and zlib by
+// their magic bytes and passes other data through. WithLimit bounds the
+// decompressed size, which guards against decompression bombs.
+func ReadAllDecompress(r io.Reader, encoding string, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	dr, err := decodeReader(r, encoding)
+	if err != nil {
+		return nil, err
+	}
+	o := readOptions(opts)
+	return readAllCap(dr, o.capacity(dr), o)
+}
+
+// ReadResponseDecompress is ReadResponse decoding the body according to its
+// Content-Encoding header, or by magic bytes without one. Net/http already
+// removes the encoding it negotiated itself. Content-Length and integrity
+// checks apply to the encoded body; WithLimit bounds the decoded one.
+func ReadResponseDecompress(rsp *http.Response, opts ...Option) ([]byte, error) {
+	return readResponse(rsp, -1, true, false, opts)
+}
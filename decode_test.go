@@ -0,0 +1,68 @@
+package readall
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func compressed(encoding, data string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	io.WriteString(w, data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestReadAllDecompress(t *testing.T) {
+	data := strings.Repeat("decompress me ", 1000)
+	for _, c := range []struct {
+		kind, encoding string
+	}{
+		{"gzip", "gzip"}, {"gzip", "x-gzip"}, {"zlib", "deflate"}, {"flate", "deflate"}, {"gzip", ""}, {"zlib", ""},
+	} {
+		got, err := ReadAllDecompress(bytes.NewReader(compressed(c.kind, data)), c.encoding)
+		if err != nil || string(got) != data {
+			t.Errorf("%v as %q len:%v, err:%v", c.kind, c.encoding, len(got), err)
+		}
+	}
+	twice := compressed("gzip", string(compressed("zlib", data)))
+	if got, err := ReadAllDecompress(bytes.NewReader(twice), "deflate, gzip"); err != nil || string(got) != data {
+		t.Errorf("stacked len:%v, err:%v", len(got), err)
+	}
+	if _, err := ReadAllDecompress(strings.NewReader("x"), "zstd"); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("zstd err:%v", err)
+	}
+	if got, err := ReadAllDecompress(bytes.NewReader(compressed("gzip", data)), "gzip", WithLimit(100)); !errors.Is(err, ErrLimitExceeded) || len(got) != 100 {
+		t.Errorf("bomb len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestReadResponseDecompress(t *testing.T) {
+	data := strings.Repeat("encoded body ", 1000)
+	body := compressed("gzip", data)
+	rsp := &http.Response{
+		Header:        http.Header{"Content-Encoding": {"gzip"}, "Content-Length": {strconv.Itoa(len(body))}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	if got, err := ReadResponseDecompress(rsp); err != nil || string(got) != data {
+		t.Errorf("response len:%v, err:%v", len(got), err)
+	}
+}
@@ -0,0 +1,86 @@
+package readall
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// ErrDirectIOUnsupported is returned by reads with WithDirectIO when the
+// platform, the file system or the file cannot do direct I/O. Reading again
+// without the option goes through the page cache.
+var ErrDirectIOUnsupported = errors.New("readall: direct I/O not supported")
+
+// directIOAlign is the alignment of buffers, offsets and lengths for direct
+// I/O. 4KiB covers the logical block size of current disks.
+const directIOAlign = 4096
+
+// directIOChunk is the size of the reads ReadFile makes with direct I/O.
+const directIOChunk = 1 << 20
+
+// WithDirectIO makes ReadFile and ParallelReadFile bypass the page cache by
+// opening the file with O_DIRECT (Linux) and reading into aligned buffers,
+// for backup and scan workloads that would otherwise evict the cache of
+// everything else. Memory mapping is not used. Where direct I/O is not
+// available, including file systems such as tmpfs and non-regular files,
+// the read fails with an error matching ErrDirectIOUnsupported.
+func WithDirectIO() Option {
+	return func(o *options) {
+		o.directIO = true
+	}
+}
+
+func alignUp(n int64) int64 {
+	return (n + directIOAlign - 1) &^ (directIOAlign - 1)
+}
+
+// alignedBuf returns a buffer of length n and capacity alignUp(n) whose
+// first byte is aligned for direct I/O.
+func alignedBuf(n int64) []byte {
+	size := alignUp(n)
+	b := make([]byte, size+directIOAlign)
+	off := 0
+	if rem := int(uintptr(unsafe.Pointer(&b[0])) & (directIOAlign - 1)); rem != 0 {
+		off = directIOAlign - rem
+	}
+	return b[off : off+int(n) : off+int(size)]
+}
+
+// readFileDirect reads the file at path with direct I/O.
+func readFileDirect(path string) ([]byte, error) {
+	f, err := openDirect(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: not a regular file", ErrDirectIOUnsupported)
+	}
+	size := fi.Size()
+	if size > maxInt-2*directIOAlign {
+		return nil, io.ErrShortBuffer
+	}
+	data := alignedBuf(size)
+	buf := data[:cap(data)]
+	var n int64
+	for n < int64(len(buf)) {
+		m, err := f.ReadAt(buf[n:min64(n+directIOChunk, int64(len(buf)))], n)
+		n += int64(m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, directErr(err)
+		}
+	}
+	// Like ParallelReadFile, growth past the size seen at open is not read.
+	if n < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
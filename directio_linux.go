@@ -0,0 +1,29 @@
+//go:build linux && !readall_purego
+// +build linux,!readall_purego
+
+package readall
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openDirect opens path for reading with O_DIRECT.
+func openDirect(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		return nil, directErr(err)
+	}
+	return f, nil
+}
+
+// directErr maps the EINVAL with which Linux rejects direct I/O on file
+// systems without it to ErrDirectIOUnsupported.
+func directErr(err error) error {
+	if errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("%w: %v", ErrDirectIOUnsupported, err)
+	}
+	return err
+}
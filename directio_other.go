@@ -0,0 +1,15 @@
+//go:build !linux || readall_purego
+// +build !linux readall_purego
+
+package readall
+
+import "os"
+
+// openDirect fails: direct I/O is only implemented on Linux.
+func openDirect(path string) (*os.File, error) {
+	return nil, ErrDirectIOUnsupported
+}
+
+func directErr(err error) error {
+	return err
+}
@@ -0,0 +1,51 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func TestAlignedBuf(t *testing.T) {
+	for _, n := range []int64{0, 1, 4095, 4096, 4097, 1 << 20} {
+		b := alignedBuf(n)
+		if int64(len(b)) != n || int64(cap(b)) != alignUp(n) || cap(b)%directIOAlign != 0 {
+			t.Errorf("buf %v len:%v, cap:%v", n, len(b), cap(b))
+		}
+		if cap(b) == 0 {
+			continue
+		}
+		if p := uintptr(unsafe.Pointer(&b[:cap(b)][0])); p%directIOAlign != 0 {
+			t.Errorf("buf %v at %#x, not aligned", n, p)
+		}
+	}
+}
+
+func TestWithDirectIO(t *testing.T) {
+	dir := t.TempDir()
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 4096, 5000, 3<<20 + 7} {
+		data := make([]byte, size)
+		rnd.Read(data)
+		path := filepath.Join(dir, "direct")
+		ioutil.WriteFile(path, data, 0644)
+		res, err := ReadFile(path, WithDirectIO())
+		if errors.Is(err, ErrDirectIOUnsupported) {
+			t.Skipf("direct I/O unsupported here: %v", err)
+		}
+		if err != nil || !bytes.Equal(res.Data, data) {
+			t.Errorf("size %v readfile len:%v, err:%v", size, len(res.Data), err)
+		}
+		got, err := ParallelReadFile(path, 6000, 3, WithDirectIO())
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("size %v parallel len:%v, err:%v", size, len(got), err)
+		}
+	}
+	if _, err := ReadFile(dir, WithDirectIO()); err == nil {
+		t.Errorf("directory read without error")
+	}
+}
@@ -0,0 +1,30 @@
+// Package readall reads sources into memory efficiently.
+//
+// ReadAll is a drop-in replacement for ioutil.ReadAll that allocates the
+// result once when the reader reveals its size. Around it the package offers
+// bounded, streaming and structured reads: pipelines over Sources with
+// limits, decompression and hashing, archive walkers, log segment and
+// tail-follow readers, and content-defined chunking.
+//
+// # Module layout
+//
+// The root module depends on the standard library only, and stays that way:
+// the core (ReadAll and its variants, options, errors, pools) as well as the
+// downloader, decompression and charset support all build from it alone.
+// Integrations with third-party formats and services plug in through
+// registries instead (RegisterDecoder, RegisterCharset, RegisterSource,
+// RegisterStrategy), so a program pays for a codec or cloud SDK only when
+// it registers one.
+// Optional extras live in subdirectories: promexp exports Stats as
+// Prometheus metrics, objstore reads objects from object stores in
+// parallel ranges and readalltest holds test helpers. An extra that
+// needs a third-party dependency goes into a nested module with its own
+// go.mod, so the root module never requires it.
+//
+// # Build tags
+//
+// The readall_purego tag leaves out every backend that talks to the kernel
+// directly (inotify, CPU affinity and similar) and uses the portable code
+// paths instead. Platforms without those facilities, such as js/wasm and
+// plan9, get the portable paths automatically.
+package readall
@@ -0,0 +1,631 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"readall/internal/core"
+)
+
+// DefaultRetries is how many consecutive failed attempts Download retries.
+const DefaultRetries = 5
+
+// DefaultRetryBackoff is the delay before the first retry; it doubles with
+// every further consecutive failure up to maxRetryBackoff.
+const DefaultRetryBackoff = 200 * time.Millisecond
+
+const maxRetryBackoff = 10 * time.Second
+
+// WithRetries sets how many consecutive failed attempts Download retries;
+// an attempt that receives data resets the count.
+func WithRetries(n int) Option {
+	return func(o *options) {
+		o.retries = n
+		o.retriesSet = true
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry of Download.
+func WithRetryBackoff(d time.Duration) Option {
+	return func(o *options) {
+		o.backoff = d
+	}
+}
+
+// WithRequestDecorator calls fn on every request Download issues, including
+// probes, resumed and range requests, right before it is sent. fn may set
+// headers such as a freshly refreshed auth token; an error from it fails the
+// download.
+func WithRequestDecorator(fn func(*http.Request) error) Option {
+	return func(o *options) {
+		o.decorate = fn
+	}
+}
+
+// WithTransport makes Download send its requests through rt instead of the
+// transport of its client, keeping the client's redirect policy, cookies and
+// timeout.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithHostClient makes Download use c for requests to host, given as
+// "host" or "host:port", in place of the client passed to it and any
+// WithTransport. Register one per mirror to mix internal mirrors behind
+// their own proxies or TLS configurations with external origins under a
+// single set of options. Redirects are followed by the client chosen for
+// the original request.
+func WithHostClient(host string, c *http.Client) Option {
+	return func(o *options) {
+		if o.hostClients == nil {
+			o.hostClients = make(map[string]*http.Client)
+		}
+		o.hostClients[host] = c
+	}
+}
+
+// errRestart makes Download start over after the resource changed.
+var errRestart = errors.New("readall: resource changed during download")
+
+// Download fetches url with client (http.DefaultClient if nil), retrying
+// transient failures: network errors, truncated bodies, 429 and 5xx
+// responses. Retries resume with a Range request from the last byte
+// received, guarded by If-Range with the ETag or Last-Modified of the first
+// response so a resource that changed in between is fetched again from the
+// start rather than spliced. WithLimit bounds the size; WithRetries and
+// WithRetryBackoff tune the retries, WithParallelRanges splits the
+// transfer into concurrent requests, WithProbe plans it with a HEAD
+// request first and WithRequestDecorator adjusts every request.
+// WithTransport and WithHostClient override the client per download and per
+// host. Under WithNormalizeNewlines retries start over rather than resume.
+// It is DownloadSource over the URL as a RangeSource.
+func Download(ctx context.Context, client *http.Client, url string, opts ...Option) ([]byte, error) {
+	res, err := DownloadResult(ctx, client, url, opts...)
+	return res.Data, err
+}
+
+// DownloadResult is Download returning a Result whose Plan tells how the
+// download was carried out.
+func DownloadResult(ctx context.Context, client *http.Client, url string, opts ...Option) (_ Result, err error) {
+	defer func() { err = withSource(url, err) }()
+	o := readOptions(opts).withContext(ctx)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if o.transport != nil {
+		c := *client
+		c.Transport = o.transport
+		client = &c
+	}
+	d := newDownload(ctx, &o)
+	d.src = &httpDownload{client: client, url: url, o: &o, plan: &d.plan}
+	return d.run()
+}
+
+// DownloadSource reads src into memory, retrying transient failures:
+// network errors, truncated reads, errors with a Temporary method reporting
+// true, and 429 and 5xx responses of HTTP sources. Retries resume with
+// OpenAt from the last byte received, and start over when src returns
+// ErrRangeUnsupported. When src is a RangeSource, WithParallelRanges splits
+// the read into concurrent OpenRange calls; WithProbe plans it with Size
+// first. WithLimit, WithRetries and WithRetryBackoff apply as for Download.
+func DownloadSource(ctx context.Context, src Source, opts ...Option) (_ Result, err error) {
+	defer func() { err = withSource(sourceName(src), err) }()
+	o := readOptions(opts).withContext(ctx)
+	d := newDownload(ctx, &o)
+	d.src = src
+	_, d.plan.AcceptRanges = src.(RangeSource)
+	return d.run()
+}
+
+type download struct {
+	ctx  context.Context
+	src  Source
+	o    *options
+	data []byte
+	plan DownloadPlan
+	// planned is set when the probe planned a read in parts.
+	planned bool
+	// sequential is set once src refused a range.
+	sequential bool
+}
+
+func newDownload(ctx context.Context, o *options) *download {
+	return &download{ctx: ctx, o: o, plan: DownloadPlan{Size: -1, Parts: 1}}
+}
+
+// run carries out the download, retrying and resuming failed attempts.
+func (d *download) run() (Result, error) {
+	rt := d.retrier()
+	for d.o.probe {
+		err := d.probe()
+		if err == nil {
+			break
+		}
+		if fe, ok := err.(finalError); ok {
+			return Result{}, fe.error
+		}
+		if !rt.retry(err, false) {
+			return Result{}, err
+		}
+	}
+	for {
+		before := len(d.data)
+		err := d.attempt()
+		if err == nil {
+			if v, ok := d.src.(interface{ verify([]byte) error }); ok && d.o.integrity {
+				if err := v.verify(d.data); err != nil {
+					return Result{}, d.o.partial(d.data, err)
+				}
+			}
+			return Result{Data: d.data, Plan: &d.plan}, nil
+		}
+		if err == errRestart {
+			d.data, d.planned = d.data[:0], false
+			continue
+		}
+		if fe, ok := err.(finalError); ok {
+			return Result{}, d.o.partial(d.data, fe.error)
+		}
+		if !rt.retry(err, len(d.data) > before) {
+			return Result{}, d.o.partial(d.data, err)
+		}
+	}
+}
+
+// probe plans the download from the size of the source.
+func (d *download) probe() error {
+	size, err := d.src.Size(d.ctx)
+	if err != nil {
+		return err
+	}
+	d.plan.Size, d.plan.Probed = size, true
+	if n := d.o.readLimit(); n > 0 && size > n {
+		return finalError{&LimitError{Limit: n, Read: size}}
+	}
+	d.plan.Parts = d.parts(size)
+	d.planned = d.plan.Parts > 1
+	return nil
+}
+
+// retrier counts consecutive failures and waits out the backoff between
+// attempts.
+type retrier struct {
+	ctx      context.Context
+	retries  int
+	backoff  time.Duration
+	failures int
+}
+
+func (d *download) retrier() *retrier {
+	rt := &retrier{ctx: d.ctx, retries: DefaultRetries, backoff: d.o.backoff}
+	if d.o.retriesSet {
+		rt.retries = d.o.retries
+	}
+	if rt.backoff <= 0 {
+		rt.backoff = DefaultRetryBackoff
+	}
+	return rt
+}
+
+// retry reports whether to try again after err, waiting first. progressed
+// tells whether the failed attempt received any data.
+func (rt *retrier) retry(err error, progressed bool) bool {
+	if !transient(rt.ctx, err) {
+		return false
+	}
+	if progressed {
+		rt.failures = 0
+	}
+	if rt.failures++; rt.failures > rt.retries {
+		return false
+	}
+	delay := rt.backoff << uint(rt.failures-1)
+	if delay > maxRetryBackoff || delay <= 0 {
+		delay = maxRetryBackoff
+	}
+	select {
+	case <-time.After(delay):
+		return true
+	case <-rt.ctx.Done():
+		return false
+	}
+}
+
+// finalError is an error Download returns without retrying, because the
+// retries were already spent on it.
+type finalError struct {
+	error
+}
+
+// attempt reads the rest of the source and appends it to d.data.
+func (d *download) attempt() error {
+	if len(d.data) == 0 && d.planned {
+		return d.readRanges(d.plan.Size, nil)
+	}
+	var rc io.ReadCloser
+	var err error
+	// Converted newlines leave len(d.data) out of step with the source, so
+	// the download starts over instead of resuming.
+	resume := len(d.data) > 0 && d.o.newline == KeepNewlines
+	if !resume {
+		d.data = d.data[:0]
+	} else {
+		rc, err = d.src.OpenAt(d.ctx, int64(len(d.data)))
+		if err == errRestart || errors.Is(err, ErrRangeUnsupported) {
+			d.data, resume = d.data[:0], false
+		}
+	}
+	if !resume {
+		rc, err = d.src.Open(d.ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	size, ok := sizeHint(rc)
+	if !ok {
+		size = -1
+	}
+	if !resume && !d.plan.Probed {
+		d.plan.Size = size
+	}
+	if n := d.o.readLimit(); n > 0 && int64(len(d.data))+size > n {
+		return &LimitError{Limit: n, Read: int64(len(d.data)) + size}
+	}
+	if !resume {
+		if d.plan.Parts = d.parts(size); d.plan.Parts > 1 {
+			return d.readRanges(size, rc)
+		}
+	}
+	if size > 0 && int64(cap(d.data)-len(d.data)) < size {
+		prealloc := int64(len(d.data)) + min64(size, maxResponsePrealloc) + 1
+		d.data = append(make([]byte, 0, prealloc), d.data...)
+		if !resume {
+			d.plan.Prealloc = prealloc
+		}
+	}
+	var r io.Reader = rc
+	if n := d.o.readLimit(); n > 0 {
+		// The limit covers the whole resource, not just this attempt.
+		r = &core.LimitReader{R: r, N: n - int64(len(d.data)), Err: &LimitError{Limit: n, Read: n + 1}}
+	}
+	d.data, err = appendAll(d.data, r, d.o, nil, nil)
+	return err
+}
+
+// transient reports whether err is worth retrying a download for.
+func transient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	var ne net.Error
+	if errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrLengthMismatch) {
+		return true
+	}
+	var te interface{ Temporary() bool }
+	return errors.As(err, &te) && te.Temporary()
+}
+
+// minRangePart is the smallest part WithParallelRanges requests on its own.
+const minRangePart = 256 << 10
+
+// WithParallelRanges makes Download fetch the resource in up to n
+// concurrent Range requests, each written straight into its slice of the
+// result, when the server advertises Accept-Ranges: bytes, a Content-Length
+// and a validator. A single stream often falls well short of the bandwidth
+// available on links with high latency. Each part is retried on its own;
+// the progress, index, rate and newline options keep the download
+// sequential. DownloadSource splits the read of any RangeSource of known
+// size the same way.
+func WithParallelRanges(n int) Option {
+	return func(o *options) {
+		o.ranges = n
+	}
+}
+
+// rangeSource returns d.src as a RangeSource if it can be read in ranges.
+func (d *download) rangeSource() (RangeSource, bool) {
+	rs, ok := d.src.(RangeSource)
+	if !ok || d.sequential {
+		return nil, false
+	}
+	if c, ok := rs.(interface{ rangesSupported() bool }); ok && !c.rangesSupported() {
+		return nil, false
+	}
+	return rs, true
+}
+
+// parts returns how many ranges to read a source of size bytes with; 1
+// means a single stream.
+func (d *download) parts(size int64) int {
+	if _, ok := d.rangeSource(); !ok || d.o.ranges <= 1 || size < 2*minRangePart || size >= maxInt ||
+		d.o.progress != nil || d.o.index != nil || d.o.rate != nil || d.o.newline != KeepNewlines {
+		return 1
+	}
+	if n := size / minRangePart; n < int64(d.o.ranges) {
+		return int(n)
+	}
+	return d.o.ranges
+}
+
+// readRanges reads the size bytes of the source in d.plan.Parts parallel
+// ranges. The first range is read from r, if not nil.
+func (d *download) readRanges(size int64, r io.Reader) error {
+	rs, _ := d.rangeSource()
+	parts := int64(d.plan.Parts)
+	if n := d.o.readLimit(); n > 0 && size > n {
+		return finalError{&LimitError{Limit: n, Read: size}}
+	}
+	data := make([]byte, size)
+	d.plan.Prealloc = size
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+	t := d.o.track(d.src)
+	defer t.done()
+	t.setAbort(cancel)
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		first   error
+	)
+	for i := int64(0); i < parts; i++ {
+		off, end := i*size/parts, (i+1)*size/parts
+		var own io.Reader
+		if i == 0 {
+			own = r
+		}
+		wg.Add(1)
+		go func(own io.Reader, p []byte, off int64) {
+			defer wg.Done()
+			if err := d.readRange(ctx, rs, t, own, p, off); err != nil {
+				errOnce.Do(func() { first = err })
+				cancel()
+			}
+		}(own, data[off:end], off)
+	}
+	wg.Wait()
+	switch {
+	case t.isKilled():
+		return finalError{t.err()}
+	case first == errRestart:
+		return first
+	case errors.Is(first, ErrRangeUnsupported):
+		d.sequential = true
+		return errRestart
+	case first != nil:
+		return finalError{first}
+	}
+	d.data = data
+	observeSize(d.o.label, size)
+	return nil
+}
+
+// readRange fills p with the bytes of the source at off, starting from r if
+// not nil and retrying with OpenRange.
+func (d *download) readRange(ctx context.Context, rs RangeSource, t *trackedRead, r io.Reader, p []byte, off int64) error {
+	rt := d.retrier()
+	rt.ctx = ctx
+	var got int
+	for got < len(p) {
+		var n int
+		var err error
+		if r != nil {
+			n, err = io.ReadFull(trackedReader{r, t}, p)
+			r = nil
+		} else {
+			n, err = d.fetchRange(ctx, rs, t, p[got:], off+int64(got))
+		}
+		got += n
+		if err == errRestart || errors.Is(err, ErrRangeUnsupported) || err != nil && !rt.retry(err, n > 0) {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchRange reads len(p) bytes of the source at off into p.
+func (d *download) fetchRange(ctx context.Context, rs RangeSource, t *trackedRead, p []byte, off int64) (int, error) {
+	rc, err := rs.OpenRange(ctx, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return io.ReadFull(trackedReader{rc, t}, p)
+}
+
+// httpDownload is the RangeSource Download reads a URL through. It guards
+// resumed and range requests with If-Range and returns errRestart when the
+// resource changed.
+type httpDownload struct {
+	client *http.Client
+	url    string
+	o      *options
+	// validator is the If-Range value identifying the version being
+	// downloaded.
+	validator string
+	// header holds the integrity headers of the version being downloaded.
+	header http.Header
+	// ranges reports whether the server advertised range requests.
+	ranges bool
+	plan   *DownloadPlan
+	// pending is a full response to a resumed request, handed out by the
+	// next Open.
+	pending *http.Response
+}
+
+func (h *httpDownload) String() string {
+	return h.url
+}
+
+// statusError is a response status Download did not expect.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "readall: GET: " + e.status
+}
+
+// Open implements Source.
+func (h *httpDownload) Open(ctx context.Context) (io.ReadCloser, error) {
+	rsp := h.pending
+	h.pending = nil
+	if rsp == nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if rsp, err = h.do(req); err != nil {
+			return nil, err
+		}
+		if rsp.StatusCode != http.StatusOK {
+			rsp.Body.Close()
+			return nil, &statusError{code: rsp.StatusCode, status: rsp.Status}
+		}
+	}
+	h.validator = ifRangeValidator(rsp.Header)
+	h.header = rsp.Header
+	h.ranges = rsp.Header.Get("Accept-Ranges") == "bytes"
+	if !h.plan.Probed {
+		h.plan.fill(rsp)
+	}
+	return &httpBody{ReadCloser: rsp.Body, size: rsp.ContentLength}, nil
+}
+
+// OpenAt implements Source. Without a validator a resumed range could belong
+// to another version, so it returns ErrRangeUnsupported and the download
+// starts over.
+func (h *httpDownload) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	if off == 0 {
+		return h.Open(ctx)
+	}
+	if h.validator == "" {
+		return nil, ErrRangeUnsupported
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	req.Header.Set("If-Range", h.validator)
+	rsp, err := h.do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		// The server ignored the range or the resource changed.
+		h.pending = rsp
+		return nil, errRestart
+	case http.StatusPartialContent:
+		if err := h.checkRange(rsp, off); err != nil {
+			rsp.Body.Close()
+			return nil, err
+		}
+		return &httpBody{ReadCloser: rsp.Body, size: rsp.ContentLength}, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		rsp.Body.Close()
+		// Everything was received before the connection dropped, unless
+		// the resource now has another size.
+		if rsp.Header.Get("Content-Range") != fmt.Sprintf("bytes */%d", off) {
+			return nil, errRestart
+		}
+		return &httpBody{ReadCloser: http.NoBody}, nil
+	}
+	rsp.Body.Close()
+	return nil, &statusError{code: rsp.StatusCode, status: rsp.Status}
+}
+
+// OpenRange implements RangeSource.
+func (h *httpDownload) OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+n-1))
+	req.Header.Set("If-Range", h.validator)
+	rsp, err := h.do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rsp.StatusCode == http.StatusOK:
+		rsp.Body.Close()
+		return nil, errRestart
+	case rsp.StatusCode != http.StatusPartialContent:
+		rsp.Body.Close()
+		return nil, &statusError{code: rsp.StatusCode, status: rsp.Status}
+	}
+	if err := h.checkRange(rsp, off); err != nil {
+		rsp.Body.Close()
+		return nil, err
+	}
+	return rsp.Body, nil
+}
+
+// rangesSupported reports whether the version being downloaded can be read
+// in ranges: the server advertised them and sent a validator.
+func (h *httpDownload) rangesSupported() bool {
+	return h.ranges && h.validator != ""
+}
+
+// verify checks data against the integrity headers of the version
+// downloaded.
+func (h *httpDownload) verify(data []byte) error {
+	return verifyData(h.header, data)
+}
+
+// do sends req after passing it to the request decorator, with the client
+// registered for its host if any.
+func (h *httpDownload) do(req *http.Request) (*http.Response, error) {
+	if h.o.decorate != nil {
+		if err := h.o.decorate(req); err != nil {
+			return nil, err
+		}
+	}
+	if c, ok := h.o.hostClients[req.URL.Host]; ok {
+		return c.Do(req)
+	}
+	if c, ok := h.o.hostClients[req.URL.Hostname()]; ok {
+		return c.Do(req)
+	}
+	return h.client.Do(req)
+}
+
+// checkRange returns errRestart unless the partial response rsp continues
+// the version being downloaded at off.
+func (h *httpDownload) checkRange(rsp *http.Response, off int64) error {
+	if !strings.HasPrefix(rsp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", off)) {
+		return errRestart
+	}
+	// Servers that ignore If-Range still reveal a changed ETag.
+	if etag := rsp.Header.Get("ETag"); etag != "" && strings.HasPrefix(h.validator, `"`) && etag != h.validator {
+		return errRestart
+	}
+	return nil
+}
+
+// ifRangeValidator returns the value for If-Range identifying the version in
+// h: a strong ETag, or else Last-Modified. Weak ETags cannot be used.
+func ifRangeValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
@@ -0,0 +1,317 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// flakyServer serves body with ETag etag, cutting the first drops responses
+// off after half of the remaining bytes.
+func flakyServer(t *testing.T, body []byte, etag *atomic.Value, drops int32) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("ETag", etag.Load().(string))
+		if n > drops {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+			return
+		}
+		var off int
+		if rng := r.Header.Get("Range"); rng != "" && r.Header.Get("If-Range") == etag.Load().(string) {
+			off, _ = strconv.Atoi(rng[len("bytes=") : len(rng)-1])
+			w.Header().Set("Content-Range", "bytes "+strconv.Itoa(off)+"-"+strconv.Itoa(len(body)-1)+"/"+strconv.Itoa(len(body)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)-off))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.Write(body[off : off+(len(body)-off)/2])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestDownloadResumes(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 10000)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	srv, calls := flakyServer(t, body, &etag, 3)
+	data, err := Download(context.Background(), srv.Client(), srv.URL, WithRetryBackoff(time.Millisecond))
+	if err != nil || !bytes.Equal(data, body) {
+		t.Fatalf("download len:%v, err:%v", len(data), err)
+	}
+	if n := atomic.LoadInt32(calls); n != 4 {
+		t.Errorf("calls:%v, want:4", n)
+	}
+}
+
+func TestDownloadRestartsOnChange(t *testing.T) {
+	body := bytes.Repeat([]byte("abcdefghij"), 1000)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	srv, _ := flakyServer(t, body, &etag, 1)
+	var first = true
+	srv.Config.Handler = func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r)
+			if first {
+				first = false
+				etag.Store(`"v2"`)
+				body[0] = 'X'
+			}
+		})
+	}(srv.Config.Handler)
+	data, err := Download(context.Background(), srv.Client(), srv.URL, WithRetryBackoff(time.Millisecond))
+	if err != nil || !bytes.Equal(data, body) {
+		t.Errorf("download len:%v, err:%v", len(data), err)
+	}
+}
+
+func TestDownloadGivesUp(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	_, err := Download(context.Background(), srv.Client(), srv.URL, WithRetries(2), WithRetryBackoff(time.Millisecond))
+	if err == nil || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("calls:%v, err:%v", calls, err)
+	}
+	atomic.StoreInt32(&calls, 0)
+	if _, err := Download(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil || calls != 1 {
+		t.Errorf("not found calls:%v, err:%v", calls, err)
+	}
+	body := make([]byte, 100)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	flaky, _ := flakyServer(t, body, &etag, 0)
+	if _, err := Download(context.Background(), flaky.Client(), flaky.URL, WithLimit(10)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limit err:%v, want:%v", err, ErrLimitExceeded)
+	}
+}
+
+func TestDownloadParallelRanges(t *testing.T) {
+	body := make([]byte, 2<<20)
+	for i := range body {
+		body[i] = byte(i * 7)
+	}
+	var ranged, dropped int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			atomic.AddInt32(&ranged, 1)
+			if atomic.CompareAndSwapInt32(&dropped, 0, 1) {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+	data, err := Download(context.Background(), srv.Client(), srv.URL, WithParallelRanges(4), WithRetryBackoff(time.Millisecond))
+	if err != nil || !bytes.Equal(data, body) {
+		t.Fatalf("download len:%v, err:%v", len(data), err)
+	}
+	if n := atomic.LoadInt32(&ranged); n != 4 {
+		t.Errorf("range requests:%v, want:4", n)
+	}
+}
+
+func TestDownloadProbe(t *testing.T) {
+	body := make([]byte, 1<<20)
+	for i := range body {
+		body[i] = byte(i)
+	}
+	sum := md5.Sum(body)
+	var heads, gets int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		if r.URL.Path == "/nohead" && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(&heads, 1)
+		} else {
+			atomic.AddInt32(&gets, 1)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+
+	res, err := DownloadResult(context.Background(), srv.Client(), srv.URL, WithProbe(), WithParallelRanges(4), WithIntegrityCheck())
+	if err != nil || !bytes.Equal(res.Data, body) {
+		t.Fatalf("download len:%v, err:%v", len(res.Data), err)
+	}
+	p := res.Plan
+	if !p.Probed || p.Size != int64(len(body)) || !p.AcceptRanges || p.Validator != `"v1"` || p.Parts != 4 || p.Checksums["Content-MD5"] == "" {
+		t.Errorf("plan:%+v", p)
+	}
+	if heads != 1 || gets != 4 {
+		t.Errorf("heads:%v, gets:%v, want 1 and 4", heads, gets)
+	}
+
+	res, err = DownloadResult(context.Background(), srv.Client(), srv.URL+"/nohead", WithProbe())
+	if err != nil || !bytes.Equal(res.Data, body) || !res.Plan.Probed || res.Plan.Size != int64(len(body)) || res.Plan.Parts != 1 {
+		t.Errorf("no HEAD plan:%+v, err:%v", res.Plan, err)
+	}
+
+	sum[0]++
+	if _, err := Download(context.Background(), srv.Client(), srv.URL, WithIntegrityCheck()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("checksum err:%v, want:%v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestDownloadRequestDecorator(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 10000)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	srv, calls := flakyServer(t, body, &etag, 2)
+	var tokens int32
+	decorate := func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+strconv.Itoa(int(atomic.AddInt32(&tokens, 1))))
+		return nil
+	}
+	data, err := Download(context.Background(), srv.Client(), srv.URL, WithRetryBackoff(time.Millisecond), WithRequestDecorator(decorate))
+	if err != nil || !bytes.Equal(data, body) {
+		t.Fatalf("download len:%v, err:%v", len(data), err)
+	}
+	if n := atomic.LoadInt32(calls); tokens != n {
+		t.Errorf("decorated:%v, requests:%v", tokens, n)
+	}
+
+	errExpired := errors.New("token expired")
+	_, err = Download(context.Background(), srv.Client(), srv.URL, WithRequestDecorator(func(*http.Request) error { return errExpired }))
+	if !errors.Is(err, errExpired) {
+		t.Errorf("decorator err:%v, want:%v", err, errExpired)
+	}
+}
+
+type countingTransport struct {
+	n int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.n, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestDownloadTransports(t *testing.T) {
+	body := []byte("artifact")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	})
+	origin, mirror := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer origin.Close()
+	defer mirror.Close()
+
+	var shared, internal countingTransport
+	opts := []Option{
+		WithTransport(&shared),
+		WithHostClient(strings.TrimPrefix(mirror.URL, "http://"), &http.Client{Transport: &internal}),
+	}
+	for _, url := range []string{origin.URL, mirror.URL, mirror.URL} {
+		if data, err := Download(context.Background(), nil, url, opts...); err != nil || !bytes.Equal(data, body) {
+			t.Errorf("%v data:%q, err:%v", url, data, err)
+		}
+	}
+	if shared.n != 1 || internal.n != 2 {
+		t.Errorf("shared:%v, internal:%v, want 1 and 2", shared.n, internal.n)
+	}
+}
+
+// flakySource is a RangeSource over data whose first drops reads end with
+// io.ErrUnexpectedEOF after half of their bytes.
+type flakySource struct {
+	data   BytesSource
+	drops  int32
+	reads  int32
+	ranges int32
+}
+
+func (s *flakySource) cut(rc io.ReadCloser, n int64) io.ReadCloser {
+	if atomic.AddInt32(&s.reads, 1) > s.drops {
+		return rc
+	}
+	return ioutil.NopCloser(io.MultiReader(io.LimitReader(rc, n/2), iotest.ErrReader(io.ErrUnexpectedEOF)))
+}
+
+func (s *flakySource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return s.OpenAt(ctx, 0)
+}
+
+func (s *flakySource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	rc, err := s.data.OpenAt(ctx, off)
+	return s.cut(rc, int64(len(s.data))-off), err
+}
+
+func (s *flakySource) OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+	atomic.AddInt32(&s.ranges, 1)
+	rc, err := s.data.OpenRange(ctx, off, n)
+	return s.cut(rc, n), err
+}
+
+func (s *flakySource) Size(ctx context.Context) (int64, error) {
+	return s.data.Size(ctx)
+}
+
+func TestDownloadSource(t *testing.T) {
+	body := make([]byte, 2<<20)
+	for i := range body {
+		body[i] = byte(i * 7)
+	}
+	src := &flakySource{data: body, drops: 3}
+	res, err := DownloadSource(context.Background(), src, WithRetryBackoff(time.Millisecond))
+	if err != nil || !bytes.Equal(res.Data, body) {
+		t.Fatalf("download len:%v, err:%v", len(res.Data), err)
+	}
+	if src.reads != 4 || src.ranges != 0 {
+		t.Errorf("reads:%v, ranges:%v, want 4 and 0", src.reads, src.ranges)
+	}
+
+	src = &flakySource{data: body, drops: 1}
+	res, err = DownloadSource(context.Background(), src, WithProbe(), WithParallelRanges(4), WithRetryBackoff(time.Millisecond))
+	if err != nil || !bytes.Equal(res.Data, body) {
+		t.Fatalf("parallel download len:%v, err:%v", len(res.Data), err)
+	}
+	if p := res.Plan; !p.Probed || p.Parts != 4 || src.ranges != 5 {
+		t.Errorf("plan:%+v, ranges:%v, want 4 parts and 5 ranges", p, src.ranges)
+	}
+
+	if _, err := DownloadSource(context.Background(), &flakySource{data: body}, WithLimit(10)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limit err:%v, want:%v", err, ErrLimitExceeded)
+	}
+}
+
+func TestDownloadNormalizeNewlines(t *testing.T) {
+	body := bytes.Repeat([]byte("ab\r\n"), 1000)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	srv, calls := flakyServer(t, body, &etag, 1)
+	data, err := Download(context.Background(), srv.Client(), srv.URL, WithNormalizeNewlines(LF), WithRetryBackoff(time.Millisecond))
+	if want := bytes.Repeat([]byte("ab\n"), 1000); err != nil || !bytes.Equal(data, want) {
+		t.Fatalf("download len:%v, err:%v", len(data), err)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("calls:%v, want:2", n)
+	}
+}
@@ -0,0 +1,32 @@
+package readall
+
+import "io"
+
+// Drain discards up to max remaining bytes of rc into a pooled scratch
+// buffer and closes it. It reports whether rc reached EOF within max bytes,
+// which for an HTTP response body means the connection can be reused for
+// the next request; a longer or failing body is simply closed, so a peer
+// cannot keep the drain going indefinitely.
+func Drain(rc io.ReadCloser, max int64) bool {
+	defer rc.Close()
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	buf := *bp
+	// Reading one byte past max tells a body of exactly max bytes, ending
+	// with EOF, from a longer one.
+	for left := max; left >= 0; {
+		p := buf
+		if left < int64(len(p))-1 {
+			p = p[:left+1]
+		}
+		n, err := rc.Read(p)
+		left -= int64(n)
+		if err == io.EOF {
+			return left >= 0
+		}
+		if err != nil {
+			return false
+		}
+	}
+	return false
+}
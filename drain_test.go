@@ -0,0 +1,36 @@
+package readall
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	for _, c := range []struct {
+		body string
+		max  int64
+		want bool
+	}{
+		{"", 0, true},
+		{"12345", 5, true},
+		{"12345", 100, true},
+		{"123456", 5, false},
+		{strings.Repeat("x", 100<<10), 64 << 10, false},
+		{strings.Repeat("x", 100<<10), math.MaxInt64, true},
+	} {
+		_, body := response(c.body, -1)
+		if got := Drain(body, c.max); got != c.want || !body.closed {
+			t.Errorf("len %v max %v drained:%v, want:%v, closed:%v", len(c.body), c.max, got, c.want, body.closed)
+		}
+		if rest, want := int64(body.Len()), int64(len(c.body))-c.max-1; !c.want && rest != want {
+			t.Errorf("len %v max %v left:%v, want:%v", len(c.body), c.max, rest, want)
+		}
+	}
+	failing := ioutil.NopCloser(&failAfterReader{r: strings.NewReader("abc"), err: errors.New("reset")})
+	if Drain(failing, 100) {
+		t.Errorf("failing body drained")
+	}
+}
@@ -0,0 +1,86 @@
+package readall
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+)
+
+// SourceError annotates an error with the identity of the source that was
+// being read: a file path, a URL, or the remote address of a connection.
+// Errors returned by the package are wrapped in it whenever the source can
+// be identified; errors.Is and errors.As see through it. Source has been
+// passed through the redactor installed with SetRedactor.
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// withSource wraps err in a SourceError for name, after redaction, unless
+// there is nothing to add: no error, io.EOF, no name, an existing
+// SourceError, or an *os.PathError that already names the path.
+func withSource(name string, err error) error {
+	if err == nil || err == io.EOF || name == "" {
+		return err
+	}
+	var se *SourceError
+	if errors.As(err, &se) {
+		return err
+	}
+	var pe *os.PathError
+	if errors.As(err, &pe) && pe.Path == name {
+		pe.Path = redact(pe.Path)
+		return err
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		// net/http errors repeat the request URL.
+		ue.URL = redact(ue.URL)
+	}
+	return &SourceError{Source: redact(name), Err: err}
+}
+
+// annotate is withSource for deferred use on a named error result.
+// Errors of standard library limiters are translated to *LimitError.
+func annotate(err *error, src interface{}) {
+	*err = withSource(sourceName(src), stdLimitError(*err))
+}
+
+// sourceName identifies src for error messages, or returns "" if it cannot.
+func sourceName(src interface{}) string {
+	switch s := src.(type) {
+	case *os.File:
+		return s.Name()
+	case net.Conn:
+		if addr := s.RemoteAddr(); addr != nil {
+			return addr.Network() + " " + addr.String()
+		}
+	case net.PacketConn:
+		if addr := s.LocalAddr(); addr != nil {
+			return addr.Network() + " " + addr.String()
+		}
+	case fmt.Stringer:
+		return s.String()
+	case *io.LimitedReader:
+		return sourceName(s.R)
+	case Unwrapper:
+		if root := Root(s.Unwrap()); root != nil {
+			if _, ok := root.(Unwrapper); !ok {
+				return sourceName(root)
+			}
+		}
+	}
+	return ""
+}
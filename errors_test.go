@@ -0,0 +1,49 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truncated.delta")
+	os.WriteFile(path, []byte{5, deltaInsert, 5, 'a'}, 0644)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Errorf("open err:%v", err)
+		return
+	}
+	defer f.Close()
+	_, err = ApplyPatch(strings.NewReader(""), f)
+	var se *SourceError
+	if !errors.As(err, &se) || se.Source != path || !errors.Is(err, ErrCorruptDelta) {
+		t.Errorf("patch err:%v", err)
+	}
+
+	_, err = ReadURL(context.Background(), "literal:"+strings.Repeat("x", 100), WithLimit(1))
+	if !errors.As(err, &se) || se.Source != "literal:" {
+		t.Errorf("url err:%v", err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	_, err = ReadURL(context.Background(), missing)
+	if strings.Count(err.Error(), missing) != 1 {
+		t.Errorf("path repeated in err:%v", err)
+	}
+}
+
+func TestSourceNameConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	server.Close()
+	_, err := ReadRangeFrom(client, 0, 1)
+	var se *SourceError
+	if !errors.As(err, &se) || !strings.HasPrefix(se.Source, "pipe") {
+		t.Errorf("conn err:%v", err)
+	}
+}
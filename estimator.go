@@ -0,0 +1,97 @@
+package readall
+
+import "math"
+
+// Estimator accumulates an order-0 entropy estimate of the bytes written to it.
+// It is meant to sit beside a read, through WithEstimator or e.g.
+// io.TeeReader(r, est), so the payload does not need a second pass before
+// deciding whether to compress it.
+type Estimator struct {
+	counts [256]int64
+	total  int64
+}
+
+// WithEstimator feeds the data of a read into e as it is read, like
+// WithHash, for the ReadAll variants and Copy.
+func WithEstimator(e *Estimator) Option {
+	return func(o *options) {
+		o.estimator = e
+	}
+}
+
+// Write records p and never fails.
+func (e *Estimator) Write(p []byte) (int, error) {
+	for _, b := range p {
+		e.counts[b]++
+	}
+	e.total += int64(len(p))
+	return len(p), nil
+}
+
+// Len returns the number of bytes observed so far.
+func (e *Estimator) Len() int64 {
+	return e.total
+}
+
+// Entropy returns the Shannon entropy of the observed bytes in bits per byte,
+// between 0 and 8.
+func (e *Estimator) Entropy() float64 {
+	if e.total == 0 {
+		return 0
+	}
+	var h float64
+	n := float64(e.total)
+	for _, c := range e.counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Ratio estimates the achievable compressed/original size ratio, between 0
+// and 1.
+func (e *Estimator) Ratio() float64 {
+	return e.Entropy() / 8
+}
+
+// Compressible reports whether the estimated ratio is below threshold,
+// e.g. 0.9 to skip compressing payloads that would shrink by less than 10%.
+func (e *Estimator) Compressible(threshold float64) bool {
+	return e.total > 0 && e.Ratio() < threshold
+}
+
+// Reset clears all observations.
+func (e *Estimator) Reset() {
+	*e = Estimator{}
+}
+
+// Histogram returns the number of occurrences of every byte value observed.
+func (e *Estimator) Histogram() [256]int64 {
+	return e.counts
+}
+
+// IsText reports whether the observed bytes look like text rather than binary
+// data: no NUL bytes and at most 1 in 32 other control characters. Bytes
+// >= 0x80 are treated as text so UTF-8 and legacy encodings are not
+// misclassified.
+func (e *Estimator) IsText() bool {
+	if e.total == 0 {
+		return true
+	}
+	if e.counts[0] > 0 {
+		return false
+	}
+	var control int64
+	for b := 1; b < 0x20; b++ {
+		switch b {
+		case '\t', '\n', '\v', '\f', '\r', 0x1b:
+			continue
+		}
+		control += e.counts[b]
+	}
+	control += e.counts[0x7f]
+	return control*32 <= e.total
+}
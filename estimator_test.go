@@ -0,0 +1,61 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEstimator(t *testing.T) {
+	var est Estimator
+	if _, err := io.Copy(ioutil.Discard, io.TeeReader(bytes.NewReader(bytes.Repeat([]byte("a"), 4096)), &est)); err != nil {
+		t.Errorf("copy err:%v", err)
+		return
+	}
+	if est.Entropy() != 0 || !est.Compressible(0.9) {
+		t.Errorf("constant data entropy:%v, compressible:%v", est.Entropy(), est.Compressible(0.9))
+	}
+
+	est.Reset()
+	random := make([]byte, 1<<16)
+	if _, err := rand.Read(random); err != nil {
+		t.Errorf("rand err:%v", err)
+		return
+	}
+	est.Write(random)
+	if est.Entropy() < 7.9 || est.Compressible(0.9) {
+		t.Errorf("random data entropy:%v, compressible:%v", est.Entropy(), est.Compressible(0.9))
+	}
+	if est.Len() != int64(len(random)) {
+		t.Errorf("len:%v, want:%v", est.Len(), len(random))
+	}
+}
+
+func TestWithEstimator(t *testing.T) {
+	var est Estimator
+	data := bytes.Repeat([]byte("estimate "), 1000)
+	if got, err := ReadAll(bytes.NewReader(data), WithEstimator(&est)); err != nil || !bytes.Equal(got, data) || est.Len() != int64(len(data)) {
+		t.Errorf("read len:%v, estimated:%v, err:%v", len(got), est.Len(), err)
+	}
+	est.Reset()
+	if n, err := Copy(ioutil.Discard, bytes.NewReader(data), WithEstimator(&est)); err != nil || est.Len() != n || !est.IsText() {
+		t.Errorf("copy n:%v, estimated:%v, text:%v, err:%v", n, est.Len(), est.IsText(), err)
+	}
+}
+
+func TestEstimatorClassify(t *testing.T) {
+	var est Estimator
+	est.Write([]byte("hello,\r\n\tworld 你好\n"))
+	if !est.IsText() {
+		t.Errorf("text classified as binary")
+	}
+	if h := est.Histogram(); h['l'] != 3 || h['\n'] != 2 {
+		t.Errorf("histogram l:%v, newline:%v", h['l'], h['\n'])
+	}
+	est.Write([]byte{0, 1, 2})
+	if est.IsText() {
+		t.Errorf("binary classified as text")
+	}
+}
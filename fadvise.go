@@ -0,0 +1,30 @@
+package readall
+
+import (
+	"io"
+	"os"
+)
+
+// WithSequentialHint tells the operating system that a file is read once
+// from start to end: before reading, the kernel is advised to read ahead
+// aggressively (POSIX_FADV_SEQUENTIAL), and afterwards to drop the file's
+// pages from the page cache (POSIX_FADV_DONTNEED), so a bulk read does not
+// evict the cache of the rest of the host. It applies to *os.File sources
+// of the ReadAll variants, ReadFile and Copy, and is a no-op on platforms
+// without posix_fadvise and in readall_purego builds.
+func WithSequentialHint() Option {
+	return func(o *options) {
+		o.sequential = true
+	}
+}
+
+// adviseSequential gives the sequential hint for r if o asks for it and
+// returns the function giving the hint that the data is no longer needed.
+func (o *options) adviseSequential(r io.Reader) func() {
+	f, ok := r.(*os.File)
+	if !o.sequential || !ok {
+		return func() {}
+	}
+	fadvise(f, fadvSequential)
+	return func() { fadvise(f, fadvDontNeed) }
+}
@@ -0,0 +1,28 @@
+//go:build linux && (amd64 || arm64) && !readall_purego
+// +build linux
+// +build amd64 arm64
+// +build !readall_purego
+
+package readall
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	fadvSequential = 2 // POSIX_FADV_SEQUENTIAL
+	fadvDontNeed   = 4 // POSIX_FADV_DONTNEED
+)
+
+// fadvise applies advice to the whole of f, ignoring failures: the advice is
+// only a hint.
+func fadvise(f *os.File, advice int) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	rc.Control(func(fd uintptr) {
+		syscall.Syscall6(syscall.SYS_FADVISE64, fd, 0, 0, uintptr(advice), 0, 0)
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !linux || !(amd64 || arm64) || readall_purego
+// +build !linux !amd64,!arm64 readall_purego
+
+package readall
+
+import "os"
+
+const (
+	fadvSequential = 0
+	fadvDontNeed   = 0
+)
+
+// fadvise has no posix_fadvise to call on this platform or build.
+func fadvise(f *os.File, advice int) {}
@@ -0,0 +1,25 @@
+package readall
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithSequentialHint(t *testing.T) {
+	data := bytes.Repeat([]byte("sequential"), 10000)
+	path := filepath.Join(t.TempDir(), "seq")
+	ioutil.WriteFile(path, data, 0644)
+	res, err := ReadFile(path, WithSequentialHint(), WithMmap(MmapNever))
+	if err != nil || !bytes.Equal(res.Data, data) {
+		t.Errorf("readfile len:%v, err:%v", len(res.Data), err)
+	}
+	f, _ := os.Open(path)
+	defer f.Close()
+	got, err := ReadAll(f, WithSequentialHint())
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("readall len:%v, err:%v", len(got), err)
+	}
+}
@@ -0,0 +1,98 @@
+package readall
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrMmapUnsupported is returned by ReadFile with MmapAlways on platforms or
+// builds (readall_purego) without memory mapping.
+var ErrMmapUnsupported = errors.New("readall: mmap not supported")
+
+// DefaultMmapThreshold is the file size from which ReadFile maps files
+// instead of reading them.
+const DefaultMmapThreshold = 4 << 20
+
+// MmapMode selects how ReadFile loads a file.
+type MmapMode int
+
+const (
+	// MmapAuto maps files of at least the mmap threshold and reads smaller
+	// ones, falling back to reading if mapping fails.
+	MmapAuto MmapMode = iota
+	// MmapAlways maps every file and fails if that is not possible.
+	MmapAlways
+	// MmapNever always reads the file into a heap buffer.
+	MmapNever
+)
+
+// WithMmap sets how ReadFile loads files, e.g. to benchmark both paths.
+func WithMmap(mode MmapMode) Option {
+	return func(o *options) {
+		o.mmap = mode
+	}
+}
+
+// WithMmapThreshold sets the file size from which MmapAuto maps files.
+func WithMmapThreshold(n int64) Option {
+	return func(o *options) {
+		o.mmapThreshold = n
+	}
+}
+
+// ReadFile returns the contents of the file at path. Files of at least the
+// mmap threshold (DefaultMmapThreshold, see WithMmapThreshold) are memory
+// mapped read-only on Linux, the BSDs, macOS and Windows; Release unmaps
+// them, and writing to mapped Data faults. Other files are read with one
+// allocation sized from Stat, and Release is nil. WithStripBOM and, for
+// files that are read, WithSequentialHint apply; WithDirectIO reads the file
+// bypassing the page cache instead.
+//
+// A mapped file must not be truncated while mapped: touching pages past the
+// new end raises SIGBUS on Unix systems.
+func ReadFile(path string, opts ...Option) (_ Result, err error) {
+	defer func() { err = withSource(path, err) }()
+	var o options
+	o.apply(opts)
+	if o.directIO {
+		data, err := readFileDirect(path)
+		return o.applyBOM(Result{Data: data}), err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+
+	threshold := o.mmapThreshold
+	if threshold <= 0 {
+		threshold = DefaultMmapThreshold
+	}
+	if o.mmap != MmapNever {
+		// Mapping needs the exact current size, so bypass any StatCache.
+		fi, err := f.Stat()
+		if err != nil {
+			return Result{}, err
+		}
+		size := fi.Size()
+		if o.mmap == MmapAlways || (fi.Mode().IsRegular() && size >= threshold) {
+			data, release, err := mmapFile(f, size)
+			if err == nil {
+				return o.applyBOM(Result{Data: data, Release: release}), nil
+			}
+			if o.mmap == MmapAlways {
+				return Result{}, err
+			}
+		}
+		defer o.adviseSequential(f)()
+		data, err := readAllCap(f, size+1, nil)
+		return o.applyBOM(Result{Data: data}), err
+	}
+	fi, err := o.stat(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer o.adviseSequential(f)()
+	data, err := readAllCap(f, fi.Size()+1, nil)
+	return o.applyBOM(Result{Data: data}), err
+}
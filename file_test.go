@@ -0,0 +1,75 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	data := bytes.Repeat([]byte("mapped "), 10000)
+	ioutil.WriteFile(path, data, 0644)
+
+	res, err := ReadFile(path)
+	if err != nil || !bytes.Equal(res.Data, data) || res.Release != nil {
+		t.Errorf("small file len:%v, mapped:%v, err:%v", len(res.Data), res.Release != nil, err)
+	}
+	res, err = ReadFile(path, WithMmapThreshold(1000))
+	if err != nil && !errors.Is(err, ErrMmapUnsupported) {
+		t.Errorf("threshold err:%v", err)
+	}
+	if !bytes.Equal(res.Data, data) {
+		t.Errorf("threshold len:%v", len(res.Data))
+	}
+	if res.Release != nil {
+		res.Release()
+		res.Release()
+	}
+
+	res, err = ReadFile(path, WithMmap(MmapAlways))
+	if errors.Is(err, ErrMmapUnsupported) {
+		t.Skip("mmap not supported")
+	}
+	if err != nil || res.Release == nil || !bytes.Equal(res.Data, data) {
+		t.Errorf("forced mmap len:%v, err:%v", len(res.Data), err)
+		return
+	}
+	res.Release()
+
+	empty := filepath.Join(dir, "empty")
+	ioutil.WriteFile(empty, nil, 0644)
+	if res, err := ReadFile(empty, WithMmap(MmapAlways)); err != nil || len(res.Data) != 0 {
+		t.Errorf("empty mmap len:%v, err:%v", len(res.Data), err)
+	}
+	res, err = ReadFile(path, WithMmap(MmapNever), WithMmapThreshold(1))
+	if err != nil || res.Release != nil || !bytes.Equal(res.Data, data) {
+		t.Errorf("forced read len:%v, err:%v", len(res.Data), err)
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing err:%v", err)
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		mode MmapMode
+	}{{"mmap", MmapAlways}, {"read", MmapNever}} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				res, err := ReadFile(testName, WithMmap(c.mode))
+				if err != nil {
+					b.Skipf("read err:%v", err)
+				}
+				if res.Release != nil {
+					res.Release()
+				}
+			}
+		})
+	}
+}
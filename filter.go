@@ -0,0 +1,43 @@
+package readall
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Filter reads r line by line and returns only the lines for which keep
+// returns true, including their "\n", in one pass and without holding the
+// rest of the input. keep sees each line without its "\n"; the slice is only
+// valid during the call. A final line without "\n" is passed as well.
+func Filter(r io.Reader, keep func(line []byte) bool) (_ []byte, err error) {
+	defer annotate(&err, r)
+	br := bufioPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	defer func() {
+		br.Reset(nil)
+		bufioPool.Put(br)
+	}()
+	var out, long []byte
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// A line longer than the buffer is gathered in long.
+			long = append(long, line...)
+			continue
+		}
+		if len(long) > 0 {
+			long = append(long, line...)
+			line, long = long, long[:0]
+		}
+		if len(line) > 0 && keep(bytes.TrimSuffix(line, []byte{'\n'})) {
+			out = append(out, line...)
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package readall
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFilter(t *testing.T) {
+	long := "ERROR " + strings.Repeat("x", 100<<10)
+	in := "INFO start\nERROR disk\nINFO ok\n" + long + "\nERROR tail"
+	got, err := Filter(iotest.HalfReader(strings.NewReader(in)), func(line []byte) bool {
+		return bytes.HasPrefix(line, []byte("ERROR"))
+	})
+	want := "ERROR disk\n" + long + "\nERROR tail"
+	if err != nil || string(got) != want {
+		t.Errorf("filter len:%v, want:%v, err:%v", len(got), len(want), err)
+	}
+	if got, err := Filter(strings.NewReader(""), func([]byte) bool { return true }); err != nil || len(got) != 0 {
+		t.Errorf("empty:%q, err:%v", got, err)
+	}
+}
@@ -0,0 +1,264 @@
+package readall
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultFollowPoll is how often Follow checks a file for new data when no
+// change notification arrives.
+const DefaultFollowPoll = 250 * time.Millisecond
+
+// WithPollInterval sets how often Follow polls the followed file.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.poll = d
+	}
+}
+
+// WithRotatedNames sets the files Follow searches for the rest of a rotated
+// log when the starting offset lies past the end of the followed file. The
+// default is path+".1" and path+".0".
+func WithRotatedNames(names ...string) Option {
+	return func(o *options) {
+		o.rotated = names
+	}
+}
+
+// WithDecompressRotated lets Follow read gzip or zlib compressed rotated
+// files and adds path+".1.gz" and path+".gz" to the default rotated names.
+func WithDecompressRotated() Option {
+	return func(o *options) {
+		o.gunzip = true
+	}
+}
+
+// backfillChunk is the size of the blocks read concurrently by a backfill.
+const backfillChunk = 1 << 20
+
+// WithBackfill makes Follow read the data already in the file with workers
+// concurrent positional reads before it starts following appends. Chunks
+// are still delivered in order, so the consumer sees one ordered stream.
+func WithBackfill(workers int) Option {
+	return func(o *options) {
+		o.backfill = workers
+	}
+}
+
+// Follow reads the file at path starting at fromOffset and keeps reading as
+// data is appended, like tail -f, calling fn for every chunk with the offset
+// of data within the current file. data is only valid until fn returns. A
+// negative fromOffset starts at the current end of the file.
+//
+// A fromOffset past the end of the file means the file was rotated since
+// that offset was recorded: Follow first delivers the rest of the rotated
+// file (see WithRotatedNames) and then reads the new file from the start.
+// When the file shrinks below the read position it is treated as truncated
+// and read again from the start. When path is replaced by a new file
+// (rotation), the old file is read to its end before Follow switches to the
+// new one at offset 0. On Linux, unless built with the readall_purego tag,
+// inotify wakes Follow as soon as the directory changes; everywhere else, and
+// as a fallback, the file is polled.
+//
+// Follow returns when ctx is done, when fn returns an error, when reading
+// fails, or with ErrShutdown once the Group of WithGroup is shut down and
+// the data available has been delivered.
+func Follow(ctx context.Context, path string, fromOffset int64, fn func(off int64, data []byte) error, opts ...Option) (err error) {
+	defer func() { err = withSource(path, err) }()
+	var o options
+	o.apply(opts)
+	if o.offsets != nil {
+		stored, ok, err := o.offsets.Load(o.offsetKey)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fromOffset = stored
+		}
+		deliver := fn
+		fn = func(off int64, data []byte) error {
+			if err := deliver(off, data); err != nil {
+				return err
+			}
+			return o.offsets.Store(o.offsetKey, off+int64(len(data)))
+		}
+	}
+	poll := o.poll
+	if poll <= 0 {
+		poll = DefaultFollowPoll
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	cur, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	buf := *bp
+
+	off := fromOffset
+	switch {
+	case off < 0:
+		off = cur.Size()
+	case off > cur.Size():
+		if err := followRotated(path, off, &o, fn, buf); err != nil {
+			return err
+		}
+		off = 0
+	}
+	if o.backfill > 0 && off < cur.Size() {
+		if err := readAtOrdered(ctx, f, off, cur.Size(), backfillChunk, o.backfill, &o, fn); err != nil {
+			return err
+		}
+		off = cur.Size()
+	}
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+
+	wake, stop := watchDir(path)
+	defer stop()
+	tick := time.NewTicker(poll)
+	defer tick.Stop()
+
+	for {
+		// A file that keeps growing never leaves the data branch, so ctx is
+		// checked before every Read and not only while idle.
+		if ctx.Err() != nil {
+			return ctxErr(ctx)
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := fn(off, buf[:n]); err != nil {
+				return err
+			}
+			off += int64(n)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		// At the end of the open file: look for rotation or truncation.
+		if fi, err := os.Stat(path); err == nil && !os.SameFile(fi, cur) {
+			nf, err := os.Open(path)
+			if err == nil {
+				f.Close()
+				f, off = nf, 0
+				if cur, err = f.Stat(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if fi, err := f.Stat(); err == nil && fi.Size() < off {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			off = 0
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctxErr(ctx)
+		case <-o.group.done():
+			return ErrShutdown
+		case <-wake:
+		case <-tick.C:
+		}
+	}
+}
+
+// followRotated delivers the data past off of the first rotated file of path
+// that is long enough to contain off. Finding none is not an error: the
+// rotated data is gone and Follow carries on with the current file.
+func followRotated(path string, off int64, o *options, fn func(int64, []byte) error, buf []byte) error {
+	names := o.rotated
+	if names == nil {
+		names = []string{path + ".1", path + ".0"}
+		if o.gunzip {
+			names = append(names, path+".1.gz", path+".gz")
+		}
+	}
+	for _, name := range names {
+		ok, err := followRemainder(name, off, o.gunzip, fn, buf)
+		if ok || err != nil {
+			return withSource(name, err)
+		}
+	}
+	return nil
+}
+
+func followRemainder(name string, off int64, gunzip bool, fn func(int64, []byte) error, buf []byte) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gunzip {
+		if r, err = decompress(f); err != nil {
+			return false, nil
+		}
+	}
+	if err := discard(r, off); err != nil {
+		return false, nil
+	}
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := fn(off, buf[:n]); err != nil {
+				return true, err
+			}
+			off += int64(n)
+		}
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, err
+		}
+	}
+}
+
+// FollowChunk is one chunk of data sent by FollowChan, or the error that
+// ended following.
+type FollowChunk struct {
+	// Offset is the offset of Data within the file it was read from.
+	Offset int64
+	Data   []byte
+	Err    error
+}
+
+// FollowChan is Follow delivering the chunks on a channel that is closed
+// when following ends. Each Data is a copy the receiver may keep. An error
+// other than ctx being done is sent as a final FollowChunk with Err set.
+func FollowChan(ctx context.Context, path string, fromOffset int64, opts ...Option) <-chan FollowChunk {
+	ch := make(chan FollowChunk)
+	go func() {
+		defer close(ch)
+		err := Follow(ctx, path, fromOffset, func(off int64, data []byte) error {
+			select {
+			case ch <- FollowChunk{Offset: off, Data: append([]byte(nil), data...)}:
+				return nil
+			case <-ctx.Done():
+				return ctxErr(ctx)
+			}
+		}, opts...)
+		if err != nil && ctx.Err() == nil {
+			select {
+			case ch <- FollowChunk{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return ch
+}
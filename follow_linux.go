@@ -0,0 +1,45 @@
+//go:build linux && !readall_purego
+// +build linux,!readall_purego
+
+package readall
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// watchDir returns a channel that receives a value whenever an entry of the
+// directory containing path changes. Watching the directory rather than the
+// file keeps working across rotation. If inotify is unavailable the channel
+// never fires and Follow relies on polling.
+func watchDir(path string) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return wake, func() {}
+	}
+	const mask = syscall.IN_MODIFY | syscall.IN_CREATE | syscall.IN_DELETE |
+		syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		syscall.Close(fd)
+		return wake, func() {}
+	}
+	// A non-blocking descriptor is registered with the runtime poller, so
+	// the reading goroutine parks instead of holding a thread, and Close
+	// unblocks it.
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return wake, func() { f.Close() }
+}
@@ -0,0 +1,10 @@
+//go:build !linux || readall_purego
+// +build !linux readall_purego
+
+package readall
+
+// watchDir has no change notification outside Linux or in readall_purego
+// builds; Follow polls instead.
+func watchDir(path string) (<-chan struct{}, func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,195 @@
+package readall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startFollow runs Follow in the background and returns a function that
+// waits until the delivered data equals want.
+func startFollow(t *testing.T, ctx context.Context, path string, from int64, opts ...Option) (func(want string) bool, <-chan error) {
+	data := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, from, func(off int64, p []byte) error {
+			data <- string(p)
+			return nil
+		}, opts...)
+	}()
+	var got strings.Builder
+	return func(want string) bool {
+		timeout := time.After(5 * time.Second)
+		for got.String() != want {
+			select {
+			case s := <-data:
+				got.WriteString(s)
+			case <-timeout:
+				t.Errorf("followed:%q, want:%q", got.String(), want)
+				return false
+			}
+		}
+		return true
+	}, done
+}
+
+func appendFile(path, s string) {
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	f.WriteString(s)
+	f.Close()
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ioutil.WriteFile(path, []byte("old line\n"), 0644)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wait, done := startFollow(t, ctx, path, 3, WithPollInterval(10*time.Millisecond))
+	if !wait(" line\n") {
+		return
+	}
+	appendFile(path, "appended\n")
+	if !wait(" line\nappended\n") {
+		return
+	}
+
+	os.Rename(path, path+".1")
+	appendFile(path+".1", "late\n")
+	appendFile(path, "rotated\n")
+	if !wait(" line\nappended\nlate\nrotated\n") {
+		return
+	}
+
+	os.Truncate(path, 0)
+	appendFile(path, "new\n")
+	if !wait(" line\nappended\nlate\nrotated\nnew\n") {
+		return
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("follow err:%v", err)
+	}
+}
+
+func TestFollowFromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ioutil.WriteFile(path, []byte("skipped\n"), 0644)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wait, _ := startFollow(t, ctx, path, -1, WithPollInterval(10*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	appendFile(path, "tail\n")
+	wait("tail\n")
+}
+
+func TestFollowRotatedRemainder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	ioutil.WriteFile(path, []byte("new\n"), 0644)
+	ioutil.WriteFile(path+".1", []byte("short"), 0644)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("0123456789"))
+	zw.Close()
+	ioutil.WriteFile(path+".1.gz", gz.Bytes(), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wait, _ := startFollow(t, ctx, path, 6, WithPollInterval(10*time.Millisecond), WithDecompressRotated())
+	wait("6789new\n")
+}
+
+func TestFollowBackfill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	existing := bytes.Repeat([]byte("0123456789abcdef"), 300000)
+	ioutil.WriteFile(path, existing, 0644)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wait, _ := startFollow(t, ctx, path, 5, WithPollInterval(10*time.Millisecond), WithBackfill(3))
+	if wait(string(existing[5:])) {
+		appendFile(path, "live\n")
+		wait(string(existing[5:]) + "live\n")
+	}
+}
+
+func TestFollowChan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ioutil.WriteFile(path, []byte("first\n"), 0644)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := FollowChan(ctx, path, 0, WithPollInterval(10*time.Millisecond))
+	var got strings.Builder
+	recv := func(want string) {
+		timeout := time.After(5 * time.Second)
+		for got.String() != want {
+			select {
+			case c := <-ch:
+				if c.Err != nil || c.Offset != int64(got.Len()) {
+					t.Fatalf("chunk:%+v", c)
+				}
+				got.Write(c.Data)
+			case <-timeout:
+				t.Fatalf("followed:%q, want:%q", got.String(), want)
+			}
+		}
+	}
+	recv("first\n")
+	appendFile(path, "second\n")
+	recv("first\nsecond\n")
+	cancel()
+	for c := range ch {
+		t.Errorf("chunk after cancel:%+v", c)
+	}
+
+	if c := <-FollowChan(context.Background(), filepath.Join(t.TempDir(), "missing"), 0); c.Err == nil {
+		t.Errorf("missing file chunk:%+v", c)
+	}
+}
+
+func TestFollowCancelWhileGrowing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	ioutil.WriteFile(path, bytes.Repeat([]byte("x"), 4<<20), 0644)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		chunk := bytes.Repeat([]byte("y"), 64<<10)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				f.Write(chunk)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, 0, func(off int64, data []byte) error {
+			calls++
+			cancel()
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("calls:%v, err:%v, want:%v", calls, err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("follow did not return after cancel")
+	}
+}
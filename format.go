@@ -0,0 +1,104 @@
+package readall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedFormat is returned when persisted state is of another kind
+// than expected or was written by a newer version of the package. The file
+// is left untouched so that the newer version can still use it.
+var ErrUnsupportedFormat = errors.New("readall: unsupported state format")
+
+// FormatError reports persisted state that cannot be read.
+type FormatError struct {
+	// Format and Version are found in the state; Want is the format
+	// expected and Max the newest version understood.
+	Format  string
+	Version int
+	Want    string
+	Max     int
+}
+
+func (e *FormatError) Error() string {
+	if e.Format != e.Want {
+		return fmt.Sprintf("readall: state format %q, want %q", e.Format, e.Want)
+	}
+	return fmt.Sprintf("readall: %s state version %d is newer than %d", e.Format, e.Version, e.Max)
+}
+
+// Is makes errors.Is(err, ErrUnsupportedFormat) match.
+func (e *FormatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// stateFormat is one kind of persisted state: the offset store, resume
+// checkpoints and the ingest journal. State is written as a JSON envelope
+// naming the format and its version. Reading migrates older versions step
+// by step; version 0 is the unversioned layout written before envelopes
+// were introduced, which is the bare data.
+type stateFormat struct {
+	name    string
+	version int
+	// migrate[v] converts data of version v to version v+1; nil keeps the
+	// data as is.
+	migrate []func(json.RawMessage) (json.RawMessage, error)
+}
+
+type stateEnvelope struct {
+	Format  string          `json:"format"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+var (
+	offsetsFormat    = stateFormat{name: "readall/offsets", version: 1, migrate: make([]func(json.RawMessage) (json.RawMessage, error), 1)}
+	checkpointFormat = stateFormat{name: "readall/checkpoint", version: 1, migrate: make([]func(json.RawMessage) (json.RawMessage, error), 1)}
+	ingestFormat     = stateFormat{name: "readall/ingest", version: 1, migrate: make([]func(json.RawMessage) (json.RawMessage, error), 1)}
+)
+
+// marshal encodes v in the current version of f; a nil v writes only the
+// envelope.
+func (f *stateFormat) marshal(v interface{}) ([]byte, error) {
+	env := stateEnvelope{Format: f.name, Version: f.version}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		env.Data = data
+	}
+	return json.Marshal(env)
+}
+
+// envelope parses b as an envelope of f, reporting false for unversioned
+// data.
+func (f *stateFormat) envelope(b []byte) (stateEnvelope, bool, error) {
+	var env stateEnvelope
+	if json.Unmarshal(b, &env) != nil || env.Format == "" {
+		return stateEnvelope{Data: b}, false, nil
+	}
+	if env.Format != f.name || env.Version > f.version || env.Version < 0 {
+		return env, true, &FormatError{Format: env.Format, Version: env.Version, Want: f.name, Max: f.version}
+	}
+	return env, true, nil
+}
+
+// unmarshal decodes state written by any version of f into v, migrating it
+// to the current version first.
+func (f *stateFormat) unmarshal(b []byte, v interface{}) error {
+	env, _, err := f.envelope(b)
+	if err != nil {
+		return err
+	}
+	data := env.Data
+	for ver := env.Version; ver < f.version; ver++ {
+		if m := f.migrate[ver]; m != nil {
+			if data, err = m(data); err != nil {
+				return fmt.Errorf("readall: migrating %s state from version %d: %w", f.name, ver, err)
+			}
+		}
+	}
+	return json.Unmarshal(data, v)
+}
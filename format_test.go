@@ -0,0 +1,87 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "format")
+	if err != nil {
+		t.Fatalf("tempdir err:%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	offsets := filepath.Join(dir, "offsets")
+	ioutil.WriteFile(offsets, []byte(`{"a.log":42}`), 0644)
+	s, err := OpenFileOffsetStore(offsets)
+	if err != nil {
+		t.Fatalf("open legacy offsets err:%v", err)
+	}
+	if off, ok, _ := s.Load("a.log"); !ok || off != 42 {
+		t.Errorf("legacy offset:%v, ok:%v", off, ok)
+	}
+	if err := s.Store("b.log", 7); err != nil {
+		t.Fatalf("store err:%v", err)
+	}
+	b, _ := ioutil.ReadFile(offsets)
+	if !bytes.HasPrefix(b, []byte(`{"format":"readall/offsets","version":1,`)) {
+		t.Errorf("offsets file:%s", b)
+	}
+	if s, err = OpenFileOffsetStore(offsets); err != nil {
+		t.Fatalf("reopen err:%v", err)
+	}
+	if off, ok, _ := s.Load("b.log"); !ok || off != 7 {
+		t.Errorf("reopened offset:%v, ok:%v", off, ok)
+	}
+
+	for _, state := range []string{
+		`{"format":"readall/offsets","version":2,"data":{}}`,
+		`{"format":"readall/checkpoint","version":1,"data":{}}`,
+	} {
+		ioutil.WriteFile(offsets, []byte(state), 0644)
+		if _, err := OpenFileOffsetStore(offsets); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("state %s err:%v, want:%v", state, err, ErrUnsupportedFormat)
+		}
+	}
+
+	cp := filepath.Join(dir, "cp")
+	ioutil.WriteFile(cp, []byte(`{"offset":5}`), 0644)
+	if c, err := loadCheckpoint(cp); err != nil || c.Offset != 5 {
+		t.Errorf("legacy checkpoint:%+v, err:%v", c, err)
+	}
+}
+
+func TestIngestJournalMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "format")
+	if err != nil {
+		t.Fatalf("tempdir err:%v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "journal")
+	var legacy bytes.Buffer
+	AppendSegment(&legacy, []byte(`{"path":"x","size":1}`))
+	ioutil.WriteFile(journal, legacy.Bytes(), 0644)
+
+	in, err := OpenIngestor(journal)
+	if err != nil {
+		t.Fatalf("open legacy journal err:%v", err)
+	}
+	if !in.Done("x") {
+		t.Errorf("legacy record lost")
+	}
+	in.Close()
+	b, _ := ioutil.ReadFile(journal)
+	sr := ReadSegments(bytes.NewReader(b))
+	if !sr.Next() || !bytes.Contains(sr.Record(), []byte(`"readall/ingest"`)) {
+		t.Errorf("journal not versioned:%q", b)
+	}
+	if in, err = OpenIngestor(journal); err != nil || !in.Done("x") {
+		t.Errorf("reopen err:%v", err)
+	}
+	in.Close()
+}
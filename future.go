@@ -0,0 +1,47 @@
+package readall
+
+import (
+	"context"
+	"io"
+)
+
+// Future is a read started by Go.
+type Future struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// Go starts reading r until EOF on a goroutine, like ReadAllContext with
+// opts, and returns at once. Join the read with Wait or Bytes, or select on
+// Done.
+//
+//	a, b := readall.Go(ctx, bodyA), readall.Go(ctx, bodyB)
+//	dataA, errA := a.Bytes()
+//	dataB, errB := b.Bytes()
+func Go(ctx context.Context, r io.Reader, opts ...Option) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.data, f.err = ReadAllContext(ctx, r, opts...)
+	}()
+	return f
+}
+
+// Done is closed when the read has finished.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the read to finish and returns its error.
+func (f *Future) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// Bytes waits for the read to finish and returns the data and error of
+// ReadAllContext.
+func (f *Future) Bytes() ([]byte, error) {
+	<-f.done
+	return f.data, f.err
+}
@@ -0,0 +1,39 @@
+package readall
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	pr, pw := io.Pipe()
+	a := Go(context.Background(), pr)
+	b := Go(context.Background(), strings.NewReader("second"))
+	if data, err := b.Bytes(); err != nil || string(data) != "second" {
+		t.Errorf("b data:%q, err:%v", data, err)
+	}
+	select {
+	case <-a.Done():
+		t.Fatalf("a done before its writer")
+	default:
+	}
+	pw.Write([]byte("first"))
+	pw.Close()
+	if err := a.Wait(); err != nil {
+		t.Errorf("a err:%v", err)
+	}
+	if data, _ := a.Bytes(); string(data) != "first" {
+		t.Errorf("a data:%q", data)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw = io.Pipe()
+	defer pw.Close()
+	c := Go(ctx, pr)
+	cancel()
+	if err := c.Wait(); err != context.Canceled {
+		t.Errorf("c err:%v, want:%v", err, context.Canceled)
+	}
+}
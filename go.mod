@@ -1,3 +1,3 @@
 module readall
 
-go 1.15
+go 1.17
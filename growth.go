@@ -0,0 +1,115 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+)
+
+// growth is the buffer policy of the ReadAll variants. The zero value sizes
+// the buffer from the reader and grows it like append.
+type growth struct {
+	initial int64
+	factor  float64
+	max     int64 // 0 means unbounded
+	chunk   int
+}
+
+// WithInitialCapacity sets the buffer size used when the reader does not
+// reveal its size. A known size still takes precedence.
+func WithInitialCapacity(n int) Option {
+	return func(o *options) {
+		o.growth.initial = int64(n)
+	}
+}
+
+// WithGrowthFactor multiplies the buffer capacity by f whenever it fills up,
+// instead of following append. Values of 1 or below restore the default.
+func WithGrowthFactor(f float64) Option {
+	return func(o *options) {
+		o.growth.factor = f
+	}
+}
+
+// WithMaxCapacity bounds the buffer at n bytes plus the spare byte used to
+// detect EOF, counted from the end of dst for AppendAll. A reader holding
+// more than n bytes fails with a *LimitError, like WithLimit, but the bound
+// also caps preallocation and growth steps.
+func WithMaxCapacity(n int64) Option {
+	return func(o *options) {
+		o.growth.max = n
+	}
+}
+
+// WithChunkSize caps how many bytes are requested from the reader per Read,
+// for readers that behave badly with large buffers. The buffer itself still
+// grows as needed.
+func WithChunkSize(n int) Option {
+	return func(o *options) {
+		o.growth.chunk = n
+	}
+}
+
+// capacity returns the initial buffer size for a reader whose size hint
+// (from readAllSize) is hint.
+func (g *growth) capacity(r io.Reader, hint int64) int64 {
+	if _, ok := sizeHint(r); !ok && g.initial > 0 {
+		return g.initial
+	}
+	return hint
+}
+
+// grow returns b with spare capacity, acquiring the new buffer from u if
+// there is a budget and recording it in st. The data read starts at
+// b[start], which the maximum capacity is measured from.
+func (g *growth) grow(b []byte, start int, u *budgetUse, st *readStats) ([]byte, error) {
+	if g.factor <= 1 && g.max <= 0 && u == nil {
+		b = append(b, 0)[:len(b)]
+		st.grew(int64(cap(b)))
+		return b, nil
+	}
+	n := int64(cap(b)) * 2
+	if g.factor > 1 {
+		n = int64(float64(cap(b)) * g.factor)
+	}
+	if n < int64(cap(b))+bytes.MinRead {
+		n = int64(cap(b)) + bytes.MinRead
+	}
+	if g.max > 0 && n-1-int64(start) > g.max {
+		n = int64(start) + g.max + 1
+	}
+	if n <= int64(len(b)) {
+		return b, &LimitError{Limit: g.max, Read: g.max + 1}
+	}
+	if err := u.grow(n); err != nil {
+		return b, err
+	}
+	nb := make([]byte, len(b), n)
+	copy(nb, b)
+	st.grew(n)
+	return nb, nil
+}
+
+// appendGrow is core.Append following g and u, recording growth in st.
+func (g *growth) appendGrow(dst []byte, r io.Reader, u *budgetUse, st *readStats) ([]byte, error) {
+	start := len(dst)
+	for {
+		if len(dst) == cap(dst) {
+			var err error
+			if dst, err = g.grow(dst, start, u, st); err != nil {
+				return dst, err
+			}
+		}
+		p := dst[len(dst):cap(dst)]
+		if g.chunk > 0 && len(p) > g.chunk {
+			p = p[:g.chunk]
+		}
+		n, err := r.Read(p)
+		dst = dst[:len(dst)+n]
+		if err == io.EOF {
+			return dst, nil
+		}
+		if err != nil {
+			return dst, err
+		}
+	}
+}
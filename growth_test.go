@@ -0,0 +1,86 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// chunkRecorder hides the size of r and records the Read buffer sizes.
+type chunkRecorder struct {
+	r     io.Reader
+	sizes []int
+}
+
+func (c *chunkRecorder) Read(p []byte) (int, error) {
+	c.sizes = append(c.sizes, len(p))
+	return c.r.Read(p)
+}
+
+func TestGrowthOptions(t *testing.T) {
+	data := strings.Repeat("g", 10000)
+
+	got, err := ReadAll(struct{ io.Reader }{strings.NewReader("tiny")}, WithInitialCapacity(16))
+	if err != nil || string(got) != "tiny" || cap(got) != 16 {
+		t.Errorf("initial: %q, cap:%v, err:%v", got, cap(got), err)
+	}
+
+	cr := &chunkRecorder{r: strings.NewReader(data)}
+	got, err = ReadAll(cr, WithInitialCapacity(1000), WithGrowthFactor(4))
+	if err != nil || string(got) != data || cap(got) != 16000 {
+		t.Errorf("factor: len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+
+	cr = &chunkRecorder{r: strings.NewReader(data)}
+	got, err = ReadAll(cr, WithChunkSize(1024))
+	if err != nil || string(got) != data {
+		t.Errorf("chunk: len:%v, err:%v", len(got), err)
+	}
+	for _, n := range cr.sizes {
+		if n > 1024 {
+			t.Errorf("read of %v bytes, want at most 1024", n)
+			break
+		}
+	}
+
+	got, err = ReadAll(struct{ io.Reader }{strings.NewReader(data)}, WithMaxCapacity(5000))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != 5000 || len(got) != 5000 || cap(got) > 5001 {
+		t.Errorf("max: len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+	if got, err := ReadAll(strings.NewReader(data), WithMaxCapacity(10000)); err != nil || cap(got) != 10001 {
+		t.Errorf("max fits: cap:%v, err:%v", cap(got), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cr = &chunkRecorder{r: strings.NewReader(data)}
+	got, err = ReadAllContext(ctx, cr, WithChunkSize(100), WithLimit(200))
+	if !errors.Is(err, ErrLimitExceeded) || len(got) != 200 || len(cr.sizes) != 3 {
+		t.Errorf("context: len:%v, reads:%v, err:%v", len(got), cr.sizes, err)
+	}
+}
+
+func TestMaxCapacityPrefilled(t *testing.T) {
+	data := strings.Repeat("m", 20)
+	got, err := AppendAll(make([]byte, 100, 100), iotest.OneByteReader(strings.NewReader(data)), WithMaxCapacity(10))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != 10 || len(got) != 110 {
+		t.Errorf("prefilled: len:%v, err:%v", len(got), err)
+	}
+	got, err = AppendAll(make([]byte, 5, 5), struct{ io.Reader }{strings.NewReader(data)}, WithMaxCapacity(10))
+	if !errors.As(err, &le) || len(got) != 15 || cap(got) > 16 {
+		t.Errorf("short dst: len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+	got, err = AppendAll(make([]byte, 5, 5), struct{ io.Reader }{strings.NewReader(data[:10])}, WithMaxCapacity(10))
+	if err != nil || len(got) != 15 {
+		t.Errorf("fits: len:%v, err:%v", len(got), err)
+	}
+	got, err = ReadAllInto(iotest.OneByteReader(strings.NewReader(data)), make([]byte, 100), WithMaxCapacity(10))
+	if !errors.As(err, &le) || len(got) != 10 {
+		t.Errorf("into: len:%v, err:%v", len(got), err)
+	}
+}
@@ -0,0 +1,79 @@
+package readall
+
+import (
+	"bytes"
+	"hash"
+	"io"
+)
+
+// WithHash feeds the data of a read through h as it is read, as ReadAllHash
+// does. Several WithHash options hash into each of their hashes.
+func WithHash(h hash.Hash) Option {
+	return func(o *options) {
+		o.hashes = append(o.hashes, h)
+	}
+}
+
+func (o *options) withHash(r io.Reader) io.Reader {
+	if o.estimator != nil {
+		r = wrappedReader{Reader: io.TeeReader(r, o.estimator), src: r}
+	}
+	switch len(o.hashes) {
+	case 0:
+		return r
+	case 1:
+		return wrappedReader{Reader: io.TeeReader(r, o.hashes[0]), src: r}
+	}
+	ws := make([]io.Writer, len(o.hashes))
+	for i, h := range o.hashes {
+		ws[i] = h
+	}
+	return wrappedReader{Reader: io.TeeReader(r, io.MultiWriter(ws...)), src: r}
+}
+
+// ReadAllHash is ReadAll feeding the data through h as it is read, and
+// returns the data together with h's digest, so hashing needs no second pass
+// over the result. On error the digest is nil.
+func ReadAllHash(r io.Reader, h hash.Hash, opts ...Option) ([]byte, []byte, error) {
+	data, sums, err := ReadAllHashes(r, []hash.Hash{h}, opts...)
+	if err != nil {
+		return data, nil, err
+	}
+	return data, sums[0], nil
+}
+
+// ReadAllHashes is ReadAllHash for several hashes at once, e.g. a SHA-256 for
+// content addressing and an MD5 for a legacy manifest. sums[i] is the digest
+// of hs[i].
+func ReadAllHashes(r io.Reader, hs []hash.Hash, opts ...Option) (data []byte, sums [][]byte, err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts)
+	ws := make([]io.Writer, len(hs))
+	for i, h := range hs {
+		ws[i] = h
+	}
+	tee := wrappedReader{Reader: io.TeeReader(r, io.MultiWriter(ws...)), src: r}
+	data, err = readAllCap(tee, o.capacity(r), o)
+	if err != nil {
+		return data, nil, err
+	}
+	sums = make([][]byte, len(hs))
+	for i, h := range hs {
+		sums[i] = h.Sum(nil)
+	}
+	return data, sums, nil
+}
+
+// ReadAllVerify is ReadAllHash that fails with a *ChecksumError matching
+// ErrChecksumMismatch, and returns no data, when the digest of r differs from
+// expected. Corrupted bytes are never handed to the caller.
+func ReadAllVerify(r io.Reader, h hash.Hash, expected []byte, opts ...Option) ([]byte, error) {
+	data, sum, err := ReadAllHash(r, h, opts...)
+	if err != nil {
+		return data, err
+	}
+	if !bytes.Equal(sum, expected) {
+		return nil, withSource(sourceName(r), &ChecksumError{Want: expected, Got: sum})
+	}
+	return data, nil
+}
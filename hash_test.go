@@ -0,0 +1,45 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadAllHash(t *testing.T) {
+	data := strings.Repeat("hashed ", 10000)
+	got, sum, err := ReadAllHash(strings.NewReader(data), sha256.New())
+	want := sha256.Sum256([]byte(data))
+	if err != nil || string(got) != data || !bytes.Equal(sum, want[:]) || cap(got) != len(data)+1 {
+		t.Errorf("len:%v, cap:%v, sum:%x, err:%v", len(got), cap(got), sum, err)
+	}
+
+	_, sums, err := ReadAllHashes(iotest.HalfReader(strings.NewReader(data)), []hash.Hash{sha256.New(), md5.New()})
+	wantMD5 := md5.Sum([]byte(data))
+	if err != nil || len(sums) != 2 || !bytes.Equal(sums[0], want[:]) || !bytes.Equal(sums[1], wantMD5[:]) {
+		t.Errorf("sums:%x, err:%v", sums, err)
+	}
+
+	boom := errors.New("boom")
+	if _, sum, err := ReadAllHash(iotest.ErrReader(boom), sha256.New()); err != boom || sum != nil {
+		t.Errorf("failed read sum:%x, err:%v", sum, err)
+	}
+}
+
+func TestReadAllVerify(t *testing.T) {
+	data := "verified artifact"
+	want := sha256.Sum256([]byte(data))
+	if got, err := ReadAllVerify(strings.NewReader(data), sha256.New(), want[:]); err != nil || string(got) != data {
+		t.Errorf("verify:%q, err:%v", got, err)
+	}
+	got, err := ReadAllVerify(strings.NewReader(data+"!"), sha256.New(), want[:])
+	var ce *ChecksumError
+	if !errors.Is(err, ErrChecksumMismatch) || !errors.As(err, &ce) || !bytes.Equal(ce.Want, want[:]) || got != nil {
+		t.Errorf("corrupt:%q, err:%v", got, err)
+	}
+}
@@ -0,0 +1,127 @@
+package readall
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// maxHeaderPrealloc caps the header allocated before any data arrives; a
+// larger n grows the header as bytes are read.
+const maxHeaderPrealloc = 64 << 10
+
+// ReadHeader reads the first n bytes of r for format detection and returns
+// them together with a reader that replays them followed by the rest of r, so
+// the full stream can still be handed to a decoder. If r holds fewer than n
+// bytes, header is shorter and err is nil. The header slice backs the replay
+// and must not be modified before rest has been read past it. rest reveals
+// the size of r as r does, so ReadAll of rest still allocates once. A
+// negative n fails with ErrNegativeLength.
+func ReadHeader(r io.Reader, n int) (header []byte, rest io.Reader, err error) {
+	defer annotate(&err, r)
+	if n < 0 {
+		return nil, nil, ErrNegativeLength
+	}
+	header = make([]byte, 0, minInt(n, maxHeaderPrealloc))
+	for len(header) < n && err == nil {
+		if len(header) == cap(header) {
+			header = growLen(header, minInt(cap(header), n-len(header)))[:len(header)]
+		}
+		var m int
+		m, err = io.ReadFull(r, header[len(header):minInt(cap(header), n)])
+		header = header[:len(header)+m]
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if err != nil {
+		return header, nil, err
+	}
+	return header, &replayReader{head: header, r: r}, nil
+}
+
+// Sniff peeks at the first n bytes of r, for magic bytes or
+// http.DetectContentType, and returns them with a reader of the full
+// stream. A *bufio.Reader whose buffer holds n bytes is peeked in place: the
+// bytes are not copied and rest is r itself, so they are only valid until
+// rest is read. Other readers are read with ReadHeader.
+func Sniff(r io.Reader, n int) (peeked []byte, rest io.Reader, err error) {
+	if br, ok := r.(*bufio.Reader); ok && n >= 0 && n <= br.Size() {
+		peeked, err = br.Peek(n)
+		if err == io.EOF {
+			err = nil
+		}
+		if err != nil {
+			return peeked, nil, withSource(sourceName(r), err)
+		}
+		return peeked, br, nil
+	}
+	return ReadHeader(r, n)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// SniffContentType returns the MIME type http.DetectContentType finds in the
+// first 512 bytes of r, and a reader of the full stream.
+func SniffContentType(r io.Reader) (string, io.Reader, error) {
+	header, rest, err := Sniff(r, sniffLen)
+	if err != nil {
+		return "", nil, err
+	}
+	return http.DetectContentType(header), rest, nil
+}
+
+// replayReader reads head, then r.
+type replayReader struct {
+	head []byte
+	r    io.Reader
+}
+
+func (p *replayReader) Read(b []byte) (int, error) {
+	if len(p.head) > 0 {
+		n := copy(b, p.head)
+		p.head = p.head[n:]
+		return n, nil
+	}
+	return p.r.Read(b)
+}
+
+// Size returns the bytes left to read, or -1 if r does not reveal its size.
+func (p *replayReader) Size() int64 {
+	n, ok := sizeHint(p.r)
+	if !ok {
+		return -1
+	}
+	return int64(len(p.head)) + n
+}
+
+// WriteTo writes the rest of the stream to w, passing r to io.Copy so a
+// WriterTo or ReaderFrom on either side is used.
+func (p *replayReader) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	if len(p.head) > 0 {
+		m, err := w.Write(p.head)
+		n = int64(m)
+		p.head = p.head[m:]
+		if err == nil && len(p.head) > 0 {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	m, err := io.Copy(w, p.r)
+	return n + m, err
+}
+
+func (p *replayReader) Unwrap() io.Reader {
+	return p.r
+}
@@ -0,0 +1,88 @@
+package readall
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadHeader(t *testing.T) {
+	header, rest, err := ReadHeader(strings.NewReader("\x89PNG\r\n\x1a\nrest of image"), 8)
+	if err != nil {
+		t.Errorf("header err:%v", err)
+		return
+	}
+	if string(header) != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("header:%q", header)
+	}
+	all, err := ioutil.ReadAll(rest)
+	if err != nil || string(all) != "\x89PNG\r\n\x1a\nrest of image" {
+		t.Errorf("rest:%q, err:%v", all, err)
+	}
+}
+
+func TestReadHeaderShort(t *testing.T) {
+	header, rest, err := ReadHeader(strings.NewReader("abc"), 8)
+	if err != nil || string(header) != "abc" {
+		t.Errorf("header:%q, err:%v", header, err)
+		return
+	}
+	if all, _ := ioutil.ReadAll(rest); string(all) != "abc" {
+		t.Errorf("rest:%q", all)
+	}
+}
+
+func TestReadHeaderLength(t *testing.T) {
+	if _, _, err := ReadHeader(strings.NewReader("abc"), -1); !errors.Is(err, ErrNegativeLength) {
+		t.Errorf("negative err:%v, want:%v", err, ErrNegativeLength)
+	}
+	header, rest, err := ReadHeader(strings.NewReader("abc"), math.MaxInt)
+	if err != nil || string(header) != "abc" {
+		t.Errorf("huge header:%q, err:%v", header, err)
+	}
+	if all, _ := ioutil.ReadAll(rest); string(all) != "abc" {
+		t.Errorf("huge rest:%q", all)
+	}
+	body := strings.Repeat("h", 3*maxHeaderPrealloc)
+	header, _, err = ReadHeader(strings.NewReader(body), 2*maxHeaderPrealloc+1)
+	if err != nil || string(header) != body[:2*maxHeaderPrealloc+1] {
+		t.Errorf("grown header len:%v, err:%v", len(header), err)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	body := "<!DOCTYPE html><title>x</title>" + strings.Repeat(" ", 1000)
+	header, rest, err := Sniff(strings.NewReader(body), 15)
+	if err != nil || string(header) != "<!DOCTYPE html>" {
+		t.Fatalf("header:%q, err:%v", header, err)
+	}
+	all, err := ReadAll(rest)
+	if err != nil || string(all) != body || cap(all) != len(body)+1 {
+		t.Errorf("rest len:%v, cap:%v, err:%v", len(all), cap(all), err)
+	}
+
+	typ, rest, err := SniffContentType(strings.NewReader(body))
+	if err != nil || typ != "text/html; charset=utf-8" {
+		t.Errorf("type:%q, err:%v", typ, err)
+	}
+	var w strings.Builder
+	if n, err := io.Copy(&w, rest); err != nil || n != int64(len(body)) || w.String() != body {
+		t.Errorf("copied:%v, err:%v", n, err)
+	}
+
+	br := bufio.NewReader(strings.NewReader(body))
+	header, rest, err = Sniff(br, 15)
+	if err != nil || string(header) != "<!DOCTYPE html>" || rest != io.Reader(br) {
+		t.Errorf("buffered header:%q, in place:%v, err:%v", header, rest == io.Reader(br), err)
+	}
+	if all, err := ioutil.ReadAll(rest); err != nil || string(all) != body {
+		t.Errorf("buffered rest len:%v, err:%v", len(all), err)
+	}
+	if _, _, err := Sniff(bufio.NewReader(strings.NewReader(body)), -1); !errors.Is(err, ErrNegativeLength) {
+		t.Errorf("negative err:%v, want:%v", err, ErrNegativeLength)
+	}
+}
@@ -0,0 +1,136 @@
+package readall
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// ErrStalled is returned when a read made with WithIdleTimeout receives no
+// data for the idle timeout.
+var ErrStalled = errors.New("readall: read stalled")
+
+// WithIdleTimeout fails the read with ErrStalled when a single Read of the
+// source makes no progress for d, independently of any overall deadline. On
+// sources with a SetReadDeadline method (net.Conn, *os.File pipes) the
+// deadline is moved before every Read. Other sources, and those whose
+// SetReadDeadline fails such as regular files, are read on a helper
+// goroutine, which is left behind until the stalled Read returns.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idle = d
+	}
+}
+
+// withIdle wraps r, which reads from src, if o sets an idle timeout.
+func (o *options) withIdle(r, src io.Reader) io.Reader {
+	if o.idle <= 0 {
+		return r
+	}
+	if c, ok := src.(deadliner); ok {
+		return &deadlineReader{r: r, c: c, d: o.idle}
+	}
+	return &idleReader{r: r, d: o.idle}
+}
+
+type deadliner interface {
+	SetReadDeadline(time.Time) error
+}
+
+// deadlineReader enforces the idle timeout with read deadlines, or with an
+// idleReader once setting one fails.
+type deadlineReader struct {
+	r    io.Reader
+	c    deadliner
+	d    time.Duration
+	idle *idleReader
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if r.idle != nil {
+		return r.idle.Read(p)
+	}
+	if err := r.c.SetReadDeadline(time.Now().Add(r.d)); err != nil {
+		r.idle = &idleReader{r: r.r, d: r.d}
+		return r.idle.Read(p)
+	}
+	n, err := r.r.Read(p)
+	if n == 0 && isTimeout(err) {
+		err = ErrStalled
+	}
+	if err != nil {
+		r.c.SetReadDeadline(time.Time{})
+	}
+	return n, err
+}
+
+func (r *deadlineReader) Unwrap() io.Reader {
+	return r.r
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// idleReader enforces the idle timeout by reading r on a goroutine into a
+// buffer of its own, so a Read abandoned on timeout cannot write into the
+// caller's buffer.
+type idleReader struct {
+	r    io.Reader
+	d    time.Duration
+	reqs chan int
+	res  chan idleResult
+	buf  []byte
+	err  error
+}
+
+type idleResult struct {
+	n   int
+	err error
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.reqs == nil {
+		r.reqs = make(chan int)
+		r.res = make(chan idleResult, 1)
+		go r.loop()
+	}
+	if cap(r.buf) < len(p) {
+		r.buf = make([]byte, len(p))
+	}
+	r.reqs <- len(p)
+	t := time.NewTimer(r.d)
+	defer t.Stop()
+	select {
+	case res := <-r.res:
+		n := copy(p, r.buf[:res.n])
+		if res.err != nil {
+			r.err = res.err
+			close(r.reqs)
+		}
+		return n, res.err
+	case <-t.C:
+		r.err = ErrStalled
+		close(r.reqs)
+		return 0, r.err
+	}
+}
+
+// loop serves the requests of Read. r.buf is only touched by one side at a
+// time: Read sizes it before sending a request and copies from it after the
+// result arrives.
+func (r *idleReader) loop() {
+	for n := range r.reqs {
+		m, err := r.r.Read(r.buf[:n])
+		r.res <- idleResult{m, err}
+	}
+}
+
+func (r *idleReader) Unwrap() io.Reader {
+	return r.r
+}
@@ -0,0 +1,57 @@
+package readall
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWithIdleTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		pw.Write([]byte("some"))
+		time.Sleep(10 * time.Millisecond)
+		pw.Write([]byte(" data"))
+	}()
+	start := time.Now()
+	data, err := ReadAll(pr, WithIdleTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrStalled) || string(data) != "some data" {
+		t.Errorf("pipe data:%q, err:%v, want:%v", data, err, ErrStalled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stall detected after %v", elapsed)
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	go c2.Write([]byte("conn"))
+	data, err = ReadAll(c1, WithIdleTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrStalled) || string(data) != "conn" {
+		t.Errorf("conn data:%q, err:%v, want:%v", data, err, ErrStalled)
+	}
+}
+
+// noDeadlineReader is a source whose SetReadDeadline always fails, like an
+// *os.File without deadline support.
+type noDeadlineReader struct {
+	io.Reader
+}
+
+func (noDeadlineReader) SetReadDeadline(time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func TestWithIdleTimeoutNoDeadline(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("stuck"))
+	data, err := ReadAll(noDeadlineReader{pr}, WithIdleTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrStalled) || string(data) != "stuck" {
+		t.Errorf("data:%q, err:%v, want:%v", data, err, ErrStalled)
+	}
+}
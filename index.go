@@ -0,0 +1,94 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+)
+
+// Index holds the start offset of every record of data read with WithIndex,
+// so records can be accessed at random without scanning the data again.
+type Index struct {
+	// Delim ends a record; the last record need not be terminated.
+	Delim byte
+	// Offsets are the start offsets of the records in the returned data.
+	Offsets []int
+}
+
+// WithIndex fills ix with the offset of every ix.Delim separated record
+// while reading, in the same pass that accumulates the data. Offsets index
+// the returned slice. AppendAll keeps the offsets ix holds for the existing
+// data, so one Index can follow a buffer grown by successive calls.
+//
+//	ix := &readall.Index{Delim: '\n'}
+//	data, err := readall.ReadAll(r, readall.WithIndex(ix))
+//	line := ix.Record(data, 41)
+func WithIndex(ix *Index) Option {
+	return func(o *options) {
+		o.index = ix
+	}
+}
+
+// Len returns the number of records.
+func (ix *Index) Len() int {
+	return len(ix.Offsets)
+}
+
+// Record returns record i of data without its delimiter.
+func (ix *Index) Record(data []byte, i int) []byte {
+	end := len(data)
+	if i+1 < len(ix.Offsets) {
+		end = ix.Offsets[i+1]
+	}
+	rec := data[ix.Offsets[i]:end]
+	if len(rec) > 0 && rec[len(rec)-1] == ix.Delim {
+		rec = rec[:len(rec)-1]
+	}
+	return rec
+}
+
+// withIndex wraps r, whose data is appended to dst, to index its records.
+func (o *options) withIndex(r io.Reader, dst []byte) io.Reader {
+	ix := o.index
+	if ix == nil {
+		return r
+	}
+	pos := len(dst)
+	// Offsets past pos belong to data a retry is replacing.
+	n := len(ix.Offsets)
+	for n > 0 && ix.Offsets[n-1] >= pos {
+		n--
+	}
+	ix.Offsets = ix.Offsets[:n]
+	return &indexReader{r: r, ix: ix, pos: pos, start: pos == 0 || dst[pos-1] == ix.Delim}
+}
+
+type indexReader struct {
+	r     io.Reader
+	ix    *Index
+	pos   int
+	start bool // the next byte starts a record
+}
+
+func (x *indexReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	b := p[:n]
+	for len(b) > 0 {
+		if x.start {
+			x.ix.Offsets = append(x.ix.Offsets, x.pos)
+			x.start = false
+		}
+		i := bytes.IndexByte(b, x.ix.Delim)
+		if i < 0 {
+			x.pos += len(b)
+			break
+		}
+		x.pos += i + 1
+		b = b[i+1:]
+		x.start = true
+	}
+	return n, err
+}
+
+func (x *indexReader) Unwrap() io.Reader {
+	return x.r
+}
@@ -0,0 +1,41 @@
+package readall
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWithIndex(t *testing.T) {
+	text := "alpha\nbeta\n\ngamma"
+	ix := &Index{Delim: '\n'}
+	data, err := ReadAll(iotest.OneByteReader(strings.NewReader(text)), WithIndex(ix))
+	if err != nil {
+		t.Fatalf("read err:%v", err)
+	}
+	want := strings.Split(text, "\n")
+	if ix.Len() != len(want) {
+		t.Fatalf("offsets:%v, want %v records", ix.Offsets, len(want))
+	}
+	for i, w := range want {
+		if got := string(ix.Record(data, i)); got != w {
+			t.Errorf("record %v:%q, want:%q", i, got, w)
+		}
+	}
+
+	ix = &Index{Delim: '\n'}
+	data, _ = AppendAll(nil, strings.NewReader("zero\none"), WithIndex(ix))
+	data, err = AppendAll(data, strings.NewReader("\ntwo\n"), WithIndex(ix))
+	if err != nil || ix.Len() != 3 || string(ix.Record(data, 1)) != "one" || string(ix.Record(data, 2)) != "two" {
+		t.Errorf("append offsets:%v, err:%v", ix.Offsets, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ix = &Index{Delim: '\n'}
+	data, err = ReadAllContext(ctx, strings.NewReader(text), WithIndex(ix))
+	if err != nil || ix.Len() != len(want) || string(ix.Record(data, 3)) != "gamma" {
+		t.Errorf("context offsets:%v, err:%v", ix.Offsets, err)
+	}
+}
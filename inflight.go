@@ -0,0 +1,260 @@
+package readall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// InFlightRead describes a read that has not finished yet.
+type InFlightRead struct {
+	ID     uint64
+	Source string // redacted like SourceError.Source
+	Label  string
+	Tenant string
+	Start  time.Time
+	Age    time.Duration
+	Bytes  int64
+	// Rate is the average throughput since Start in bytes per second.
+	Rate float64
+}
+
+// ErrReadKilled is returned by a read stopped with KillRead.
+var ErrReadKilled = errors.New("readall: read killed by operator")
+
+// trackedRead is the registry entry of one running read.
+type trackedRead struct {
+	id     uint64 // 0 if not registered
+	src    interface{}
+	label  string
+	tenant string
+	start  time.Time
+	n      int64 // atomic
+
+	killed int32 // atomic, set by stop
+	cause  error // set by stop before killed
+
+	mu    sync.Mutex
+	abort func()        // optional, unblocks a pending Read
+	kill  chan struct{} // made by stopped, closed by stop
+
+	chunks *chunkRing // set by a watchdog before the first Read
+	groups []*Group   // left by done, see Group.join
+}
+
+var inflight struct {
+	on    int32 // atomic, set by the first InFlight or InFlightHandler
+	mu    sync.Mutex
+	next  uint64
+	reads map[uint64]*trackedRead
+}
+
+// track registers a read of src until done is called on the result. Reads
+// are only entered in the registry once InFlight or InFlightHandler has been
+// called, so programs that never list them do not pay for it.
+func track(src interface{}, label, tenant string) *trackedRead {
+	t := &trackedRead{src: src, label: label, tenant: tenant, start: time.Now()}
+	if atomic.LoadInt32(&inflight.on) == 0 {
+		return t
+	}
+	inflight.mu.Lock()
+	inflight.next++
+	t.id = inflight.next
+	if inflight.reads == nil {
+		inflight.reads = make(map[uint64]*trackedRead)
+	}
+	inflight.reads[t.id] = t
+	inflight.mu.Unlock()
+	return t
+}
+
+func (t *trackedRead) add(n int) {
+	atomic.AddInt64(&t.n, int64(n))
+}
+
+// stop marks the read as killed and unblocks a pending Read where possible:
+// through abort if the owner set one, or by moving the read deadline of the
+// source into the past.
+func (t *trackedRead) stop(cause error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isKilled() {
+		return
+	}
+	t.cause = cause
+	atomic.StoreInt32(&t.killed, 1)
+	if t.kill != nil {
+		close(t.kill)
+	}
+	if t.abort != nil {
+		t.abort()
+	} else if d, ok := t.src.(interface{ SetReadDeadline(time.Time) error }); ok {
+		d.SetReadDeadline(time.Unix(1, 0))
+	}
+}
+
+// setAbort registers how to interrupt the read, calling fn right away if the
+// read was already killed.
+func (t *trackedRead) setAbort(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.abort = fn
+	if t.isKilled() {
+		fn()
+	}
+}
+
+// stopped returns a channel closed when the read is stopped.
+func (t *trackedRead) stopped() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.kill == nil {
+		t.kill = make(chan struct{})
+		if t.isKilled() {
+			close(t.kill)
+		}
+	}
+	return t.kill
+}
+
+func (t *trackedRead) isKilled() bool {
+	return atomic.LoadInt32(&t.killed) != 0
+}
+
+// err returns why a killed read was stopped: ErrReadKilled or ErrShutdown.
+func (t *trackedRead) err() error {
+	return t.cause
+}
+
+func (t *trackedRead) done() {
+	if t.id != 0 {
+		inflight.mu.Lock()
+		delete(inflight.reads, t.id)
+		inflight.mu.Unlock()
+	}
+	for _, g := range t.groups {
+		g.leave(t)
+	}
+}
+
+// trackedReader counts the bytes read from r into its registry entry.
+type trackedReader struct {
+	r io.Reader
+	t *trackedRead
+}
+
+func (r trackedReader) Read(p []byte) (int, error) {
+	if r.t.isKilled() {
+		return 0, r.t.err()
+	}
+	var start time.Time
+	if r.t.chunks != nil {
+		start = time.Now()
+	}
+	n, err := r.r.Read(p)
+	r.t.add(n)
+	if r.t.chunks != nil {
+		r.t.chunks.add(ChunkTiming{At: start, Bytes: n, Took: time.Since(start)})
+	}
+	if r.t.isKilled() {
+		return n, r.t.err()
+	}
+	return n, err
+}
+
+func (r trackedReader) Unwrap() io.Reader {
+	return r.r
+}
+
+// InFlight returns a snapshot of the reads currently running through
+// ReadAll and its variants, Pool.ReadAll and pipelines, oldest first. Reads
+// are registered from the first call to InFlight or InFlightHandler on;
+// until then the registry costs nothing.
+func InFlight() []InFlightRead {
+	atomic.StoreInt32(&inflight.on, 1)
+	now := time.Now()
+	inflight.mu.Lock()
+	reads := make([]InFlightRead, 0, len(inflight.reads))
+	for _, t := range inflight.reads {
+		reads = append(reads, t.snapshot(now))
+	}
+	inflight.mu.Unlock()
+	sort.Slice(reads, func(i, j int) bool { return reads[i].ID < reads[j].ID })
+	return reads
+}
+
+func (t *trackedRead) snapshot(now time.Time) InFlightRead {
+	r := InFlightRead{
+		ID:     t.id,
+		Source: redact(sourceName(t.src)),
+		Label:  t.label,
+		Tenant: t.tenant,
+		Start:  t.start,
+		Age:    now.Sub(t.start),
+		Bytes:  atomic.LoadInt64(&t.n),
+	}
+	if s := r.Age.Seconds(); s > 0 {
+		r.Rate = float64(r.Bytes) / s
+	}
+	return r
+}
+
+// KillRead stops the in-flight read with the given ID, as listed by InFlight,
+// and reports whether it was found. The read fails with ErrReadKilled at its
+// next Read, or at once if its source can be interrupted (sockets and pipes
+// by deadline, pipeline sources by closing them). ReadAll and its variants
+// then drop the partial data so the buffer can be collected.
+func KillRead(id uint64) bool {
+	inflight.mu.Lock()
+	t, ok := inflight.reads[id]
+	inflight.mu.Unlock()
+	if ok {
+		t.stop(ErrReadKilled)
+	}
+	return ok
+}
+
+// InFlightHandler returns an http.Handler rendering InFlight as a text table,
+// or as JSON when the request has format=json, for mounting on a debug mux.
+// A POST with kill=ID calls KillRead. Reads are registered from the first
+// call on, so mount it at startup.
+func InFlightHandler() http.Handler {
+	atomic.StoreInt32(&inflight.on, 1)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			id, err := strconv.ParseUint(r.FormValue("kill"), 10, 64)
+			if err != nil {
+				http.Error(w, "kill: invalid read ID", http.StatusBadRequest)
+				return
+			}
+			if !KillRead(id) {
+				http.Error(w, "kill: no such read", http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, "killed read %d\n", id)
+			return
+		}
+		reads := InFlight()
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(reads)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSOURCE\tLABEL\tBYTES\tRATE\tAGE")
+		for _, rd := range reads {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%.1f MB/s\t%s\n",
+				rd.ID, rd.Source, rd.Label, rd.Bytes, rd.Rate/1e6, rd.Age.Truncate(time.Millisecond))
+		}
+		tw.Flush()
+	})
+}
@@ -0,0 +1,126 @@
+package readall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type namedBlockingReader struct {
+	*blockingReader
+}
+
+func (namedBlockingReader) String() string { return "stuck-source" }
+
+func findInFlight(source string) (InFlightRead, bool) {
+	for _, r := range InFlight() {
+		if r.Source == source {
+			return r, true
+		}
+	}
+	return InFlightRead{}, false
+}
+
+func TestInFlight(t *testing.T) {
+	InFlight() // register the reads below
+	r := namedBlockingReader{&blockingReader{data: []byte("partial"), release: make(chan struct{})}}
+	done := make(chan struct{})
+	go func() {
+		ReadAll(r)
+		close(done)
+	}()
+	var rd InFlightRead
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		var ok bool
+		if rd, ok = findInFlight("stuck-source"); ok && rd.Bytes == 7 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("read not in flight:%+v", InFlight())
+			close(r.release)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	InFlightHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/readall", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "stuck-source") || !strings.HasPrefix(body, "ID") {
+		t.Errorf("table:\n%s", body)
+	}
+	rec = httptest.NewRecorder()
+	InFlightHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/readall?format=json", nil))
+	var reads []InFlightRead
+	if err := json.Unmarshal(rec.Body.Bytes(), &reads); err != nil || len(reads) == 0 {
+		t.Errorf("json reads:%v, err:%v", reads, err)
+	}
+
+	close(r.release)
+	<-done
+	if _, ok := findInFlight("stuck-source"); ok {
+		t.Errorf("finished read still in flight")
+	}
+}
+
+func TestKillRead(t *testing.T) {
+	InFlight() // register the reads below
+	r := namedBlockingReader{&blockingReader{data: []byte("partial"), release: make(chan struct{})}}
+	defer close(r.release)
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		data, err := ReadAllContext(ctx, r)
+		done <- result{data, err}
+	}()
+	var rd InFlightRead
+	for ok := false; !ok; rd, ok = findInFlight("stuck-source") {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	InFlightHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/debug/readall?kill=999999999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown id status:%v", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	InFlightHandler().ServeHTTP(rec, httptest.NewRequest("POST", fmt.Sprintf("/debug/readall?kill=%d", rd.ID), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("kill status:%v, body:%s", rec.Code, rec.Body)
+	}
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, ErrReadKilled) || res.data != nil {
+			t.Errorf("killed read:%q, err:%v", res.data, res.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("killed read did not return")
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go server.Write([]byte("conn"))
+	go func() {
+		for {
+			if rd, ok := findInFlight("pipe pipe"); ok && rd.Bytes == 4 {
+				KillRead(rd.ID)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	if data, err := ReadAll(client); !errors.Is(err, ErrReadKilled) || data != nil {
+		t.Errorf("killed conn read:%q, err:%v", data, err)
+	}
+}
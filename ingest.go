@@ -0,0 +1,166 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// IngestRecord is the journal entry written once a file has been processed.
+type IngestRecord struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	SHA256  []byte    `json:"sha256"`
+}
+
+// Ingestor keeps an append-only journal of fully processed files so batch jobs
+// re-run after a crash skip inputs that were already ingested. The journal uses
+// the ReadSegments framing; a torn last record is discarded on open. Its first
+// record names the journal version, and journals written before versioning
+// are rewritten with one when opened.
+type Ingestor struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]IngestRecord
+}
+
+// OpenIngestor opens or creates the journal at path.
+func OpenIngestor(path string) (*Ingestor, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	in := &Ingestor{f: f, done: make(map[string]IngestRecord)}
+	sr := ReadSegments(f)
+	versioned := false
+	for first := true; sr.Next(); first = false {
+		if first {
+			_, ok, err := ingestFormat.envelope(sr.Record())
+			if err != nil {
+				f.Close()
+				return nil, withSource(path, err)
+			}
+			if versioned = ok; ok {
+				continue
+			}
+		}
+		var rec IngestRecord
+		if err := json.Unmarshal(sr.Record(), &rec); err != nil {
+			f.Close()
+			return nil, err
+		}
+		in.done[rec.Path] = rec
+	}
+	if err := sr.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !versioned {
+		f.Close()
+		if err := in.rewrite(path); err != nil {
+			return nil, withSource(path, err)
+		}
+		if f, err = os.OpenFile(path, os.O_RDWR, 0644); err != nil {
+			return nil, err
+		}
+		in.f = f
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return in, nil
+	}
+	if err := f.Truncate(sr.Offset()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(sr.Offset(), io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return in, nil
+}
+
+// rewrite replaces the journal at path with a versioned one holding the
+// records in in.done.
+func (in *Ingestor) rewrite(path string) error {
+	hdr, err := ingestFormat.marshal(nil)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	AppendSegment(&buf, hdr)
+	for _, rec := range in.done {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		AppendSegment(&buf, b)
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// Ingest reads the file at path and passes its contents to fn, then journals
+// it. If the journal already holds the file with the same size and mtime, or
+// the same content digest, fn is not called and skipped is true.
+func (in *Ingestor) Ingest(path string, fn func(data []byte) error) (skipped bool, err error) {
+	defer func() { err = withSource(path, err) }()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	in.mu.Lock()
+	prev, ok := in.done[path]
+	in.mu.Unlock()
+	if ok && prev.Size == fi.Size() && prev.ModTime.Equal(fi.ModTime()) {
+		return true, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+	if ok && bytes.Equal(prev.SHA256, sum[:]) {
+		return true, nil
+	}
+	if err := fn(data); err != nil {
+		return false, err
+	}
+	return false, in.record(IngestRecord{Path: path, Size: int64(len(data)), ModTime: fi.ModTime(), SHA256: sum[:]})
+}
+
+// Done reports whether path has been journaled.
+func (in *Ingestor) Done(path string) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	_, ok := in.done[path]
+	return ok
+}
+
+func (in *Ingestor) record(rec IngestRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if err := AppendSegment(in.f, b); err != nil {
+		return err
+	}
+	if err := in.f.Sync(); err != nil {
+		return err
+	}
+	in.done[rec.Path] = rec
+	return nil
+}
+
+// Close closes the journal.
+func (in *Ingestor) Close() error {
+	return in.f.Close()
+}
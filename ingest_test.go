@@ -0,0 +1,52 @@
+package readall
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestIngestor(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	ioutil.WriteFile(a, []byte("first"), 0644)
+	ioutil.WriteFile(b, []byte("second"), 0644)
+	journal := filepath.Join(dir, "journal")
+
+	in, err := OpenIngestor(journal)
+	if err != nil {
+		t.Errorf("open err:%v", err)
+		return
+	}
+	var processed []string
+	process := func(data []byte) error {
+		processed = append(processed, string(data))
+		return nil
+	}
+	if _, err := in.Ingest(a, process); err != nil {
+		t.Errorf("ingest err:%v", err)
+	}
+	if _, err := in.Ingest(b, func([]byte) error { return errors.New("crash") }); err == nil {
+		t.Errorf("expected processing failure")
+	}
+	in.Close()
+
+	in, err = OpenIngestor(journal)
+	if err != nil {
+		t.Errorf("reopen err:%v", err)
+		return
+	}
+	defer in.Close()
+	for _, p := range []string{a, b} {
+		if _, err := in.Ingest(p, process); err != nil {
+			t.Errorf("ingest err:%v", err)
+		}
+	}
+	if len(processed) != 2 || processed[0] != "first" || processed[1] != "second" {
+		t.Errorf("processed:%q", processed)
+	}
+	if !in.Done(a) || !in.Done(b) {
+		t.Errorf("done a:%v, b:%v", in.Done(a), in.Done(b))
+	}
+}
@@ -0,0 +1,183 @@
+package readall
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when data does not match a checksum its
+// source declared. It is returned as a *ChecksumError, which matches it with
+// errors.Is.
+var ErrChecksumMismatch = errors.New("readall: checksum mismatch")
+
+// ChecksumError reports data that does not match its expected digest.
+type ChecksumError struct {
+	// Header is the integrity header or trailer that declared the digest,
+	// and Algorithm its hash; both are empty for ReadAllVerify.
+	Header    string
+	Algorithm string
+	Want      []byte
+	// Got is nil when the digest was declared in a trailer the response
+	// did not announce, so the body was not hashed for it.
+	Got []byte
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Header == "" {
+		return fmt.Sprintf("readall: checksum mismatch: got %x, want %x", e.Got, e.Want)
+	}
+	if e.Got == nil {
+		return fmt.Sprintf("readall: %s %s not announced as a trailer, want %x", e.Header, e.Algorithm, e.Want)
+	}
+	return fmt.Sprintf("readall: %s %s mismatch: got %x, want %x", e.Header, e.Algorithm, e.Got, e.Want)
+}
+
+// Is reports whether target is ErrChecksumMismatch.
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// WithIntegrityCheck makes ReadResponse verify the body against the integrity
+// headers and trailers it recognizes: Content-MD5, X-Amz-Checksum-Crc32,
+// -Crc32c, -Sha1 and -Sha256, and X-Goog-Hash (crc32c and md5). Only the
+// algorithms the response declares are computed. A mismatch fails the read
+// with a *ChecksumError and no data. Download checks the whole resource
+// against the headers of the probe or of the first full response.
+func WithIntegrityCheck() Option {
+	return func(o *options) {
+		o.integrity = true
+	}
+}
+
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c": func() hash.Hash { return crc32.New(castagnoli) },
+}
+
+type checksum struct {
+	header, algorithm, want string
+}
+
+var amzChecksums = []checksum{
+	{header: "X-Amz-Checksum-Crc32", algorithm: "crc32"},
+	{header: "X-Amz-Checksum-Crc32c", algorithm: "crc32c"},
+	{header: "X-Amz-Checksum-Sha1", algorithm: "sha1"},
+	{header: "X-Amz-Checksum-Sha256", algorithm: "sha256"},
+}
+
+// trailerAlgorithms are the algorithms an announced integrity trailer may
+// declare, by trailer name.
+var trailerAlgorithms = map[string][]string{
+	"Content-MD5": {"md5"},
+	"X-Goog-Hash": {"crc32c", "md5"},
+}
+
+func init() {
+	for _, a := range amzChecksums {
+		trailerAlgorithms[a.header] = []string{a.algorithm}
+	}
+}
+
+// checksums returns the integrity values declared in h.
+func checksums(h http.Header) []checksum {
+	var cs []checksum
+	if v := h.Get("Content-MD5"); v != "" {
+		cs = append(cs, checksum{"Content-MD5", "md5", v})
+	}
+	for _, a := range amzChecksums {
+		if v := h.Get(a.header); v != "" {
+			cs = append(cs, checksum{a.header, a.algorithm, v})
+		}
+	}
+	for _, v := range h.Values("X-Goog-Hash") {
+		for _, part := range strings.Split(v, ",") {
+			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+			if len(kv) == 2 && (kv[0] == "crc32c" || kv[0] == "md5") {
+				cs = append(cs, checksum{"X-Goog-Hash", kv[0], kv[1]})
+			}
+		}
+	}
+	return cs
+}
+
+// integrityCheck hashes a response body for the algorithms its headers and
+// announced trailers name.
+type integrityCheck struct {
+	rsp    *http.Response
+	hashes map[string]hash.Hash
+}
+
+func newIntegrityCheck(rsp *http.Response) *integrityCheck {
+	c := &integrityCheck{rsp: rsp, hashes: make(map[string]hash.Hash)}
+	var algorithms []string
+	for _, cs := range checksums(rsp.Header) {
+		algorithms = append(algorithms, cs.algorithm)
+	}
+	for k := range rsp.Trailer {
+		// Trailer values arrive with EOF; only the names are known now.
+		algorithms = append(algorithms, trailerAlgorithms[http.CanonicalHeaderKey(k)]...)
+	}
+	for _, a := range algorithms {
+		if _, ok := c.hashes[a]; !ok {
+			c.hashes[a] = checksumAlgorithms[a]()
+		}
+	}
+	return c
+}
+
+// reader returns r teeing into the hashes, or r itself if there are none.
+func (c *integrityCheck) reader(r io.Reader) io.Reader {
+	if len(c.hashes) == 0 {
+		return r
+	}
+	ws := make([]io.Writer, 0, len(c.hashes))
+	for _, h := range c.hashes {
+		ws = append(ws, h)
+	}
+	return wrappedReader{Reader: io.TeeReader(r, io.MultiWriter(ws...)), src: r}
+}
+
+// verifyData checks data against the integrity headers in h.
+func verifyData(h http.Header, data []byte) error {
+	for _, cs := range checksums(h) {
+		sum := checksumAlgorithms[cs.algorithm]()
+		sum.Write(data)
+		got := sum.Sum(nil)
+		if base64.StdEncoding.EncodeToString(got) != cs.want {
+			want, _ := base64.StdEncoding.DecodeString(cs.want)
+			return &ChecksumError{Header: cs.header, Algorithm: cs.algorithm, Want: want, Got: got}
+		}
+	}
+	return nil
+}
+
+// verify compares the hashes against the headers and, now that the body has
+// been read, the trailers.
+func (c *integrityCheck) verify() error {
+	for _, h := range []http.Header{c.rsp.Header, c.rsp.Trailer} {
+		for _, cs := range checksums(h) {
+			// An undecodable header leaves Want empty.
+			want, _ := base64.StdEncoding.DecodeString(cs.want)
+			sum, ok := c.hashes[cs.algorithm]
+			if !ok {
+				return &ChecksumError{Header: cs.header, Algorithm: cs.algorithm, Want: want}
+			}
+			if got := sum.Sum(nil); base64.StdEncoding.EncodeToString(got) != cs.want {
+				return &ChecksumError{Header: cs.header, Algorithm: cs.algorithm, Want: want, Got: got}
+			}
+		}
+	}
+	return nil
+}
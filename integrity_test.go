@@ -0,0 +1,68 @@
+package readall
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+	body := "integrity checked body"
+	sum := md5.Sum([]byte(body))
+	crc := crc32.New(castagnoli)
+	io.WriteString(crc, body)
+	goodMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	goodCRC := base64.StdEncoding.EncodeToString(crc.Sum(nil))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/md5":
+			w.Header().Set("Content-MD5", goodMD5)
+		case "/bad-md5":
+			w.Header().Set("Content-MD5", goodCRC)
+		case "/goog":
+			w.Header().Set("X-Goog-Hash", "crc32c="+goodCRC+",md5="+goodMD5)
+		case "/trailer":
+			w.Header().Set("Trailer", "X-Amz-Checksum-Crc32c")
+			io.WriteString(w, body)
+			w.Header().Set("X-Amz-Checksum-Crc32c", "AAAAAA==")
+			return
+		case "/goog-trailer":
+			w.Header().Set("Trailer", "X-Goog-Hash, Content-MD5")
+			io.WriteString(w, body)
+			w.Header().Set("X-Goog-Hash", "crc32c="+goodCRC+",md5="+goodMD5)
+			w.Header().Set("Content-MD5", goodMD5)
+			return
+		case "/unannounced":
+			// Flushing switches to chunked encoding, which can carry
+			// trailers that were not announced.
+			io.WriteString(w, body)
+			w.(http.Flusher).Flush()
+			w.Header().Set(http.TrailerPrefix+"X-Goog-Hash", "crc32c="+goodCRC)
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	for path, ok := range map[string]bool{"/md5": true, "/goog": true, "/none": true, "/bad-md5": false, "/trailer": false, "/goog-trailer": true, "/unannounced": false} {
+		rsp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Errorf("get err:%v", err)
+			continue
+		}
+		data, err := ReadResponse(rsp, WithIntegrityCheck())
+		if ok && (err != nil || string(data) != body) {
+			t.Errorf("%v data:%q, err:%v", path, data, err)
+		}
+		var ce *ChecksumError
+		if !ok && (!errors.Is(err, ErrChecksumMismatch) || !errors.As(err, &ce) || data != nil) {
+			t.Errorf("%v err:%v, want:%v", path, err, ErrChecksumMismatch)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Package core holds the accumulation loop and limits shared by package
+// readall. It only depends on errors and io, so it builds with TinyGo and
+// other constrained toolchains for readers such as UARTs or flash devices.
+package core
+
+import "io"
+
+// Append reads r until EOF, appending the data to dst, and returns the
+// extended slice. EOF is not reported as an error. When dst runs out of
+// space it grows by doubling, like append.
+func Append(dst []byte, r io.Reader) ([]byte, error) {
+	for {
+		if len(dst) == cap(dst) {
+			dst = append(dst, 0)[:len(dst)]
+		}
+		n, err := r.Read(dst[len(dst):cap(dst)])
+		dst = dst[:len(dst)+n]
+		if err == io.EOF {
+			return dst, nil
+		}
+		if err != nil {
+			return dst, err
+		}
+	}
+}
+
+// LimitReader reads from R, returning Err once more than N bytes have been
+// read in total. Unlike io.LimitReader, excess data is an error rather than
+// a silent truncation; the bytes up to the limit are still returned.
+type LimitReader struct {
+	R   io.Reader
+	N   int64 // bytes still allowed; negative after the limit was exceeded
+	Err error
+}
+
+func (l *LimitReader) Read(p []byte) (int, error) {
+	if l.N < 0 {
+		return 0, l.Err
+	}
+	// Ask for one byte more than allowed so exceeding is noticed without
+	// requiring an extra Read at EOF.
+	if l.N < int64(len(p))-1 {
+		p = p[:l.N+1]
+	}
+	n, err := l.R.Read(p)
+	l.N -= int64(n)
+	if l.N < 0 {
+		return n + int(l.N), l.Err
+	}
+	return n, err
+}
+
+// Unwrap returns R.
+func (l *LimitReader) Unwrap() io.Reader {
+	return l.R
+}
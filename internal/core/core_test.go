@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAppend(t *testing.T) {
+	data := strings.Repeat("core", 1000)
+	got, err := Append([]byte("pre:"), iotest.HalfReader(strings.NewReader(data)))
+	if err != nil || string(got) != "pre:"+data {
+		t.Errorf("append len:%v, err:%v", len(got), err)
+	}
+	got, err = Append(nil, iotest.TimeoutReader(strings.NewReader(data)))
+	if err != iotest.ErrTimeout || len(got) == 0 || string(got) != data[:len(got)] {
+		t.Errorf("append len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	errLimit := errors.New("limit")
+	got, err := Append(nil, &LimitReader{R: strings.NewReader("0123456789"), N: 10, Err: errLimit})
+	if err != nil || len(got) != 10 {
+		t.Errorf("at limit len:%v, err:%v", len(got), err)
+	}
+	got, err = Append(nil, &LimitReader{R: iotest.OneByteReader(strings.NewReader("0123456789")), N: 4, Err: errLimit})
+	if err != errLimit || string(got) != "0123" {
+		t.Errorf("over limit:%q, err:%v", got, err)
+	}
+	got, err = Append(nil, &LimitReader{R: strings.NewReader("hello"), N: math.MaxInt64, Err: errLimit})
+	if err != nil || string(got) != "hello" {
+		t.Errorf("max limit:%q, err:%v", got, err)
+	}
+}
@@ -0,0 +1,39 @@
+package readall
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// jsonPool holds the buffers DecodeJSON reads into.
+var jsonPool Pool
+
+// DecodeJSON decodes the JSON value in r into v, rejecting anything but
+// white space after it. The data is read into a pooled buffer, sized from r
+// when r reveals its size, that is reused once v is filled, so ReadAll
+// followed by json.Unmarshal stops costing an allocation per call:
+// json.Decoder would not help, as it buffers each value whole in an
+// allocation of its own before decoding it. WithLimit bounds the encoded
+// size; the options of Pool.ReadAll apply.
+func DecodeJSON(r io.Reader, v interface{}, opts ...Option) (err error) {
+	defer annotate(&err, r)
+	data, release, err := jsonPool.ReadAll(r, opts...)
+	defer release()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DecodeJSONResponse decodes the JSON body of rsp into v like DecodeJSON,
+// reading it as ReadResponse does: the body is checked against its
+// Content-Length, drained and closed.
+func DecodeJSONResponse(rsp *http.Response, v interface{}, opts ...Option) (err error) {
+	data, err := ReadResponse(rsp, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() { err = withSource(responseSource(rsp), err) }()
+	return json.Unmarshal(data, v)
+}
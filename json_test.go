@@ -0,0 +1,54 @@
+package readall
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type jsonDoc struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Count int      `json:"count"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var doc jsonDoc
+	err := DecodeJSON(unsized{strings.NewReader(`{"name":"a","tags":["x","y"],"count":3}` + "\n")}, &doc)
+	if err != nil || doc.Name != "a" || len(doc.Tags) != 2 || doc.Count != 3 {
+		t.Errorf("doc:%+v, err:%v", doc, err)
+	}
+	// Decoded strings must not share the pooled buffer.
+	var other jsonDoc
+	DecodeJSON(strings.NewReader(`{"name":"b","tags":["zzzz"]}`), &other)
+	if doc.Name != "a" || doc.Tags[0] != "x" {
+		t.Errorf("doc changed by a later decode:%+v", doc)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	var doc jsonDoc
+	if err := DecodeJSON(strings.NewReader(`{"name":"a"} {"name":"b"}`), &doc); err == nil {
+		t.Errorf("trailing value accepted")
+	}
+	if err := DecodeJSON(strings.NewReader(`{"name":"`+strings.Repeat("a", 100)+`"}`), &doc, WithLimit(50)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limit err:%v, want:%v", err, ErrLimitExceeded)
+	}
+	if err := DecodeJSON(strings.NewReader(`{"name":`), &doc); err == nil {
+		t.Errorf("truncated value accepted")
+	}
+}
+
+func TestDecodeJSONResponse(t *testing.T) {
+	rsp, body := response(`{"name":"rsp","count":1}`, 24)
+	var doc jsonDoc
+	if err := DecodeJSONResponse(rsp, &doc); err != nil || doc.Name != "rsp" || !body.closed {
+		t.Errorf("doc:%+v, err:%v, closed:%v", doc, err, body.closed)
+	}
+	rsp = &http.Response{ContentLength: 100, Body: ioutil.NopCloser(strings.NewReader(`{"name":"x"}`))}
+	if err := DecodeJSONResponse(rsp, &doc); !errors.Is(err, ErrShortBody) {
+		t.Errorf("short body err:%v, want:%v", err, ErrShortBody)
+	}
+}
@@ -0,0 +1,40 @@
+package readall
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestStdlibOnly keeps the root module free of third-party dependencies;
+// extras needing one belong in a nested module.
+func TestStdlibOnly(t *testing.T) {
+	mod, err := ioutil.ReadFile("go.mod")
+	if err != nil {
+		t.Fatalf("go.mod err:%v", err)
+	}
+	if strings.Contains(string(mod), "require") {
+		t.Errorf("go.mod requires modules:\n%s", mod)
+	}
+	for _, dir := range []string{".", "cmd/readallbench", "internal/core", "objstore", "promexp", "readalltest"} {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		for _, name := range files {
+			f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ImportsOnly)
+			if err != nil {
+				t.Errorf("parse %v err:%v", name, err)
+				continue
+			}
+			for _, imp := range f.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				first := strings.SplitN(path, "/", 2)[0]
+				if first != "readall" && strings.Contains(first, ".") {
+					t.Errorf("%v imports %v", name, path)
+				}
+			}
+		}
+	}
+}
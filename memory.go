@@ -0,0 +1,135 @@
+package readall
+
+import (
+	"context"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPressureThreshold is the fraction of the memory limit the heap goal
+// must reach for WatchMemoryPressure to trim.
+const DefaultPressureThreshold = 0.9
+
+// Trimmer is a cache or pool that can give memory back on demand. Trim
+// drops what it holds and returns about how many bytes it released.
+// Pool and StatCache are Trimmers.
+type Trimmer interface {
+	Trim() int64
+}
+
+// MemoryStat reports what memory-pressure trimming released since the
+// process started.
+type MemoryStat struct {
+	// Trims counts the calls to TrimMemory, including those made by
+	// WatchMemoryPressure.
+	Trims int64
+	// Released is the bytes the Trimmers reported releasing.
+	Released int64
+}
+
+var (
+	trimmers struct {
+		sync.Mutex
+		next uint64
+		set  map[uint64]Trimmer
+	}
+	memTrims, memReleased int64
+)
+
+// RegisterTrimmer makes TrimMemory trim t until unregister is called.
+func RegisterTrimmer(t Trimmer) (unregister func()) {
+	trimmers.Lock()
+	defer trimmers.Unlock()
+	if trimmers.set == nil {
+		trimmers.set = make(map[uint64]Trimmer)
+	}
+	trimmers.next++
+	id := trimmers.next
+	trimmers.set[id] = t
+	return func() {
+		trimmers.Lock()
+		delete(trimmers.set, id)
+		trimmers.Unlock()
+	}
+}
+
+// TrimMemory trims every registered Trimmer and returns the bytes released.
+func TrimMemory() int64 {
+	trimmers.Lock()
+	ts := make([]Trimmer, 0, len(trimmers.set))
+	for _, t := range trimmers.set {
+		ts = append(ts, t)
+	}
+	trimmers.Unlock()
+	var n int64
+	for _, t := range ts {
+		n += t.Trim()
+	}
+	atomic.AddInt64(&memTrims, 1)
+	atomic.AddInt64(&memReleased, n)
+	return n
+}
+
+// MemoryStats returns the totals of memory-pressure trimming.
+func MemoryStats() MemoryStat {
+	return MemoryStat{Trims: atomic.LoadInt64(&memTrims), Released: atomic.LoadInt64(&memReleased)}
+}
+
+// WatchMemoryPressure polls runtime/metrics every interval until ctx is
+// done, and after each garbage collection whose heap goal has reached
+// threshold (DefaultPressureThreshold if not positive) of the memory limit
+// set by GOMEMLIMIT or debug.SetMemoryLimit, calls TrimMemory. Without a
+// memory limit, or on runtimes that do not report one, it never trims.
+//
+//	go readall.WatchMemoryPressure(ctx, time.Second, 0)
+func WatchMemoryPressure(ctx context.Context, interval time.Duration, threshold float64) {
+	if threshold <= 0 {
+		threshold = DefaultPressureThreshold
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	var seen uint64
+	for {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+		m := readMemory()
+		if m.cycles == seen {
+			continue
+		}
+		seen = m.cycles
+		if m.limit > 0 && float64(m.goal) >= threshold*float64(m.limit) {
+			TrimMemory()
+		}
+	}
+}
+
+type memorySample struct {
+	cycles, goal, limit uint64
+}
+
+// readMemory samples the GC counters; a metric the runtime lacks reads as 0.
+var readMemory = func() memorySample {
+	s := []metrics.Sample{
+		{Name: "/gc/cycles/total:gc-cycles"},
+		{Name: "/gc/heap/goal:bytes"},
+		{Name: "/gc/gomemlimit:bytes"},
+	}
+	metrics.Read(s)
+	v := func(i int) uint64 {
+		if s[i].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return s[i].Value.Uint64()
+	}
+	m := memorySample{cycles: v(0), goal: v(1), limit: v(2)}
+	if m.limit == 1<<63-1 {
+		// math.MaxInt64 means no limit.
+		m.limit = 0
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countTrimmer int64
+
+func (c *countTrimmer) Trim() int64 {
+	atomic.AddInt64((*int64)(c), 1)
+	return 10
+}
+
+func TestTrimMemory(t *testing.T) {
+	var p Pool
+	_, release, err := p.ReadAll(bytes.NewReader(make([]byte, 5000)))
+	if err != nil {
+		t.Fatalf("pool read err:%v", err)
+	}
+	release()
+	f, err := ioutil.TempFile("", "stat")
+	if err != nil {
+		t.Fatalf("temp err:%v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	c := NewStatCache(time.Minute)
+	c.Stat(f.Name())
+
+	unregisterPool := RegisterTrimmer(&p)
+	unregisterCache := RegisterTrimmer(c)
+	before := MemoryStats()
+	n := TrimMemory()
+	unregisterPool()
+	unregisterCache()
+	// The pooled buffer may have been dropped by a GC already.
+	if n < int64(len(f.Name())) {
+		t.Errorf("released:%v", n)
+	}
+	if after := MemoryStats(); after.Trims != before.Trims+1 || after.Released != before.Released+n {
+		t.Errorf("stats before:%+v, after:%+v", before, after)
+	}
+	if c.Trim() != 0 || p.Trim() != 0 {
+		t.Errorf("second trim released memory")
+	}
+}
+
+func TestWatchMemoryPressure(t *testing.T) {
+	var samples int64
+	old := readMemory
+	readMemory = func() memorySample {
+		n := uint64(atomic.AddInt64(&samples, 1))
+		if n > 8 {
+			n = 8
+		}
+		// A new GC cycle every other sample; the heap goal passes 90% of the
+		// limit from the second cycle on.
+		return memorySample{cycles: (n + 1) / 2, goal: 70 + 10*n, limit: 100}
+	}
+	defer func() { readMemory = old }()
+	var trims countTrimmer
+	defer RegisterTrimmer(&trims)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchMemoryPressure(ctx, time.Millisecond, 0)
+		close(done)
+	}()
+	for atomic.LoadInt64(&samples) < 8 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if n := atomic.LoadInt64((*int64)(&trims)); n != 3 {
+		t.Errorf("trims:%v", n)
+	}
+}
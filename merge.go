@@ -0,0 +1,128 @@
+package readall
+
+import (
+	"io"
+	"sort"
+)
+
+// MergePolicy selects which ready source MergeAll takes the next chunk from.
+type MergePolicy int
+
+const (
+	// MergeRoundRobin cycles over sources that have data ready.
+	MergeRoundRobin MergePolicy = iota
+	// MergePriority always takes ready data from the highest Priority source.
+	MergePriority
+)
+
+// mergeChunkSize is the largest chunk MergeAll reads from a source at once.
+const mergeChunkSize = 32 << 10
+
+// TaggedReader is one input of MergeAll.
+type TaggedReader struct {
+	Tag      string
+	R        io.Reader
+	Priority int
+}
+
+// TaggedChunk is a piece of data read from the source with the given Tag.
+type TaggedChunk struct {
+	Tag  string
+	Data []byte
+}
+
+type mergeEvent struct {
+	idx  int
+	data []byte
+	err  error
+}
+
+// MergeAll reads all sources concurrently until each reaches EOF and returns
+// their data as tagged chunks, interleaved in arrival order as arbitrated by
+// policy, so one slow source does not hold back the others. A source's chunks
+// keep their relative order. On the first read error MergeAll returns the
+// chunks merged so far and the error, in a SourceError naming the tag; sources
+// still blocked in Read finish in the background.
+func MergeAll(readers []TaggedReader, policy MergePolicy) ([]TaggedChunk, error) {
+	events := make(chan mergeEvent)
+	done := make(chan struct{})
+	defer close(done)
+	for i, tr := range readers {
+		go func(i int, r io.Reader) {
+			for {
+				buf := make([]byte, mergeChunkSize)
+				n, err := r.Read(buf)
+				if n > 0 {
+					select {
+					case events <- mergeEvent{idx: i, data: buf[:n]}:
+					case <-done:
+						return
+					}
+				}
+				if err != nil {
+					select {
+					case events <- mergeEvent{idx: i, err: err}:
+					case <-done:
+					}
+					return
+				}
+			}
+		}(i, tr.R)
+	}
+
+	order := make([]int, len(readers))
+	for i := range order {
+		order[i] = i
+	}
+	if policy == MergePriority {
+		sort.SliceStable(order, func(a, b int) bool {
+			return readers[order[a]].Priority > readers[order[b]].Priority
+		})
+	}
+	queues := make([][][]byte, len(readers))
+	var out []TaggedChunk
+	next, live := 0, len(readers)
+	for live > 0 || pending(queues) {
+		if live > 0 {
+			ev := <-events
+			// Take everything else that is ready before arbitrating.
+			for ok := true; ok; {
+				if ev.err == io.EOF {
+					live--
+				} else if ev.err != nil {
+					return out, withSource(readers[ev.idx].Tag, ev.err)
+				} else {
+					queues[ev.idx] = append(queues[ev.idx], ev.data)
+				}
+				select {
+				case ev = <-events:
+				default:
+					ok = false
+				}
+			}
+		}
+		for k := 0; k < len(order); k++ {
+			i := order[k]
+			if policy == MergeRoundRobin {
+				i = order[(next+k)%len(order)]
+			}
+			if len(queues[i]) == 0 {
+				continue
+			}
+			out = append(out, TaggedChunk{Tag: readers[i].Tag, Data: queues[i][0]})
+			queues[i] = queues[i][1:]
+			next = (next + k + 1) % len(order)
+			break
+		}
+	}
+	return out, nil
+}
+
+func pending(queues [][][]byte) bool {
+	for _, q := range queues {
+		if len(q) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMergeAll(t *testing.T) {
+	a := strings.Repeat("a", 100<<10)
+	b := strings.Repeat("b", 10)
+	for _, policy := range []MergePolicy{MergeRoundRobin, MergePriority} {
+		chunks, err := MergeAll([]TaggedReader{
+			{Tag: "a", R: strings.NewReader(a)},
+			{Tag: "b", R: iotest.OneByteReader(strings.NewReader(b)), Priority: 1},
+		}, policy)
+		if err != nil {
+			t.Errorf("policy %v merge err:%v", policy, err)
+			continue
+		}
+		got := make(map[string]*bytes.Buffer)
+		for _, c := range chunks {
+			if got[c.Tag] == nil {
+				got[c.Tag] = new(bytes.Buffer)
+			}
+			got[c.Tag].Write(c.Data)
+		}
+		if got["a"].String() != a || got["b"].String() != b {
+			t.Errorf("policy %v merged a:%v, b:%q", policy, got["a"].Len(), got["b"])
+		}
+	}
+}
+
+func TestMergeAllError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := MergeAll([]TaggedReader{
+		{Tag: "ok", R: strings.NewReader("fine")},
+		{Tag: "bad", R: iotest.ErrReader(boom)},
+	}, MergeRoundRobin)
+	var se *SourceError
+	if !errors.Is(err, boom) || !errors.As(err, &se) || se.Source != "bad" {
+		t.Errorf("merge err:%v", err)
+	}
+}
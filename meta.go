@@ -0,0 +1,43 @@
+package readall
+
+import "context"
+
+// ContextMeta describes who a read is for. Stored in a context with
+// SetContextMeta, it tags every read made with that context, so audit
+// events, size metrics and the in-flight registry agree without each call
+// site passing the same options.
+type ContextMeta struct {
+	// Tenant identifies the customer or workload the read is done for.
+	Tenant string
+	// Label is used when the read has no WithLabel option.
+	Label string
+	// Priority orders competing reads; higher is more important.
+	Priority int
+}
+
+type metaKey struct{}
+
+// SetContextMeta returns a copy of ctx carrying m.
+func SetContextMeta(ctx context.Context, m ContextMeta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+// ContextMetaFrom returns the ContextMeta stored in ctx, if any.
+func ContextMetaFrom(ctx context.Context) (ContextMeta, bool) {
+	m, ok := ctx.Value(metaKey{}).(ContextMeta)
+	return m, ok
+}
+
+// withContext returns o completed with ctx and its metadata. Explicit options
+// take precedence.
+func (o options) withContext(ctx context.Context) options {
+	o.ctx = ctx
+	if m, ok := ContextMetaFrom(ctx); ok {
+		if o.label == "" {
+			o.label = m.Label
+		}
+		o.tenant = m.Tenant
+		o.priority = m.Priority
+	}
+	return o
+}
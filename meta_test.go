@@ -0,0 +1,38 @@
+package readall
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestContextMeta(t *testing.T) {
+	if _, ok := ContextMetaFrom(context.Background()); ok {
+		t.Errorf("meta in background context")
+	}
+	path := filepath.Join(t.TempDir(), "tagged")
+	ioutil.WriteFile(path, []byte("tagged"), 0644)
+	var events []AuditEvent
+	audit := WithAudit(func(ev AuditEvent) { events = append(events, ev) })
+	ctx := SetContextMeta(context.Background(), ContextMeta{Tenant: "acme", Label: "ctx", Priority: 2})
+
+	if _, err := ReadURL(ctx, path, audit); err != nil {
+		t.Errorf("read err:%v", err)
+		return
+	}
+	if _, err := From(FileSource(path)).Context(ctx).Options(audit, WithLabel("explicit")).Bytes(); err != nil {
+		t.Errorf("pipeline err:%v", err)
+		return
+	}
+	if len(events) != 2 {
+		t.Errorf("events:%v, want:2", len(events))
+		return
+	}
+	if ev := events[0]; ev.Tenant != "acme" || ev.Label != "ctx" || ev.Priority != 2 {
+		t.Errorf("event:%+v", ev)
+	}
+	if ev := events[1]; ev.Tenant != "acme" || ev.Label != "explicit" || ev.Priority != 2 {
+		t.Errorf("event:%+v", ev)
+	}
+}
@@ -0,0 +1,49 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+type bodyKey struct{}
+
+// Middleware returns a handler reading each request body into a pooled
+// buffer before calling next, which gets it from FromRequest, or from
+// r.Body, which is replaced by a reader over the same bytes. The buffer
+// goes back to the pool once next returns, so next must not keep the data
+// past that. Bodies over WithLimit are answered with 413 Request Entity Too
+// Large and failed reads with 400 Bad Request, without calling next; the
+// other options of Pool.ReadAll apply.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	var pool Pool
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, release, err := pool.ReadAll(&httpBody{ReadCloser: r.Body, size: r.ContentLength}, opts...)
+		defer release()
+		r.Body.Close()
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, ErrLimitExceeded) {
+				code = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), bodyKey{}, data))
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FromRequest returns the body read by Middleware. It reports false for a
+// request that did not pass through Middleware or had no body.
+func FromRequest(r *http.Request) ([]byte, bool) {
+	data, ok := r.Context().Value(bodyKey{}).([]byte)
+	return data, ok
+}
@@ -0,0 +1,40 @@
+package readall
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got, body string
+	var ok bool
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data []byte
+		data, ok = FromRequest(r)
+		got = string(data)
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	}), WithLimit(10))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+	if rec.Code != http.StatusOK || !ok || got != "payload" || body != "payload" {
+		t.Errorf("code:%v, ok:%v, got:%q, body:%q", rec.Code, ok, got, body)
+	}
+
+	ok = false
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("much too long")))
+	if rec.Code != http.StatusRequestEntityTooLarge || ok {
+		t.Errorf("limit code:%v, want:%v", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || ok {
+		t.Errorf("no body code:%v, ok:%v", rec.Code, ok)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build readall_purego || !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+// +build readall_purego !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package readall
+
+import "os"
+
+// mmapFile is not available; ReadFile reads instead.
+func mmapFile(f *os.File, size int64) ([]byte, func(), error) {
+	return nil, nil, ErrMmapUnsupported
+}
@@ -0,0 +1,27 @@
+//go:build (linux || darwin || dragonfly || freebsd || netbsd || openbsd) && !readall_purego
+// +build linux darwin dragonfly freebsd netbsd openbsd
+// +build !readall_purego
+
+package readall
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// mmapFile maps the first size bytes of f read-only.
+func mmapFile(f *os.File, size int64) ([]byte, func(), error) {
+	if size == 0 {
+		return []byte{}, func() {}, nil
+	}
+	if size != int64(int(size)) {
+		return nil, nil, syscall.EFBIG
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return data, func() { once.Do(func() { syscall.Munmap(data) }) }, nil
+}
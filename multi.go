@@ -0,0 +1,109 @@
+package readall
+
+import "io"
+
+// ReadAllMulti reads rs one after another into a single buffer, like ReadAll
+// of io.MultiReader(rs...), while the next reader is prefetched in the
+// background as the current one drains, so the latency of opening or
+// starting each part overlaps the transfer of the previous one. The buffer
+// is presized from the sum of the size hints the readers reveal. It takes
+// the options of ReadAll; an error names the reader it came from.
+//
+// Prefetching reads ahead at most DefaultPrefetchSize bytes of the next
+// reader. ReadAllMulti does not close rs; a prefetch pending when the read
+// fails finishes in the background.
+func ReadAllMulti(rs []io.Reader, opts ...Option) ([]byte, error) {
+	m := newMultiPrefetch(rs)
+	defer m.Close()
+	o := readOptions(opts)
+	return readAllCap(m, o.capacity(m), o)
+}
+
+// multiPrefetch concatenates readers, prefetching the one after the
+// current.
+type multiPrefetch struct {
+	rs   []io.Reader
+	cur  io.Reader
+	next io.ReadCloser
+	size int64
+}
+
+func newMultiPrefetch(rs []io.Reader) *multiPrefetch {
+	m := &multiPrefetch{rs: rs, size: -1}
+	// Hints come first: a Seek-based one must not race a prefetch.
+	for _, r := range rs {
+		if n, ok := sizeHint(r); ok {
+			if m.size < 0 {
+				m.size = 0
+			}
+			m.size += n
+		}
+	}
+	m.advance()
+	return m
+}
+
+// Size returns the sum of the size hints, or -1 if no reader gave one.
+func (m *multiPrefetch) Size() int64 {
+	return m.size
+}
+
+// advance makes the prefetched reader current and starts prefetching the
+// one after it.
+func (m *multiPrefetch) advance() {
+	if m.next != nil {
+		m.cur = m.next
+	} else if len(m.rs) > 0 {
+		m.cur, m.rs = m.rs[0], m.rs[1:]
+	} else {
+		m.cur = nil
+	}
+	m.next = nil
+	if len(m.rs) > 0 && m.cur != nil {
+		// Hide Close from Prefetch: rs belong to the caller.
+		m.next = prefetchPart{Prefetch(struct{ io.Reader }{m.rs[0]}, 0, 1), m.rs[0]}
+		m.rs = m.rs[1:]
+	}
+}
+
+func (m *multiPrefetch) Read(p []byte) (int, error) {
+	for m.cur != nil {
+		n, err := m.cur.Read(p)
+		if err == io.EOF {
+			if pp, ok := m.cur.(prefetchPart); ok {
+				pp.Close()
+			}
+			m.advance()
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		if err != nil {
+			err = withSource(sourceName(m.cur), err)
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+// Close stops the prefetches.
+func (m *multiPrefetch) Close() error {
+	for _, r := range []io.Reader{m.cur, m.next} {
+		if pp, ok := r.(prefetchPart); ok {
+			pp.Close()
+		}
+	}
+	m.cur, m.next, m.rs = nil, nil, nil
+	return nil
+}
+
+// prefetchPart is a prefetcher of one part, named after the part.
+type prefetchPart struct {
+	io.ReadCloser
+	src io.Reader
+}
+
+func (p prefetchPart) Unwrap() io.Reader {
+	return p.src
+}
@@ -0,0 +1,90 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// startReader records when its first Read happened.
+type startReader struct {
+	io.Reader
+	started chan struct{}
+}
+
+func (s *startReader) Read(p []byte) (int, error) {
+	select {
+	case <-s.started:
+	default:
+		close(s.started)
+	}
+	return s.Reader.Read(p)
+}
+
+func TestReadAllMulti(t *testing.T) {
+	parts := []io.Reader{
+		strings.NewReader("alpha,"),
+		iotest.OneByteReader(strings.NewReader("beta,")),
+		bytes.NewReader(nil),
+		struct{ io.Reader }{strings.NewReader("gamma")},
+	}
+	data, err := ReadAllMulti(parts)
+	if err != nil || string(data) != "alpha,beta,gamma" {
+		t.Errorf("data:%q, err:%v", data, err)
+	}
+	if data, err := ReadAllMulti(nil); err != nil || len(data) != 0 {
+		t.Errorf("no readers data:%q, err:%v", data, err)
+	}
+}
+
+func TestReadAllMultiPresizes(t *testing.T) {
+	parts := []io.Reader{strings.NewReader("0123456789"), bytes.NewReader(make([]byte, 90))}
+	data, err := ReadAllMulti(parts)
+	if err != nil || len(data) != 100 || cap(data) != 101 {
+		t.Errorf("len:%v, cap:%v, err:%v", len(data), cap(data), err)
+	}
+}
+
+func TestReadAllMultiPrefetches(t *testing.T) {
+	first := &blockingReader{data: []byte("first,"), release: make(chan struct{})}
+	second := &startReader{Reader: strings.NewReader("second"), started: make(chan struct{})}
+	done := make(chan []byte)
+	go func() {
+		data, _ := ReadAllMulti([]io.Reader{first, second})
+		done <- data
+	}()
+	select {
+	case <-second.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("next reader not prefetched")
+	}
+	close(first.release)
+	if data := <-done; string(data) != "first,second" {
+		t.Errorf("data:%q", data)
+	}
+}
+
+func TestReadAllMultiErrors(t *testing.T) {
+	boom := errors.New("boom")
+	parts := []io.Reader{strings.NewReader("ok"), namedReader{iotest.ErrReader(boom), "part-2"}, strings.NewReader("never")}
+	data, err := ReadAllMulti(parts)
+	var se *SourceError
+	if !errors.Is(err, boom) || !errors.As(err, &se) || se.Source != "part-2" || string(data) != "ok" {
+		t.Errorf("data:%q, err:%v", data, err)
+	}
+	_, err = ReadAllMulti([]io.Reader{strings.NewReader("12345"), strings.NewReader("67890")}, WithLimit(8))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limit err:%v, want:%v", err, ErrLimitExceeded)
+	}
+}
+
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+func (n namedReader) String() string { return n.name }
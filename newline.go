@@ -0,0 +1,93 @@
+package readall
+
+import "io"
+
+// Newline is a line ending for WithNormalizeNewlines.
+type Newline int
+
+const (
+	// KeepNewlines leaves line endings alone.
+	KeepNewlines Newline = iota
+	// LF turns "\r\n" into "\n".
+	LF
+	// CRLF turns a "\n" not preceded by "\r" into "\r\n".
+	CRLF
+)
+
+// WithNormalizeNewlines converts line endings to nl while the data is read,
+// saving text workloads a second pass over the result. A lone "\r" is not a
+// line ending and is kept. It applies to the ReadAll variants and, after all
+// stages, to pipelines.
+func WithNormalizeNewlines(nl Newline) Option {
+	return func(o *options) {
+		o.newline = nl
+	}
+}
+
+// withNewlines wraps r if o asks for newline conversion.
+func (o *options) withNewlines(r io.Reader) io.Reader {
+	if o.newline != LF && o.newline != CRLF {
+		return r
+	}
+	return &newlineReader{r: r, nl: o.newline}
+}
+
+type newlineReader struct {
+	r    io.Reader
+	nl   Newline
+	in   []byte
+	out  []byte // converted, not yet returned
+	prev byte   // last input byte, for a "\r\n" split across reads
+	err  error
+}
+
+func (n *newlineReader) Read(p []byte) (int, error) {
+	for len(n.out) == 0 && n.err == nil {
+		if n.in == nil {
+			n.in = make([]byte, 32<<10)
+		}
+		m, err := n.r.Read(n.in)
+		n.convert(n.in[:m])
+		if err != nil {
+			if n.nl == LF && n.prev == '\r' {
+				n.out = append(n.out, '\r')
+			}
+			n.err = err
+		}
+	}
+	if len(n.out) == 0 {
+		return 0, n.err
+	}
+	m := copy(p, n.out)
+	n.out = n.out[m:]
+	if len(n.out) == 0 {
+		n.out = n.out[:0:0]
+	}
+	return m, nil
+}
+
+func (n *newlineReader) convert(b []byte) {
+	out := n.out
+	for _, c := range b {
+		switch n.nl {
+		case LF:
+			if n.prev == '\r' && c != '\n' {
+				out = append(out, '\r')
+			}
+			if c != '\r' {
+				out = append(out, c)
+			}
+		case CRLF:
+			if c == '\n' && n.prev != '\r' {
+				out = append(out, '\r')
+			}
+			out = append(out, c)
+		}
+		n.prev = c
+	}
+	n.out = out
+}
+
+func (n *newlineReader) Unwrap() io.Reader {
+	return n.r
+}
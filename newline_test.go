@@ -0,0 +1,33 @@
+package readall
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNormalizeNewlines(t *testing.T) {
+	in := "a\r\nb\nc\rd\r\n\r\ne\r"
+	for _, c := range []struct {
+		nl   Newline
+		want string
+	}{
+		{LF, "a\nb\nc\rd\n\ne\r"},
+		{CRLF, "a\r\nb\r\nc\rd\r\n\r\ne\r"},
+		{KeepNewlines, in},
+	} {
+		got, err := ReadAll(iotest.OneByteReader(strings.NewReader(in)), WithNormalizeNewlines(c.nl))
+		if err != nil || string(got) != c.want {
+			t.Errorf("mode %v:%q, err:%v, want:%q", c.nl, got, err, c.want)
+		}
+		got, err = From(BytesSource(in)).Options(WithNormalizeNewlines(c.nl)).Bytes()
+		if err != nil || string(got) != c.want {
+			t.Errorf("pipeline mode %v:%q, err:%v, want:%q", c.nl, got, err, c.want)
+		}
+	}
+	big := strings.Repeat("line\n", 20000)
+	got, err := ReadAll(strings.NewReader(big), WithNormalizeNewlines(CRLF))
+	if err != nil || string(got) != strings.Replace(big, "\n", "\r\n", -1) {
+		t.Errorf("big len:%v, err:%v", len(got), err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package objstore reads objects from object stores such as S3 or GCS into
+// memory or a spill file with the ranged parallel engine of readall, retrying
+// each range on its own, so one dropped connection costs a range instead of
+// the whole download. A store only has to serve ranged reads; HTTPStore
+// does so for plain and presigned URLs without an SDK.
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"readall"
+	"time"
+)
+
+// Store is an object store serving ranged reads.
+type Store interface {
+	// GetRange returns a reader of the n bytes of object key starting at
+	// off; a negative n reads to the end of the object.
+	GetRange(ctx context.Context, key string, off, n int64) (io.ReadCloser, error)
+	// Size returns the size of object key.
+	Size(ctx context.Context, key string) (int64, error)
+}
+
+// ErrNotFound is returned by stores for a missing object.
+var ErrNotFound = errors.New("objstore: object not found")
+
+// Defaults of Options.
+const (
+	DefaultChunkSize = 8 << 20
+	DefaultWorkers   = 8
+	DefaultRetries   = 3
+	DefaultBackoff   = 100 * time.Millisecond
+)
+
+// Options tunes a read. The zero value selects the defaults.
+type Options struct {
+	// ChunkSize is the size of each ranged request.
+	ChunkSize int
+	// Workers is the number of ranged requests in flight.
+	Workers int
+	// Retries is how many times a failed range is retried; a negative
+	// value disables retries.
+	Retries int
+	// Backoff is the wait before the first retry of a range, doubling for
+	// each further one.
+	Backoff time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = DefaultWorkers
+	}
+	if o.Retries == 0 {
+		o.Retries = DefaultRetries
+	} else if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultBackoff
+	}
+	return o
+}
+
+// ReadObject reads object key of s into memory, allocated once at its size,
+// with ranged requests in parallel. WithLimit rejects larger objects before
+// anything is fetched; the other options of readall.ParallelReadAt apply.
+func ReadObject(ctx context.Context, s Store, key string, opt Options, opts ...readall.Option) ([]byte, error) {
+	opt = opt.withDefaults()
+	size, err := s.Size(ctx, key)
+	if err != nil {
+		return nil, &readall.SourceError{Source: key, Err: err}
+	}
+	return readall.ParallelReadAt(ctx, &readerAt{ctx: ctx, s: s, key: key, opt: opt}, size, opt.ChunkSize, opt.Workers, opts...)
+}
+
+// SpillObject downloads object key of s into a new temporary file in dir
+// (os.TempDir if empty) with ranged requests in parallel, holding at most
+// Workers chunks in memory, and returns the file positioned at its start
+// together with the object size. The caller closes and removes the file.
+func SpillObject(ctx context.Context, s Store, key, dir string, opt Options, opts ...readall.Option) (*os.File, int64, error) {
+	opt = opt.withDefaults()
+	size, err := s.Size(ctx, key)
+	if err != nil {
+		return nil, 0, &readall.SourceError{Source: key, Err: err}
+	}
+	f, err := ioutil.TempFile(dir, "objstore-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	err = readall.ParallelCopyAt(ctx, f, &readerAt{ctx: ctx, s: s, key: key, opt: opt}, size, opt.ChunkSize, opt.Workers, opts...)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, err
+	}
+	return f, size, nil
+}
+
+// NewReaderAt returns an io.ReaderAt over object key of s whose ReadAt
+// issues one ranged request, retried as opt says and resumed where a
+// failed attempt stopped. Requests use ctx, which also ends the retries.
+func NewReaderAt(ctx context.Context, s Store, key string, opt Options) io.ReaderAt {
+	return &readerAt{ctx: ctx, s: s, key: key, opt: opt.withDefaults()}
+}
+
+type readerAt struct {
+	ctx context.Context
+	s   Store
+	key string
+	opt Options
+}
+
+func (r *readerAt) String() string {
+	return r.key
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+	backoff := r.opt.Backoff
+	for attempt := 0; ; attempt++ {
+		m, err := r.readRange(p[n:], off+int64(n))
+		n += m
+		if err == nil || permanent(err) || r.ctx.Err() != nil || attempt >= r.opt.Retries {
+			return n, err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-r.ctx.Done():
+			t.Stop()
+			return n, r.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// permanent reports whether retrying the range cannot help.
+func permanent(err error) bool {
+	return err == io.EOF || errors.Is(err, ErrNotFound) || errors.Is(err, readall.ErrRangeUnsupported)
+}
+
+// readRange reads len(p) bytes at off in one request.
+func (r *readerAt) readRange(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	rc, err := r.s.GetRange(r.ctx, r.key, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, err := io.ReadFull(rc, p)
+	if err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("objstore: range at %d ended after %d of %d bytes: %w", off, n, len(p), err)
+	}
+	return n, err
+}
+
+// Source returns object key of s as a readall.Source, for Download-style
+// pipelines and strategies.
+func Source(s Store, key string) readall.Source {
+	return source{s: s, key: key}
+}
+
+type source struct {
+	s   Store
+	key string
+}
+
+func (s source) String() string {
+	return s.key
+}
+
+func (s source) Open(ctx context.Context) (io.ReadCloser, error) {
+	return s.s.GetRange(ctx, s.key, 0, -1)
+}
+
+func (s source) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	return s.s.GetRange(ctx, s.key, off, -1)
+}
+
+func (s source) Size(ctx context.Context) (int64, error) {
+	return s.s.Size(ctx, s.key)
+}
@@ -0,0 +1,126 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"readall"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyStore fails the first request for each chunk after handing out half
+// of it.
+type flakyStore struct {
+	Store
+	chunk int64
+	seen  sync.Map
+}
+
+func (f *flakyStore) GetRange(ctx context.Context, key string, off, n int64) (io.ReadCloser, error) {
+	rc, err := f.Store.GetRange(ctx, key, off, n)
+	if _, again := f.seen.LoadOrStore(off, true); err != nil || again || off%f.chunk != 0 {
+		return rc, err
+	}
+	return flakyBody{io.LimitReader(rc, n/2), rc}, nil
+}
+
+type flakyBody struct {
+	io.Reader
+	io.Closer
+}
+
+func (b flakyBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF {
+		err = errors.New("connection reset")
+	}
+	return n, err
+}
+
+func writeObject(t *testing.T, dir, key string, data []byte) {
+	name := filepath.Join(dir, filepath.FromSlash(key))
+	os.MkdirAll(filepath.Dir(name), 0o755)
+	if err := ioutil.WriteFile(name, data, 0o644); err != nil {
+		t.Fatalf("write err:%v", err)
+	}
+}
+
+func TestReadObject(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	writeObject(t, dir, "a/b.bin", data)
+	opt := Options{ChunkSize: 5000, Workers: 4, Backoff: time.Millisecond}
+
+	got, err := ReadObject(context.Background(), DirStore(dir), "a/b.bin", opt)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+	got, err = ReadObject(context.Background(), &flakyStore{Store: DirStore(dir), chunk: 5000}, "a/b.bin", opt)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("flaky len:%v, err:%v", len(got), err)
+	}
+	_, err = ReadObject(context.Background(), &flakyStore{Store: DirStore(dir), chunk: 5000}, "a/b.bin", Options{ChunkSize: 5000, Retries: -1})
+	if err == nil {
+		t.Errorf("no retries err:%v", err)
+	}
+	if _, err := ReadObject(context.Background(), DirStore(dir), "missing", opt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing err:%v, want:%v", err, ErrNotFound)
+	}
+	var le *readall.LimitError
+	if _, err := ReadObject(context.Background(), DirStore(dir), "a/b.bin", opt, readall.WithLimit(100)); !errors.As(err, &le) {
+		t.Errorf("limit err:%v", err)
+	}
+}
+
+func TestSpillObject(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("spill"), 10000)
+	writeObject(t, dir, "obj", data)
+	f, size, err := SpillObject(context.Background(), &flakyStore{Store: DirStore(dir), chunk: 4096}, "obj", t.TempDir(), Options{ChunkSize: 4096, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("spill err:%v", err)
+	}
+	defer f.Close()
+	got, err := ioutil.ReadAll(f)
+	if err != nil || size != int64(len(data)) || !bytes.Equal(got, data) {
+		t.Errorf("size:%v, len:%v, err:%v", size, len(got), err)
+	}
+}
+
+func TestHTTPStore(t *testing.T) {
+	data := bytes.Repeat([]byte("http object "), 3000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bucket/dir/x y" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	s := &HTTPStore{BaseURL: srv.URL + "/bucket/"}
+	got, err := ReadObject(context.Background(), s, "dir/x y", Options{ChunkSize: 7000})
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+	if _, err := s.Size(context.Background(), "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing err:%v, want:%v", err, ErrNotFound)
+	}
+	rc, err := Source(s, "dir/x y").OpenAt(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("source open err:%v", err)
+	}
+	defer rc.Close()
+	got, err = readall.ReadAll(rc)
+	if err != nil || !bytes.Equal(got, data[100:]) {
+		t.Errorf("source len:%v, err:%v", len(got), err)
+	}
+}
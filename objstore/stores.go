@@ -0,0 +1,151 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"readall"
+	"strings"
+)
+
+// HTTPStore serves objects at BaseURL joined with their key, such as a
+// public bucket endpoint or a gateway in front of one, with Range GET
+// requests and HEAD for Size. A nil Client means http.DefaultClient;
+// Header is added to every request, e.g. for authorization.
+type HTTPStore struct {
+	Client  *http.Client
+	BaseURL string
+	Header  http.Header
+}
+
+// GetRange implements Store.
+func (h *HTTPStore) GetRange(ctx context.Context, key string, off, n int64) (io.ReadCloser, error) {
+	req, err := h.request(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, err
+	}
+	ranged := off > 0 || n >= 0
+	switch {
+	case n == 0:
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	case n > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+n-1))
+	case off > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	}
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ranged && rsp.StatusCode == http.StatusPartialContent:
+	case ranged && rsp.StatusCode == http.StatusOK:
+		rsp.Body.Close()
+		return nil, readall.ErrRangeUnsupported
+	case rsp.StatusCode == http.StatusOK:
+	default:
+		rsp.Body.Close()
+		return nil, statusErr(rsp)
+	}
+	return rsp.Body, nil
+}
+
+// Size implements Store.
+func (h *HTTPStore) Size(ctx context.Context, key string) (int64, error) {
+	req, err := h.request(ctx, http.MethodHead, key)
+	if err != nil {
+		return 0, err
+	}
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return 0, statusErr(rsp)
+	}
+	if rsp.ContentLength < 0 {
+		return 0, errors.New("objstore: HEAD: no Content-Length")
+	}
+	return rsp.ContentLength, nil
+}
+
+func (h *HTTPStore) request(ctx context.Context, method, key string) (*http.Request, error) {
+	u := strings.TrimSuffix(h.BaseURL, "/") + "/" + (&url.URL{Path: strings.TrimPrefix(key, "/")}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+func (h *HTTPStore) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	return http.DefaultClient
+}
+
+func statusErr(rsp *http.Response) error {
+	if rsp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("objstore: %s: %s", rsp.Request.Method, rsp.Status)
+}
+
+// DirStore serves objects as the files below a directory, keyed by their
+// slash-separated path. It suits tests and locally mounted buckets.
+type DirStore string
+
+// GetRange implements Store.
+func (d DirStore) GetRange(ctx context.Context, key string, off, n int64) (io.ReadCloser, error) {
+	f, err := d.open(key)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+	return sectionCloser{io.NewSectionReader(f, off, n), f}, nil
+}
+
+// Size implements Store.
+func (d DirStore) Size(ctx context.Context, key string) (int64, error) {
+	f, err := d.open(key)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func (d DirStore) open(key string) (*os.File, error) {
+	name := filepath.Join(string(d), filepath.FromSlash(filepath.Clean("/"+key)))
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+type sectionCloser struct {
+	*io.SectionReader
+	io.Closer
+}
@@ -0,0 +1,84 @@
+package readall
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// OffsetStore persists consumer positions by key, for example the followed
+// path or a segment file name. Store must be atomic: after a crash Load
+// returns either the previous or the new offset.
+type OffsetStore interface {
+	// Load returns the offset stored under key; ok is false if there is none.
+	Load(key string) (off int64, ok bool, err error)
+	// Store records off under key.
+	Store(key string, off int64) error
+}
+
+// FileOffsetStore is an OffsetStore keeping all offsets in one versioned JSON
+// file, rewritten atomically on every Store. Files of older versions are
+// migrated when opened. It is safe for concurrent use within
+// a process.
+type FileOffsetStore struct {
+	path string
+
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+// OpenFileOffsetStore loads the offsets recorded in path; a missing file is
+// an empty store.
+func OpenFileOffsetStore(path string) (*FileOffsetStore, error) {
+	s := &FileOffsetStore{path: path, offsets: make(map[string]int64)}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := offsetsFormat.unmarshal(b, &s.offsets); err != nil {
+		return nil, withSource(path, err)
+	}
+	return s, nil
+}
+
+// Load implements OffsetStore.
+func (s *FileOffsetStore) Load(key string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	off, ok := s.offsets[key]
+	return off, ok, nil
+}
+
+// Store implements OffsetStore.
+func (s *FileOffsetStore) Store(key string, off int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, had := s.offsets[key]
+	s.offsets[key] = off
+	b, err := offsetsFormat.marshal(s.offsets)
+	if err == nil {
+		err = writeFileAtomic(s.path, b)
+	}
+	if err != nil {
+		if had {
+			s.offsets[key] = prev
+		} else {
+			delete(s.offsets, key)
+		}
+		return withSource(s.path, err)
+	}
+	return nil
+}
+
+// WithOffsetStore makes Follow resume from the offset stored under key,
+// ignoring fromOffset when one is found, and store the position after every
+// chunk fn accepts. A crash between fn and the store replays that chunk, so
+// delivery is at least once.
+func WithOffsetStore(store OffsetStore, key string) Option {
+	return func(o *options) {
+		o.offsets, o.offsetKey = store, key
+	}
+}
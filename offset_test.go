@@ -0,0 +1,88 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileOffsetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets.json")
+	s, err := OpenFileOffsetStore(path)
+	if err != nil {
+		t.Errorf("open err:%v", err)
+		return
+	}
+	if _, ok, err := s.Load("a"); ok || err != nil {
+		t.Errorf("empty load ok:%v, err:%v", ok, err)
+	}
+	s.Store("a", 10)
+	s.Store("b", 20)
+	s.Store("a", 30)
+
+	s, err = OpenFileOffsetStore(path)
+	if err != nil {
+		t.Errorf("reopen err:%v", err)
+		return
+	}
+	if off, ok, _ := s.Load("a"); !ok || off != 30 {
+		t.Errorf("a:%v, ok:%v", off, ok)
+	}
+	if off, ok, _ := s.Load("b"); !ok || off != 20 {
+		t.Errorf("b:%v, ok:%v", off, ok)
+	}
+}
+
+func TestResumeSegments(t *testing.T) {
+	var log bytes.Buffer
+	for _, r := range []string{"one", "two", "three"} {
+		AppendSegment(&log, []byte(r))
+	}
+	s, _ := OpenFileOffsetStore(filepath.Join(t.TempDir(), "offsets.json"))
+
+	sr, err := ResumeSegments(bytes.NewReader(log.Bytes()), s, "log")
+	if err != nil || !sr.Next() || string(sr.Record()) != "one" {
+		t.Errorf("first record err:%v", err)
+		return
+	}
+	sr.Commit(s, "log")
+
+	sr, err = ResumeSegments(bytes.NewReader(log.Bytes()), s, "log")
+	if err != nil {
+		t.Errorf("resume err:%v", err)
+		return
+	}
+	var got []string
+	for sr.Next() {
+		got = append(got, string(sr.Record()))
+	}
+	if len(got) != 2 || got[0] != "two" || sr.Offset() != int64(log.Len()) {
+		t.Errorf("resumed records:%v, offset:%v", got, sr.Offset())
+	}
+}
+
+func TestFollowOffsetStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	ioutil.WriteFile(path, []byte("first\n"), 0644)
+	s, _ := OpenFileOffsetStore(filepath.Join(dir, "offsets.json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait, done := startFollow(t, ctx, path, 0, WithPollInterval(10*time.Millisecond), WithOffsetStore(s, "app"))
+	ok := wait("first\n")
+	cancel()
+	<-done
+	if !ok {
+		return
+	}
+
+	appendFile(path, "second\n")
+	ctx, cancel = context.WithCancel(context.Background())
+	wait, done = startFollow(t, ctx, path, 0, WithPollInterval(10*time.Millisecond), WithOffsetStore(s, "app"))
+	wait("second\n")
+	cancel()
+	<-done
+}
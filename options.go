@@ -0,0 +1,109 @@
+package readall
+
+import (
+	"context"
+	"hash"
+	"net/http"
+	"time"
+)
+
+// Option configures a read.
+type Option func(*options)
+
+type options struct {
+	limit    int64 // 0 means unlimited
+	audit    func(AuditEvent)
+	label    string
+	tenant   string
+	priority int
+	strategy Strategy
+	poll     time.Duration
+	rotated  []string
+	gunzip   bool
+	backfill int
+	lockOS   bool
+	cpus     []int
+	stats    *StatCache
+	growth   growth
+
+	errPolicy  ErrorPolicy
+	lengthWarn func(*LengthError)
+	integrity  bool
+	stripBOM   bool
+	newline    Newline
+
+	budget     *Budget
+	budgetWait bool
+	group      *Group
+
+	progress      func(read, total int64)
+	progressEvery time.Duration
+	progressSet   bool
+
+	mmap          MmapMode
+	mmapThreshold int64
+
+	offsets   OffsetStore
+	offsetKey string
+	index     *Index
+
+	retries     int
+	retriesSet  bool
+	backoff     time.Duration
+	ranges      int
+	probe       bool
+	decorate    func(*http.Request) error
+	transport   http.RoundTripper
+	hostClients map[string]*http.Client
+
+	ctx        context.Context // set by withContext
+	rate       Limiter
+	rateSmooth bool
+	idle       time.Duration
+	softLimit  int64
+	softFn     func(read int64) bool
+	readStats  *Stats
+	truncation TruncationPolicy
+	hashes     []hash.Hash
+	estimator  *Estimator
+
+	coalesceMin    int
+	coalesceDelay  time.Duration
+	sequential     bool
+	directIO       bool
+	watchdog       time.Duration
+	watchFn        func(Diagnostics)
+	sizer          *Sizer
+	sizerName      string
+	partialOnError bool
+	allocator      Allocator
+}
+
+func (o *options) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithLimit fails the read with ErrLimitExceeded once more than n bytes
+// have been read.
+func WithLimit(n int64) Option {
+	return func(o *options) {
+		o.limit = n
+	}
+}
+
+// WithAudit calls fn once for every completed read, successful or not.
+func WithAudit(fn func(AuditEvent)) Option {
+	return func(o *options) {
+		o.audit = fn
+	}
+}
+
+// WithLabel sets a caller label, e.g. the calling component, reported with
+// the read in audit events.
+func WithLabel(label string) Option {
+	return func(o *options) {
+		o.label = label
+	}
+}
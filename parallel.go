@@ -0,0 +1,386 @@
+package readall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultParallelChunk is the range size used by ParallelReadFile when
+// chunkSize is not positive.
+const DefaultParallelChunk = 4 << 20
+
+// WithLockOSThread runs each worker of a parallel read on its own locked OS
+// thread, so workers are not migrated between threads while they read.
+func WithLockOSThread() Option {
+	return func(o *options) {
+		o.lockOS = true
+	}
+}
+
+// WithCPUAffinity locks parallel read workers to OS threads and restricts
+// worker i to CPU cpus[i%len(cpus)] where the platform supports it (Linux).
+// The pinned threads are discarded when the workers finish rather than
+// returned to the scheduler with a narrowed CPU mask.
+func WithCPUAffinity(cpus ...int) Option {
+	return func(o *options) {
+		o.lockOS = true
+		o.cpus = cpus
+	}
+}
+
+// pinWorker applies the thread options to the calling goroutine, which runs
+// worker w, and returns the function to call when the worker is done.
+func pinWorker(o *options, w int) func() {
+	if !o.lockOS {
+		return func() {}
+	}
+	runtime.LockOSThread()
+	if len(o.cpus) > 0 && setAffinity(o.cpus[w%len(o.cpus)]) == nil {
+		// Exiting while locked makes the runtime terminate the thread.
+		return func() {}
+	}
+	return runtime.UnlockOSThread
+}
+
+type readAtBlock struct {
+	off  int64
+	data []byte
+	err  error
+}
+
+// CancelStat summarizes how long parallel reads took to stop all their
+// workers once their context was done.
+type CancelStat struct {
+	Count int64
+	Mean  time.Duration
+	Max   time.Duration
+}
+
+var cancels struct {
+	count, total, max int64 // atomic; durations in nanoseconds
+}
+
+// CancelLatency reports the time from a context being done to the last
+// worker of a parallel read exiting, over all parallel reads cancelled since
+// the process started. It is bounded by one in-flight ReadAt per worker.
+func CancelLatency() CancelStat {
+	st := CancelStat{
+		Count: atomic.LoadInt64(&cancels.count),
+		Max:   time.Duration(atomic.LoadInt64(&cancels.max)),
+	}
+	if st.Count > 0 {
+		st.Mean = time.Duration(atomic.LoadInt64(&cancels.total) / st.Count)
+	}
+	return st
+}
+
+// cancelWatch notes when ctx is done during a parallel read.
+type cancelWatch struct {
+	at   int64 // atomic, UnixNano
+	stop chan struct{}
+}
+
+func watchCancel(ctx context.Context) *cancelWatch {
+	c := &cancelWatch{stop: make(chan struct{})}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				atomic.StoreInt64(&c.at, time.Now().UnixNano())
+			case <-c.stop:
+			}
+		}()
+	}
+	return c
+}
+
+// finish is called once all workers have exited and records the cancel
+// latency if ctx was done first.
+func (c *cancelWatch) finish() {
+	close(c.stop)
+	at := atomic.LoadInt64(&c.at)
+	if at == 0 {
+		return
+	}
+	d := time.Now().UnixNano() - at
+	atomic.AddInt64(&cancels.count, 1)
+	atomic.AddInt64(&cancels.total, d)
+	for {
+		max := atomic.LoadInt64(&cancels.max)
+		if d <= max || atomic.CompareAndSwapInt64(&cancels.max, max, d) {
+			return
+		}
+	}
+}
+
+// readAtOrdered reads [off, end) of r in chunk-sized blocks with workers
+// concurrent ReadAt calls and passes the blocks to fn in offset order. Worker
+// w reads blocks w, w+workers, ... into a buffer of its own, so at most
+// workers blocks are held at a time. It returns ctx.Err() once ctx is done,
+// and in every case only after all workers have exited.
+func readAtOrdered(ctx context.Context, r io.ReaderAt, off, end int64, chunk, workers int, o *options, fn func(int64, []byte) error) error {
+	if end <= off {
+		return nil
+	}
+	count := (end - off + int64(chunk) - 1) / int64(chunk)
+	if workers <= 0 {
+		workers = 1
+	}
+	if int64(workers) > count {
+		workers = int(count)
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	cw := watchCancel(ctx)
+	defer func() {
+		close(done)
+		wg.Wait()
+		cw.finish()
+	}()
+	out := make([]chan readAtBlock, workers)
+	free := make([]chan []byte, workers)
+	for w := range out {
+		out[w] = make(chan readAtBlock, 1)
+		free[w] = make(chan []byte, 1)
+		free[w] <- make([]byte, chunk)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			defer pinWorker(o, w)()
+			for i := int64(w); i < count; i += int64(workers) {
+				var buf []byte
+				select {
+				case buf = <-free[w]:
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				}
+				start := off + i*int64(chunk)
+				buf = buf[:min64(int64(chunk), end-start)]
+				m, err := r.ReadAt(buf, start)
+				if m == len(buf) {
+					err = nil
+				} else if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				select {
+				case out[w] <- readAtBlock{off: start, data: buf, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}(w)
+	}
+	for i := int64(0); i < count; i++ {
+		w := int(i % int64(workers))
+		var b readAtBlock
+		select {
+		case b = <-out[w]:
+		case <-ctx.Done():
+			return ctxErr(ctx)
+		}
+		if b.err != nil {
+			return b.err
+		}
+		if err := fn(b.off, b.data); err != nil {
+			return err
+		}
+		free[w] <- b.data[:cap(b.data)]
+	}
+	return nil
+}
+
+// ParallelReadFile reads the file at path with workers concurrent ReadAt
+// calls of chunkSize bytes each, straight into their offsets of a single
+// result slice. On fast storage this keeps more requests in flight than a
+// sequential read. Non-positive arguments select DefaultParallelChunk and
+// runtime.GOMAXPROCS workers; WithLockOSThread and WithCPUAffinity apply to
+// the workers, and so does WithDirectIO. A file that shrinks while being
+// read fails with io.ErrUnexpectedEOF; growth past the size seen at open is
+// not read.
+func ParallelReadFile(path string, chunkSize, workers int, opts ...Option) ([]byte, error) {
+	return ParallelReadFileContext(context.Background(), path, chunkSize, workers, opts...)
+}
+
+// ParallelReadFileContext is ParallelReadFile that stops when ctx is done.
+// Workers check ctx before every chunk, so each stops after at most one more
+// ReadAt. It returns nil and ctx.Err() only once all workers have exited,
+// dropping the partly filled buffer; see CancelLatency for how long that
+// takes in practice.
+func ParallelReadFileContext(ctx context.Context, path string, chunkSize, workers int, opts ...Option) (_ []byte, err error) {
+	defer func() { err = withSource(path, err) }()
+	if ctx.Err() != nil {
+		return nil, ctxErr(ctx)
+	}
+	var o options
+	o.apply(opts)
+	if chunkSize <= 0 {
+		chunkSize = DefaultParallelChunk
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	open := os.Open
+	if o.directIO {
+		open = openDirect
+		chunkSize = int(alignUp(int64(chunkSize)))
+	}
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		if o.directIO {
+			return nil, fmt.Errorf("%w: not a regular file", ErrDirectIOUnsupported)
+		}
+		return readAllCap(f, readAllSize(f), nil)
+	}
+	size := fi.Size()
+	if size > maxInt-2*directIOAlign {
+		return nil, io.ErrShortBuffer
+	}
+	// With direct I/O the last chunk reads up to the aligned end of data.
+	data := make([]byte, size)
+	if o.directIO {
+		data = alignedBuf(size)
+	}
+	o = o.withContext(ctx)
+	t := o.track(f)
+	defer t.done()
+	if err := parallelFill(ctx, f, data, size, chunkSize, workers, &o, t); err != nil {
+		return nil, err
+	}
+	observeSize(o.label, size)
+	return data, nil
+}
+
+// ParallelReadAt is ParallelReadFileContext for the first size bytes of any
+// io.ReaderAt, such as a remote object whose ReadAt issues a ranged
+// request. It fails with a *LimitError up front when size exceeds
+// WithLimit. The thread options apply to the workers.
+func ParallelReadAt(ctx context.Context, r io.ReaderAt, size int64, chunkSize, workers int, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	if ctx.Err() != nil {
+		return nil, ctxErr(ctx)
+	}
+	o := readOptions(opts).withContext(ctx)
+	if n := o.readLimit(); n > 0 && size > n {
+		return nil, &LimitError{Limit: n, Read: size}
+	}
+	if size < 0 || size > maxInt {
+		return nil, io.ErrShortBuffer
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultParallelChunk
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	data := make([]byte, size)
+	t := o.track(r)
+	defer t.done()
+	if err := parallelFill(ctx, r, data, size, chunkSize, workers, &o, t); err != nil {
+		return nil, err
+	}
+	observeSize(o.label, size)
+	return data, nil
+}
+
+// ParallelCopyAt copies the first size bytes of r to the same offsets of
+// dst, such as a spill file, with workers concurrent ReadAt calls of
+// chunkSize bytes; at most workers chunks are held in memory at a time.
+// Non-positive arguments select DefaultParallelChunk and
+// runtime.GOMAXPROCS workers.
+func ParallelCopyAt(ctx context.Context, dst io.WriterAt, r io.ReaderAt, size int64, chunkSize, workers int, opts ...Option) (err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts).withContext(ctx)
+	if n := o.readLimit(); n > 0 && size > n {
+		return &LimitError{Limit: n, Read: size}
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultParallelChunk
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	t := o.track(r)
+	defer t.done()
+	return readAtOrdered(ctx, r, 0, size, chunkSize, workers, &o, func(off int64, p []byte) error {
+		t.add(len(p))
+		_, err := dst.WriteAt(p, off)
+		return err
+	})
+}
+
+// parallelFill reads the size bytes of r into data with workers concurrent
+// ReadAt calls of chunkSize bytes, recording them in t. data may extend past
+// size, for aligned direct I/O.
+func parallelFill(ctx context.Context, r io.ReaderAt, data []byte, size int64, chunkSize, workers int, o *options, t *trackedRead) error {
+	count := (size + int64(chunkSize) - 1) / int64(chunkSize)
+	if int64(workers) > count {
+		workers = int(count)
+	}
+
+	var (
+		next    int64 = -1
+		failed  int32
+		errOnce sync.Once
+		first   error
+		wg      sync.WaitGroup
+	)
+	cw := watchCancel(ctx)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			defer pinWorker(o, w)()
+			for atomic.LoadInt32(&failed) == 0 {
+				select {
+				case <-ctx.Done():
+					errOnce.Do(func() { first = ctxErr(ctx) })
+					atomic.StoreInt32(&failed, 1)
+					return
+				default:
+				}
+				i := atomic.AddInt64(&next, 1)
+				if i >= count {
+					return
+				}
+				off := i * int64(chunkSize)
+				want := int(min64(off+int64(chunkSize), size) - off)
+				p := data[off:min64(off+int64(chunkSize), int64(cap(data)))]
+				n, err := r.ReadAt(p, off)
+				t.add(n)
+				if t.isKilled() {
+					err = t.err()
+				} else if n >= want {
+					err = nil
+				} else if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				} else if o.directIO {
+					err = directErr(err)
+				}
+				if err != nil {
+					errOnce.Do(func() { first = err })
+					atomic.StoreInt32(&failed, 1)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	cw.finish()
+	return first
+}
@@ -0,0 +1,158 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadAtOrdered(t *testing.T) {
+	data := make([]byte, 100003)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, opts := range [][]Option{nil, {WithLockOSThread()}, {WithCPUAffinity(0)}} {
+		var o options
+		o.apply(opts)
+		for _, workers := range []int{1, 3, 64} {
+			var got []byte
+			err := readAtOrdered(context.Background(), bytes.NewReader(data), 3, int64(len(data)), 1000, workers, &o, func(off int64, p []byte) error {
+				if off != int64(3+len(got)) {
+					t.Errorf("workers %v block at %v after %v bytes", workers, off, len(got))
+				}
+				got = append(got, p...)
+				return nil
+			})
+			if err != nil || !bytes.Equal(got, data[3:]) {
+				t.Errorf("workers %v len:%v, err:%v", workers, len(got), err)
+			}
+		}
+	}
+
+	stop := errors.New("stop")
+	var o options
+	err := readAtOrdered(context.Background(), bytes.NewReader(data), 0, int64(len(data)), 10, 4, &o, func(int64, []byte) error { return stop })
+	if err != stop {
+		t.Errorf("callback err:%v", err)
+	}
+	err = readAtOrdered(context.Background(), bytes.NewReader(data), 0, int64(len(data))+5, 1000, 4, &o, func(int64, []byte) error { return nil })
+	if err == nil {
+		t.Errorf("short source err:%v", err)
+	}
+}
+
+// slowReaderAt sleeps in every ReadAt and counts the calls in progress.
+type slowReaderAt struct {
+	active int32
+}
+
+func (s *slowReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	atomic.AddInt32(&s.active, 1)
+	defer atomic.AddInt32(&s.active, -1)
+	time.Sleep(5 * time.Millisecond)
+	return len(p), nil
+}
+
+func TestReadAtOrderedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	before := CancelLatency().Count
+	r := &slowReaderAt{}
+	var o options
+	blocks := 0
+	err := readAtOrdered(ctx, r, 0, 1<<30, 1000, 8, &o, func(int64, []byte) error {
+		if blocks++; blocks == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("cancel err:%v", err)
+	}
+	if n := atomic.LoadInt32(&r.active); n != 0 {
+		t.Errorf("%v ReadAt calls still running", n)
+	}
+	if st := CancelLatency(); st.Count != before+1 || st.Max <= 0 || st.Max > time.Second {
+		t.Errorf("cancel stat:%+v", st)
+	}
+
+	if _, err := ParallelReadFileContext(ctx, "unused", 0, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("done context err:%v", err)
+	}
+}
+
+func TestParallelReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	data := make([]byte, 1<<20+123)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	ioutil.WriteFile(path, data, 0644)
+	for _, c := range []struct{ chunk, workers int }{{0, 0}, {1000, 1}, {4096, 7}, {1 << 30, 4}} {
+		got, err := ParallelReadFile(path, c.chunk, c.workers)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("chunk %v workers %v len:%v, err:%v", c.chunk, c.workers, len(got), err)
+		}
+	}
+	empty := filepath.Join(dir, "empty")
+	ioutil.WriteFile(empty, nil, 0644)
+	if got, err := ParallelReadFile(empty, 10, 4); err != nil || len(got) != 0 {
+		t.Errorf("empty len:%v, err:%v", len(got), err)
+	}
+	if _, err := ParallelReadFile(filepath.Join(dir, "missing"), 10, 4); !os.IsNotExist(err) {
+		t.Errorf("missing err:%v", err)
+	}
+}
+
+func TestParallelReadAt(t *testing.T) {
+	data := bytes.Repeat([]byte("ranged"), 5000)
+	got, err := ParallelReadAt(context.Background(), bytes.NewReader(data), int64(len(data)), 1000, 3)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+	if _, err := ParallelReadAt(context.Background(), bytes.NewReader(data), int64(len(data))+1, 1000, 3); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("short err:%v, want:%v", err, io.ErrUnexpectedEOF)
+	}
+	var le *LimitError
+	if _, err := ParallelReadAt(context.Background(), bytes.NewReader(data), int64(len(data)), 1000, 3, WithLimit(10)); !errors.As(err, &le) {
+		t.Errorf("limit err:%v", err)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "copy"))
+	if err != nil {
+		t.Fatalf("create err:%v", err)
+	}
+	defer f.Close()
+	if err := ParallelCopyAt(context.Background(), f, bytes.NewReader(data), int64(len(data)), 999, 4); err != nil {
+		t.Errorf("copy err:%v", err)
+	}
+	if got, err := ioutil.ReadFile(f.Name()); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("copy len:%v, err:%v", len(got), err)
+	}
+}
+
+func BenchmarkParallelReadFile(b *testing.B) {
+	if _, err := os.Stat(testName); err != nil {
+		b.Skipf("stat err:%v", err)
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ReadFile(testName, WithMmap(MmapNever))
+		}
+	})
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ParallelReadFile(testName, 0, workers)
+			}
+		})
+	}
+}
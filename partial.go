@@ -0,0 +1,51 @@
+package readall
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PartialError is returned, with WithPartialOnError, by a read that fails
+// after it started, carrying what was read so a caller can log or salvage
+// it. It wraps the cause, so errors.Is still matches ErrLimitExceeded,
+// ErrStalled, ErrChecksumMismatch, ErrShortBody and the others, and
+// errors.As still finds a *LimitError or *LengthError.
+type PartialError struct {
+	Err error
+	// Read is the number of bytes read successfully before the failure.
+	Read int64
+	// Data holds those bytes. For a checksum mismatch it is all of the
+	// data, which failed verification.
+	Data []byte
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%v (%d bytes read)", e.Err, e.Read)
+}
+
+// Unwrap returns the cause.
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// WithPartialOnError makes the ReadAll variants, ReadResponse and its
+// variants, and Download return their errors as a *PartialError holding the
+// data read so far. Download and a checksum mismatch otherwise drop that
+// data; elsewhere it is also returned alongside the error as usual.
+func WithPartialOnError() Option {
+	return func(o *options) {
+		o.partialOnError = true
+	}
+}
+
+// partial wraps err for the data read so far if o asks for it.
+func (o *options) partial(data []byte, err error) error {
+	if !o.partialOnError || err == nil {
+		return err
+	}
+	var pe *PartialError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return &PartialError{Err: err, Read: int64(len(data)), Data: data}
+}
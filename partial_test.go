@@ -0,0 +1,91 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestWithPartialOnError(t *testing.T) {
+	boom := errors.New("boom")
+	r := &failAfterReader{r: strings.NewReader("salvage me"), err: boom}
+	data, err := ReadAll(r, WithPartialOnError())
+	var pe *PartialError
+	if !errors.As(err, &pe) || !errors.Is(err, boom) || pe.Read != 10 || string(pe.Data) != "salvage me" || string(data) != "salvage me" {
+		t.Errorf("data:%q, err:%v", data, err)
+	}
+
+	_, err = ReadAll(strings.NewReader("0123456789"), WithLimit(4), WithPartialOnError())
+	var le *LimitError
+	if !errors.As(err, &pe) || !errors.As(err, &le) || !errors.Is(err, ErrLimitExceeded) || string(pe.Data) != "0123" {
+		t.Errorf("limit err:%v", err)
+	}
+
+	dst := []byte("prefix:")
+	_, err = AppendAll(dst, iotest.TimeoutReader(strings.NewReader("abc")), WithPartialOnError())
+	if !errors.As(err, &pe) || string(pe.Data) != "abc" {
+		t.Errorf("append err:%v", err)
+	}
+
+	if _, err := ReadAll(strings.NewReader("fine"), WithPartialOnError()); err != nil {
+		t.Errorf("clean read err:%v", err)
+	}
+	if _, err := ReadAll(&failAfterReader{r: strings.NewReader("x"), err: boom}); errors.As(err, &pe) {
+		t.Errorf("partial error without the option:%v", err)
+	}
+}
+
+func TestWithPartialOnErrorContext(t *testing.T) {
+	r := &blockingReader{data: []byte("partial"), release: make(chan struct{})}
+	defer close(r.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := ReadAllContext(ctx, r, WithPartialOnError())
+	var pe *PartialError
+	if !errors.As(err, &pe) || !errors.Is(err, context.DeadlineExceeded) || string(pe.Data) != "partial" {
+		t.Errorf("err:%v", err)
+	}
+}
+
+func TestWithPartialOnErrorResponse(t *testing.T) {
+	rsp, _ := response("short", 10)
+	_, err := ReadResponse(rsp, WithPartialOnError())
+	var pe *PartialError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrShortBody) || pe.Read != 5 {
+		t.Errorf("short body err:%v", err)
+	}
+}
+
+func TestWithPartialOnErrorDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write(bytes.Repeat([]byte("d"), 40))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+	_, err := Download(context.Background(), srv.Client(), srv.URL, WithRetries(0), WithPartialOnError())
+	var pe *PartialError
+	if !errors.As(err, &pe) || pe.Read != 40 || !bytes.Equal(pe.Data, bytes.Repeat([]byte("d"), 40)) {
+		t.Errorf("download err:%v", err)
+	}
+}
+
+// failAfterReader returns the data of r and then err instead of io.EOF.
+type failAfterReader struct {
+	r   *strings.Reader
+	err error
+}
+
+func (f *failAfterReader) Read(p []byte) (int, error) {
+	if f.r.Len() == 0 {
+		return 0, f.err
+	}
+	return f.r.Read(p)
+}
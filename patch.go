@@ -0,0 +1,182 @@
+package readall
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+// ErrCorruptDelta is returned by ApplyPatch when the delta stream is malformed
+// or does not produce the declared target size.
+var ErrCorruptDelta = errors.New("readall: corrupt delta")
+
+// Delta op codes. A delta is a uvarint target size followed by ops:
+//
+//	0x01 uvarint(offset) uvarint(length)   copy length bytes of base at offset
+//	0x02 uvarint(length) data[length]      insert literal data
+const (
+	deltaCopy   = 0x01
+	deltaInsert = 0x02
+)
+
+// maxPatchPrealloc caps how much of the declared target size is allocated up
+// front, since the size comes from the (possibly untrusted) delta.
+const maxPatchPrealloc = 64 << 20
+
+// patchStep bounds how far the output grows ahead of the bytes an op has
+// actually produced, since op lengths come from the delta too.
+const patchStep = 1 << 20
+
+var bufioPool = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewReaderSize(nil, 32<<10)
+	},
+}
+
+// ApplyPatch materializes the result of applying delta to base. See
+// DeltaBuilder for the delta format.
+func ApplyPatch(base io.ReaderAt, delta io.Reader) (_ []byte, err error) {
+	defer annotate(&err, delta)
+	br := bufioPool.Get().(*bufio.Reader)
+	br.Reset(delta)
+	defer func() {
+		br.Reset(nil)
+		bufioPool.Put(br)
+	}()
+
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, deltaErr(err)
+	}
+	prealloc := int64(maxPatchPrealloc)
+	if size < maxPatchPrealloc {
+		prealloc = int64(size)
+	}
+	out := make([]byte, 0, prealloc)
+	for {
+		op, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		switch op {
+		case deltaCopy:
+			off, err := binary.ReadUvarint(br)
+			if err != nil {
+				return out, deltaErr(err)
+			}
+			n, err := readDeltaLen(br, out, size)
+			if err != nil {
+				return out, err
+			}
+			if off > math.MaxInt64-uint64(n) {
+				return out, ErrCorruptDelta
+			}
+			for n > 0 {
+				step := n
+				if step > patchStep {
+					step = patchStep
+				}
+				pos := len(out)
+				out = growLen(out, step)
+				m, err := base.ReadAt(out[pos:], int64(off))
+				if m < step {
+					if err == nil || err == io.EOF {
+						err = ErrCorruptDelta
+					}
+					return out[:pos+m], err
+				}
+				off += uint64(step)
+				n -= step
+			}
+		case deltaInsert:
+			n, err := readDeltaLen(br, out, size)
+			if err != nil {
+				return out, err
+			}
+			for n > 0 {
+				step := n
+				if step > patchStep {
+					step = patchStep
+				}
+				pos := len(out)
+				out = growLen(out, step)
+				if m, err := io.ReadFull(br, out[pos:]); err != nil {
+					return out[:pos+m], deltaErr(err)
+				}
+				n -= step
+			}
+		default:
+			return out, ErrCorruptDelta
+		}
+	}
+	if uint64(len(out)) != size {
+		return out, ErrCorruptDelta
+	}
+	return out, nil
+}
+
+func readDeltaLen(br *bufio.Reader, out []byte, size uint64) (int, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, deltaErr(err)
+	}
+	if n > size-uint64(len(out)) || n > uint64(maxInt-int64(len(out))) {
+		return 0, ErrCorruptDelta
+	}
+	return int(n), nil
+}
+
+func deltaErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorruptDelta
+	}
+	return err
+}
+
+// growLen extends b by n bytes, reallocating only when capacity runs out.
+func growLen(b []byte, n int) []byte {
+	if len(b)+n <= cap(b) {
+		return b[:len(b)+n]
+	}
+	nb := make([]byte, len(b)+n, 2*cap(b)+n)
+	copy(nb, b)
+	return nb
+}
+
+// DeltaBuilder encodes a delta understood by ApplyPatch.
+type DeltaBuilder struct {
+	ops  []byte
+	size uint64
+}
+
+// Copy appends an op copying n bytes of the base starting at off.
+func (d *DeltaBuilder) Copy(off, n int64) {
+	d.ops = append(d.ops, deltaCopy)
+	d.ops = appendUvarint(d.ops, uint64(off))
+	d.ops = appendUvarint(d.ops, uint64(n))
+	d.size += uint64(n)
+}
+
+// Insert appends an op inserting data literally.
+func (d *DeltaBuilder) Insert(data []byte) {
+	d.ops = append(d.ops, deltaInsert)
+	d.ops = appendUvarint(d.ops, uint64(len(data)))
+	d.ops = append(d.ops, data...)
+	d.size += uint64(len(data))
+}
+
+// Bytes returns the encoded delta.
+func (d *DeltaBuilder) Bytes() []byte {
+	return append(appendUvarint(nil, d.size), d.ops...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(b, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
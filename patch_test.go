@@ -0,0 +1,55 @@
+package readall
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	base := strings.NewReader("the quick brown fox jumps over the lazy dog")
+	var d DeltaBuilder
+	d.Copy(0, 10)
+	d.Insert([]byte("red"))
+	d.Copy(15, 28)
+	got, err := ApplyPatch(base, bytes.NewReader(d.Bytes()))
+	if err != nil {
+		t.Errorf("patch err:%v", err)
+		return
+	}
+	if want := "the quick red fox jumps over the lazy dog"; string(got) != want {
+		t.Errorf("patch got:%q, want:%q", got, want)
+	}
+}
+
+func TestApplyPatchCorrupt(t *testing.T) {
+	base := strings.NewReader("short")
+	var d DeltaBuilder
+	d.Copy(2, 10)
+	if _, err := ApplyPatch(base, bytes.NewReader(d.Bytes())); err != ErrCorruptDelta {
+		t.Errorf("copy past base err:%v, want:%v", err, ErrCorruptDelta)
+	}
+
+	d = DeltaBuilder{}
+	d.Insert([]byte("hello"))
+	delta := d.Bytes()
+	if _, err := ApplyPatch(base, bytes.NewReader(delta[:len(delta)-2])); err != ErrCorruptDelta {
+		t.Errorf("truncated delta err:%v, want:%v", err, ErrCorruptDelta)
+	}
+}
+
+func TestApplyPatchAdversarial(t *testing.T) {
+	base := strings.NewReader("short")
+	huge := appendUvarint(nil, 1<<50)
+	for name, delta := range map[string][]byte{
+		"huge insert": append(append(append(append([]byte{}, huge...), deltaInsert), huge...), 'x'),
+		"huge copy":   append(append(append(append([]byte{}, huge...), deltaCopy), 0), huge...),
+		"max uint64":  append(append(append(appendUvarint(nil, math.MaxUint64), deltaInsert), appendUvarint(nil, math.MaxUint64)...), 'x'),
+		"far offset":  append(append(append(appendUvarint(nil, 1), deltaCopy), appendUvarint(nil, math.MaxUint64)...), 1),
+	} {
+		if _, err := ApplyPatch(base, bytes.NewReader(delta)); err != ErrCorruptDelta {
+			t.Errorf("%v err:%v, want:%v", name, err, ErrCorruptDelta)
+		}
+	}
+}
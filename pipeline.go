@@ -0,0 +1,279 @@
+package readall
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"readall/internal/core"
+)
+
+// ErrLimitExceeded is returned when a read produces more bytes than allowed.
+// Limited reads return it as a *LimitError, which matches it with errors.Is.
+var ErrLimitExceeded = errors.New("readall: size limit exceeded")
+
+// LimitError reports a read stopped by a size limit.
+type LimitError struct {
+	// Limit is the number of bytes allowed.
+	Limit int64
+	// Read is the number of bytes consumed from the source when the limit
+	// was found to be exceeded. Only Limit bytes are returned.
+	Read int64
+
+	std error // the standard library error translated, if any
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("readall: size limit of %d bytes exceeded (%d bytes read)", e.Limit, e.Read)
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Unwrap returns the *http.MaxBytesError the LimitError was translated
+// from, if any.
+func (e *LimitError) Unwrap() error {
+	return e.std
+}
+
+// Transform wraps the reader at one point of a pipeline, e.g. to decrypt or
+// decompress it.
+type Transform func(r io.Reader) (io.Reader, error)
+
+// Pipeline describes a read from a Source through a chain of stages. Stages
+// apply in the order they are added, so Limit before Decompress bounds the
+// compressed size and Limit after it bounds the decompressed size. Nothing is
+// read until To or Bytes runs the pipeline in a single copy loop.
+//
+//	data, err := readall.From(readall.FileSource(path)).
+//		Limit(1 << 30).
+//		Decompress().
+//		Hash(h).
+//		Bytes()
+type Pipeline struct {
+	ctx    context.Context
+	src    Source
+	stages []Transform
+	// err is the first error from building the pipeline, reported by To.
+	err error
+	// sized reports whether the output size still equals the source size,
+	// so the destination can be preallocated.
+	sized bool
+	// limit is the smallest Limit stage, if limited. It caps the
+	// preallocation along with maxResponsePrealloc, since the source size
+	// may come from the peer.
+	limit   int64
+	limited bool
+
+	opts options
+	// digest is the hash of the first Hash stage, reported in audit events.
+	digest hash.Hash
+	// inflate counts the first Decompress stage for CompressionStats.
+	inflate *decompressRun
+
+	// Set by BuildPipeline.
+	hashes map[string]hash.Hash
+	sink   string
+}
+
+// From starts a pipeline reading src.
+func From(src Source) *Pipeline {
+	return &Pipeline{ctx: context.Background(), src: src, sized: true}
+}
+
+// Context sets the context used to open the source. The copy loop checks it
+// between reads as well and, when it has a deadline, shrinks reads as the
+// deadline approaches; see To.
+func (p *Pipeline) Context(ctx context.Context) *Pipeline {
+	p.ctx = ctx
+	return p
+}
+
+// Limit fails the pipeline with ErrLimitExceeded if more than n bytes pass
+// this point.
+func (p *Pipeline) Limit(n int64) *Pipeline {
+	if !p.limited || n < p.limit {
+		p.limit, p.limited = n, true
+	}
+	p.stages = append(p.stages, func(r io.Reader) (io.Reader, error) {
+		return newLimitReader(r, n), nil
+	})
+	return p
+}
+
+// Decompress transparently decompresses gzip and zlib streams, detected by
+// their magic bytes; other data passes through unchanged.
+func (p *Pipeline) Decompress() *Pipeline {
+	p.sized = false
+	if p.inflate == nil {
+		p.inflate = new(decompressRun)
+		p.stages = append(p.stages, p.inflate.stage)
+		return p
+	}
+	p.stages = append(p.stages, decompress)
+	return p
+}
+
+// Options applies read options to the pipeline. WithLimit adds a Limit stage
+// at this point.
+func (p *Pipeline) Options(opts ...Option) *Pipeline {
+	var o options
+	o.apply(opts)
+	p.opts.apply(opts)
+	if o.limit > 0 {
+		p.Limit(o.limit)
+	}
+	return p
+}
+
+// Hash feeds every byte passing this point into h.
+func (p *Pipeline) Hash(h hash.Hash) *Pipeline {
+	if p.digest == nil {
+		p.digest = h
+	}
+	p.stages = append(p.stages, func(r io.Reader) (io.Reader, error) {
+		return wrappedReader{Reader: io.TeeReader(r, h), src: r}, nil
+	})
+	return p
+}
+
+// Apply adds t as the next stage.
+func (p *Pipeline) Apply(t Transform) *Pipeline {
+	p.sized = false
+	p.stages = append(p.stages, t)
+	return p
+}
+
+// To runs the pipeline, copying its output to w. If the pipeline context
+// ends first, the error wraps its error and reports how many bytes were read
+// at what rate. A pipeline without stages copying a file or socket to a file
+// or socket lets the kernel move the data without a user-space copy where
+// the platform supports it.
+func (p *Pipeline) To(w io.Writer) (n int64, err error) {
+	o := p.opts.withContext(p.ctx)
+	if o.audit != nil {
+		start := time.Now()
+		defer func() { emitAudit(&o, p.src, start, n, p.digest, err) }()
+	}
+	defer annotate(&err, p.src)
+	if p.err != nil {
+		return 0, p.err
+	}
+	defer func() { observeSize(o.label, n) }()
+	if p.inflate != nil {
+		key := o.label
+		if key == "" {
+			key = redact(sourceName(p.src))
+		}
+		defer func() {
+			if err == nil {
+				p.inflate.observe(key)
+			}
+		}()
+	}
+	t := o.track(p.src)
+	defer t.done()
+	defer o.watch(t)()
+	rc, err := p.src.Open(p.ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	t.setAbort(func() { rc.Close() })
+	var r io.Reader = trackedReader{o.withRate(o.withIdle(o.withProgress(o.withCoalesce(rc)), rc)), t}
+	for _, s := range p.stages {
+		if r, err = s(r); err != nil {
+			return 0, err
+		}
+	}
+	r = o.withSoftLimit(o.withNewlines(r))
+	if buf, ok := w.(*bytes.Buffer); ok && p.sized {
+		if size, ok := sizeHint(rc); ok && size > 0 {
+			size = min64(size, maxResponsePrealloc)
+			if p.limited {
+				size = min64(size, p.limit)
+			}
+			if size > 0 {
+				buf.Grow(int(size))
+			}
+		}
+	}
+	if len(p.stages) == 0 && p.ctx.Done() == nil && o.progress == nil && o.rate == nil && o.idle == 0 && o.softFn == nil && o.newline == KeepNewlines && kernelCopy(w, rc) {
+		return io.Copy(w, rc)
+	}
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	if p.ctx.Done() != nil {
+		return copyContext(p.ctx, w, r, *bp)
+	}
+	return io.CopyBuffer(w, onlyReader{r}, *bp)
+}
+
+// kernelCopy reports whether copying src to w with io.Copy lets the standard
+// library move the data inside the kernel (copy_file_range, sendfile or
+// splice on Linux) instead of through a user-space buffer.
+func kernelCopy(w io.Writer, src io.Reader) bool {
+	switch w.(type) {
+	case *os.File, *net.TCPConn, *net.UnixConn:
+	default:
+		return false
+	}
+	switch src.(type) {
+	case *os.File, *net.TCPConn, *net.UnixConn:
+		return true
+	}
+	return false
+}
+
+// Bytes runs the pipeline and returns its output.
+func (p *Pipeline) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := p.To(&buf)
+	return buf.Bytes(), err
+}
+
+// onlyReader hides WriterTo so io.CopyBuffer uses the pooled buffer.
+type onlyReader struct {
+	io.Reader
+}
+
+// newLimitReader returns a reader failing with a *LimitError once more than
+// n bytes have been read from r. It never asks r for more than one byte past
+// the limit, so exceeding is noticed with exactly n+1 bytes consumed.
+func newLimitReader(r io.Reader, n int64) io.Reader {
+	read := n + 1
+	if read < n {
+		read = n
+	}
+	return &core.LimitReader{R: r, N: n, Err: &LimitError{Limit: n, Read: read}}
+}
+
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var dr io.Reader = br
+	switch {
+	case len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		dr, err = gzip.NewReader(br)
+	case len(magic) == 2 && magic[0] == 0x78 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0:
+		dr, err = zlib.NewReader(br)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wrappedReader{Reader: dr, src: r}, nil
+}
@@ -0,0 +1,68 @@
+package readall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildPipeline(t *testing.T) {
+	dir := t.TempDir()
+	plain := strings.Repeat("configured ", 500)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(plain))
+	zw.Close()
+	src := filepath.Join(dir, "in.gz")
+	ioutil.WriteFile(src, gz.Bytes(), 0644)
+
+	doc := `{
+		"source": "file://` + filepath.ToSlash(src) + `",
+		"stages": [
+			{"type": "limit", "limit": 1048576},
+			{"type": "decompress"},
+			{"type": "hash", "algorithm": "SHA256"}
+		],
+		"sink": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"
+	}`
+	var cfg PipelineConfig
+	if err := json.Unmarshal([]byte(doc), &cfg); err != nil {
+		t.Errorf("unmarshal err:%v", err)
+		return
+	}
+	p, err := BuildPipeline(cfg)
+	if err != nil {
+		t.Errorf("build err:%v", err)
+		return
+	}
+	if _, err := p.Run(); err != nil {
+		t.Errorf("run err:%v", err)
+		return
+	}
+	out, _ := ioutil.ReadFile(cfg.Sink)
+	if string(out) != plain {
+		t.Errorf("sink len:%v, want:%v", len(out), len(plain))
+	}
+	if sum := sha256.Sum256([]byte(plain)); !bytes.Equal(p.Sum("sha256"), sum[:]) {
+		t.Errorf("digest mismatch")
+	}
+}
+
+func TestBuildPipelineInvalid(t *testing.T) {
+	cfgs := []PipelineConfig{
+		{Source: "ftp://host/file"},
+		{Source: "x", Stages: []StageConfig{{Type: "encrypt"}}},
+		{Source: "x", Stages: []StageConfig{{Type: "hash", Algorithm: "whirlpool"}}},
+		{Source: "x", Stages: []StageConfig{{Type: "limit"}}},
+	}
+	for _, cfg := range cfgs {
+		if _, err := BuildPipeline(cfg); err == nil {
+			t.Errorf("config %+v accepted", cfg)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package readall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	plain := strings.Repeat("pipeline ", 1000)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(plain))
+	zw.Close()
+
+	h := sha256.New()
+	data, err := From(BytesSource(gz.Bytes())).Limit(int64(gz.Len())).Decompress().Hash(h).Bytes()
+	if err != nil {
+		t.Errorf("pipeline err:%v", err)
+		return
+	}
+	if string(data) != plain {
+		t.Errorf("pipeline data len:%v, want:%v", len(data), len(plain))
+	}
+	if sum := sha256.Sum256([]byte(plain)); !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Errorf("digest mismatch")
+	}
+
+	passthrough, err := From(BytesSource([]byte(plain))).Decompress().Bytes()
+	if err != nil || string(passthrough) != plain {
+		t.Errorf("passthrough len:%v, err:%v", len(passthrough), err)
+	}
+}
+
+func TestPipelineLimit(t *testing.T) {
+	plain := strings.Repeat("x", 1000)
+	data, err := From(BytesSource([]byte(plain))).Limit(100).Bytes()
+	if !errors.Is(err, ErrLimitExceeded) || len(data) != 100 {
+		t.Errorf("limited len:%v, err:%v", len(data), err)
+	}
+	if _, err := From(BytesSource([]byte(plain))).Limit(1000).Bytes(); err != nil {
+		t.Errorf("exact limit err:%v", err)
+	}
+}
+
+// lyingServer declares a 1TiB body and sends a few bytes.
+func lyingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1099511627776")
+		w.Write([]byte("short"))
+	}))
+}
+
+func TestPipelineLyingLength(t *testing.T) {
+	srv := lyingServer()
+	defer srv.Close()
+	if _, err := From(&HTTPSource{URL: srv.URL}).Limit(1 << 20).Bytes(); err == nil {
+		t.Errorf("lying length err:%v", err)
+	}
+	if _, err := From(&HTTPSource{URL: srv.URL}).Bytes(); err == nil {
+		t.Errorf("unlimited lying length err:%v", err)
+	}
+}
+
+func TestPipelineFileToFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	data := bytes.Repeat([]byte("kernel copy "), 10000)
+	ioutil.WriteFile(src, data, 0644)
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Errorf("create err:%v", err)
+		return
+	}
+	defer f.Close()
+	if !kernelCopy(f, f) || kernelCopy(&bytes.Buffer{}, f) {
+		t.Errorf("kernel copy detection wrong")
+	}
+	n, err := From(FileSource(src)).To(f)
+	if err != nil || n != int64(len(data)) {
+		t.Errorf("copy n:%v, err:%v", n, err)
+	}
+	if got, _ := ioutil.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Errorf("copied len:%v", len(got))
+	}
+}
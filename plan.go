@@ -0,0 +1,106 @@
+package readall
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DownloadPlan describes how DownloadResult fetched a resource.
+type DownloadPlan struct {
+	// Probed is set when the plan was made from a request ahead of the
+	// download (see WithProbe) rather than from the first response.
+	Probed bool
+	// Size is the resource size, or -1 if the server did not tell.
+	Size int64
+	// AcceptRanges reports whether the server advertised range requests.
+	AcceptRanges bool
+	// Validator is the ETag or Last-Modified value guarding resumption,
+	// empty if the resource has neither.
+	Validator string
+	// Checksums are the integrity headers declared for the resource, by
+	// header name, as checked by WithIntegrityCheck.
+	Checksums map[string]string
+	// Parts is the number of concurrent range requests; 1 is a single
+	// stream.
+	Parts int
+	// Prealloc is the buffer capacity allocated up front.
+	Prealloc int64
+}
+
+// WithProbe makes Download ask for the size, range support, validator and
+// checksums of the resource before fetching it: with a HEAD request, or a
+// GET of the first byte where HEAD is refused or sends no Content-Length.
+// The download is then planned up front; with WithParallelRanges all parts
+// start at once instead of after the first response. The probe costs a
+// round trip.
+func WithProbe() Option {
+	return func(o *options) {
+		o.probe = true
+	}
+}
+
+// fill records what rsp, a full response or a probe, tells about the
+// resource.
+func (p *DownloadPlan) fill(rsp *http.Response) {
+	p.Size = rsp.ContentLength
+	p.AcceptRanges = rsp.Header.Get("Accept-Ranges") == "bytes"
+	p.Validator = ifRangeValidator(rsp.Header)
+	p.Checksums = nil
+	for _, cs := range checksums(rsp.Header) {
+		if p.Checksums == nil {
+			p.Checksums = make(map[string]string)
+		}
+		p.Checksums[cs.header] = cs.want
+	}
+}
+
+// Size implements Source. It plans the download from a HEAD request,
+// falling back to a request for the first byte.
+func (h *httpDownload) Size(ctx context.Context) (int64, error) {
+	rsp, err := h.probeRequest(ctx, http.MethodHead)
+	if err != nil {
+		return 0, err
+	}
+	rsp.Body.Close()
+	switch {
+	case rsp.StatusCode == http.StatusOK && rsp.ContentLength >= 0:
+	case rsp.StatusCode == http.StatusOK, rsp.StatusCode == http.StatusMethodNotAllowed, rsp.StatusCode == http.StatusNotImplemented:
+		if rsp, err = h.probeRequest(ctx, http.MethodGet); err != nil {
+			return 0, err
+		}
+		rsp.Body.Close()
+		switch rsp.StatusCode {
+		case http.StatusOK:
+		case http.StatusPartialContent:
+			// The size is the total of Content-Range, and ranges work.
+			rsp.ContentLength = -1
+			cr := rsp.Header.Get("Content-Range")
+			if i := strings.LastIndexByte(cr, '/'); i >= 0 {
+				if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+					rsp.ContentLength = n
+				}
+			}
+			rsp.Header.Set("Accept-Ranges", "bytes")
+		default:
+			return 0, &statusError{code: rsp.StatusCode, status: rsp.Status}
+		}
+	default:
+		return 0, &statusError{code: rsp.StatusCode, status: rsp.Status}
+	}
+	h.plan.fill(rsp)
+	h.validator, h.header, h.ranges = h.plan.Validator, rsp.Header, h.plan.AcceptRanges
+	return h.plan.Size, nil
+}
+
+func (h *httpDownload) probeRequest(ctx context.Context, method string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	return h.do(req)
+}
@@ -0,0 +1,132 @@
+package readall
+
+import (
+	"io"
+	"math/bits"
+	"sync"
+)
+
+// Buffer size classes kept by a Pool: powers of two from 4KiB to 1GiB.
+const (
+	minPoolShift = 12
+	maxPoolShift = 30
+)
+
+// Pool reuses read buffers across ReadAll calls to cut allocation and GC
+// work when many goroutines read large payloads concurrently. Buffers are
+// kept in power-of-two size classes, each backed by a sync.Pool, so a read
+// reuses a buffer of at most twice the size it needs. The zero value is
+// ready to use and a Pool is safe for concurrent use.
+type Pool struct {
+	tiers [maxPoolShift - minPoolShift + 1]sync.Pool
+	group Group // reads in progress, see Shutdown
+}
+
+// ReadAll reads r until EOF into a pooled buffer, sized from r like the
+// package-level ReadAll. The data is valid until release is called, which
+// returns the buffer to the pool; calling release more than once is a
+// no-op. On error the data read so far is returned and release must still
+// be called. WithLimit, WithLabel and WithStats apply; the Stats count the
+// buffers taken from the pool as PoolHits and the ones allocated as
+// PoolMisses.
+func (p *Pool) ReadAll(r io.Reader, opts ...Option) (data []byte, release func(), err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts)
+	st := o.startStats()
+	defer st.finish()
+	b := p.get(o.capacity(r), st)
+	t := o.track(r)
+	p.group.join(t)
+	defer t.done()
+	defer o.watch(t)()
+	r = trackedReader{r, t}
+	if n := o.readLimit(); n > 0 {
+		r = newLimitReader(r, n)
+	}
+	r = st.reader(r)
+	for {
+		if len(b) == cap(b) {
+			if st != nil {
+				st.Grows++
+			}
+			nb := p.get(2*int64(cap(b)), st)
+			nb = append(nb, b...)
+			p.put(b)
+			b = nb
+		}
+		n, rerr := r.Read(b[len(b):cap(b)])
+		b = b[:len(b)+n]
+		if rerr != nil {
+			if rerr != io.EOF {
+				err = rerr
+			}
+			break
+		}
+	}
+	if t.isKilled() {
+		p.put(b)
+		return nil, func() {}, t.err()
+	}
+	observeSize(o.label, int64(len(b)))
+	if err == nil {
+		o.learnSize(int64(len(b)))
+	}
+	var once sync.Once
+	return b, func() { once.Do(func() { p.put(b) }) }, err
+}
+
+// poolTier returns the size class holding buffers of at least n bytes, or -1 if
+// n is larger than the largest class.
+func poolTier(n int64) int {
+	shift := minPoolShift
+	if n > 1<<minPoolShift {
+		shift = bits.Len64(uint64(n - 1))
+	}
+	if shift > maxPoolShift {
+		return -1
+	}
+	return shift - minPoolShift
+}
+
+// get returns an empty buffer of capacity at least n, recording in st
+// whether it came from the pool.
+func (p *Pool) get(n int64, st *readStats) []byte {
+	t := poolTier(n)
+	if t >= 0 {
+		if v := p.tiers[t].Get(); v != nil {
+			if st != nil {
+				st.PoolHits++
+			}
+			return (*v.(*[]byte))[:0]
+		}
+		n = 1 << uint(t+minPoolShift)
+	}
+	if st != nil {
+		st.PoolMisses++
+	}
+	st.alloc(n)
+	return make([]byte, 0, n)
+}
+
+// put returns b to its class. Buffers not allocated by get, whose capacity
+// is not exactly a class size, are left to the garbage collector.
+func (p *Pool) put(b []byte) {
+	c := int64(cap(b))
+	t := poolTier(c)
+	if t < 0 || c != 1<<uint(t+minPoolShift) {
+		return
+	}
+	b = b[:0]
+	p.tiers[t].Put(&b)
+}
+
+// Trim empties the pool and returns the capacity of the buffers dropped.
+func (p *Pool) Trim() int64 {
+	var n int64
+	for t := range p.tiers {
+		for v := p.tiers[t].Get(); v != nil; v = p.tiers[t].Get() {
+			n += int64(cap(*v.(*[]byte)))
+		}
+	}
+	return n
+}
@@ -0,0 +1,85 @@
+package readall
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPoolReadAll(t *testing.T) {
+	var p Pool
+	data := bytes.Repeat([]byte("pooled"), 5000)
+	got, release, err := p.ReadAll(bytes.NewReader(data))
+	if err != nil || !bytes.Equal(got, data) || cap(got) != 32<<10 {
+		t.Errorf("sized len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+	release()
+	release()
+
+	got, release, err = p.ReadAll(iotest.HalfReader(bytes.NewReader(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("unsized len:%v, err:%v", len(got), err)
+	}
+	release()
+
+	var s Stats
+	got, release, err = p.ReadAll(bytes.NewReader(data), WithStats(&s))
+	if err != nil || s.Bytes != int64(len(data)) || s.PoolHits+s.PoolMisses != 1 || s.Grows != 0 {
+		t.Errorf("stats:%+v, err:%v", s, err)
+	}
+	release()
+
+	_, release, err = p.ReadAll(iotest.TimeoutReader(strings.NewReader("x")))
+	if err == nil {
+		t.Errorf("read err:%v", err)
+	}
+	release()
+}
+
+func TestPoolTier(t *testing.T) {
+	for _, c := range []struct {
+		n    int64
+		tier int
+	}{{0, 0}, {4096, 0}, {4097, 1}, {1 << 20, 8}, {1 << 30, 18}, {1<<30 + 1, -1}} {
+		if got := poolTier(c.n); got != c.tier {
+			t.Errorf("tier(%v):%v, want:%v", c.n, got, c.tier)
+		}
+	}
+	var p Pool
+	b := p.get(5000, nil)
+	p.put(b)
+	p.put(make([]byte, 5000))
+	if got := p.get(8000, nil); cap(got) != 8192 {
+		t.Errorf("reused cap:%v", cap(got))
+	}
+}
+
+func BenchmarkPoolReadAll(b *testing.B) {
+	var p Pool
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			f, err := os.Open(testName)
+			if err != nil {
+				b.Skipf("open err:%v", err)
+			}
+			_, release, _ := p.ReadAll(f)
+			release()
+			f.Close()
+		}
+	})
+}
+
+func BenchmarkReadAllParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			f, err := os.Open(testName)
+			if err != nil {
+				b.Skipf("open err:%v", err)
+			}
+			ReadAll(f)
+			f.Close()
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package readall
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultPrefetchSize is the buffer size Prefetch uses when bufSize is not
+// positive.
+const DefaultPrefetchSize = 64 << 10
+
+var errPrefetchClosed = errors.New("readall: read from closed prefetcher")
+
+// Prefetch returns a reader over r that fills up to depth buffers of bufSize
+// bytes ahead of the consumer in a background goroutine, so a network read
+// and the consumer's work on the previous chunk overlap. depth is at least
+// 1. Close stops the goroutine once its pending Read of r returns, and
+// closes r if it is an io.Closer, which is what usually unblocks that Read.
+func Prefetch(r io.Reader, bufSize, depth int) io.ReadCloser {
+	if bufSize <= 0 {
+		bufSize = DefaultPrefetchSize
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	p := &prefetcher{
+		r:    r,
+		full: make(chan prefetchChunk, depth),
+		free: make(chan []byte, depth+1),
+		done: make(chan struct{}),
+	}
+	for i := 0; i <= depth; i++ {
+		p.free <- make([]byte, bufSize)
+	}
+	go p.fill()
+	return p
+}
+
+type prefetchChunk struct {
+	buf []byte
+	n   int
+	err error
+}
+
+type prefetcher struct {
+	r    io.Reader
+	full chan prefetchChunk
+	free chan []byte
+	done chan struct{}
+	once sync.Once
+
+	cur prefetchChunk
+	off int
+	err error
+}
+
+// fill reads r into free buffers until an error, handing them to Read.
+func (p *prefetcher) fill() {
+	for {
+		var buf []byte
+		select {
+		case buf = <-p.free:
+		case <-p.done:
+			return
+		}
+		var n int
+		var err error
+		for n == 0 && err == nil {
+			n, err = p.r.Read(buf)
+		}
+		select {
+		case p.full <- prefetchChunk{buf: buf, n: n, err: err}:
+		case <-p.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *prefetcher) Read(b []byte) (int, error) {
+	for p.off == p.cur.n {
+		if p.err != nil {
+			return 0, p.err
+		}
+		if p.cur.buf != nil {
+			p.free <- p.cur.buf
+		}
+		select {
+		case <-p.done:
+		case p.cur = <-p.full:
+		}
+		if p.closed() {
+			p.cur, p.off, p.err = prefetchChunk{}, 0, errPrefetchClosed
+			return 0, p.err
+		}
+		p.off, p.err = 0, p.cur.err
+	}
+	n := copy(b, p.cur.buf[p.off:p.cur.n])
+	p.off += n
+	return n, nil
+}
+
+func (p *prefetcher) closed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *prefetcher) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.done)
+		if c, ok := p.r.(io.Closer); ok {
+			err = c.Close()
+		}
+	})
+	return err
+}
+
+func (p *prefetcher) Unwrap() io.Reader {
+	return p.r
+}
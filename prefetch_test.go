@@ -0,0 +1,49 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPrefetch(t *testing.T) {
+	data := bytes.Repeat([]byte("prefetch"), 10000)
+	for _, depth := range []int{0, 1, 4} {
+		p := Prefetch(iotest.HalfReader(bytes.NewReader(data)), 1000, depth)
+		got, err := ioutil.ReadAll(iotest.OneByteReader(io.LimitReader(p, 5000)))
+		if err != nil {
+			t.Fatalf("depth %v read err:%v", depth, err)
+		}
+		rest, err := ioutil.ReadAll(p)
+		if err != nil || !bytes.Equal(append(got, rest...), data) {
+			t.Errorf("depth %v len:%v, err:%v", depth, len(got)+len(rest), err)
+		}
+		p.Close()
+	}
+
+	p := Prefetch(iotest.TimeoutReader(bytes.NewReader(data)), 100, 2)
+	if _, err := ioutil.ReadAll(p); err != iotest.ErrTimeout {
+		t.Errorf("err:%v, want:%v", err, iotest.ErrTimeout)
+	}
+}
+
+func TestPrefetchClose(t *testing.T) {
+	pr, pw := io.Pipe()
+	p := Prefetch(pr, 10, 2)
+	go pw.Write([]byte("hello"))
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(p, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("read:%q, err:%v", buf, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("close err:%v", err)
+	}
+	if _, err := p.Read(buf); err != errPrefetchClosed {
+		t.Errorf("read after close err:%v, want:%v", err, errPrefetchClosed)
+	}
+	if _, err := pw.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("source not closed, write err:%v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package readall
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Preview returns at most the first n bytes of data as a printable string for
+// logs: non-printable bytes and invalid UTF-8 are escaped Go-style, and a
+// truncation marker with the total size is appended when data is longer.
+func Preview(data []byte, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	head := data
+	if len(head) > n {
+		head = head[:n]
+	}
+	var b strings.Builder
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&b, `\x%02x`, head[0])
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.Write(head[:size])
+		}
+		head = head[size:]
+	}
+	if len(data) > n {
+		fmt.Fprintf(&b, "...(%d more bytes, %d total)", len(data)-n, len(data))
+	}
+	return b.String()
+}
+
+// Hexdump formats n bytes of data starting at off like hexdump -C, with
+// offsets relative to the start of data. The window is clamped to data, and
+// markers note the bytes left out before and after it.
+func Hexdump(data []byte, off, n int64) string {
+	size := int64(len(data))
+	if off < 0 {
+		off = 0
+	}
+	if off > size {
+		off = size
+	}
+	end := off + n
+	if n < 0 || end > size {
+		end = size
+	}
+	var b strings.Builder
+	if off > 0 {
+		fmt.Fprintf(&b, "... %d bytes before\n", off)
+	}
+	for line := off; line < end; line += 16 {
+		fmt.Fprintf(&b, "%08x  ", line)
+		for i := line; i < line+16; i++ {
+			if i < end {
+				fmt.Fprintf(&b, "%02x ", data[i])
+			} else {
+				b.WriteString("   ")
+			}
+			if i == line+7 {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(" |")
+		for i := line; i < line+16 && i < end; i++ {
+			c := data[i]
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	if end < size {
+		fmt.Fprintf(&b, "... %d bytes after\n", size-end)
+	}
+	return b.String()
+}
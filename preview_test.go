@@ -0,0 +1,35 @@
+package readall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	cases := []struct {
+		data string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"line\nnext\t\x00\xff", 20, `line\nnext\t\x00\xff`},
+		{"你好世界", 6, "你好...(6 more bytes, 12 total)"},
+		{strings.Repeat("a", 100), 3, "aaa...(97 more bytes, 100 total)"},
+	}
+	for _, c := range cases {
+		if got := Preview([]byte(c.data), c.n); got != c.want {
+			t.Errorf("preview %q:%q, want:%q", c.data, got, c.want)
+		}
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	data := []byte("0123456789abcdefGHIJ\x00")
+	want := "... 2 bytes before\n" +
+		"00000002  32 33 34 35 36 37 38 39  61 62 63 64 65 66 47 48  |23456789abcdefGH|\n" +
+		"00000012  49 4a                                             |IJ|\n" +
+		"... 1 bytes after\n"
+	if got := Hexdump(data, 2, 18); got != want {
+		t.Errorf("hexdump:\n%s\nwant:\n%s", got, want)
+	}
+}
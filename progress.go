@@ -0,0 +1,77 @@
+package readall
+
+import (
+	"io"
+	"time"
+)
+
+// DefaultProgressInterval is how often WithProgress reports by default.
+const DefaultProgressInterval = 100 * time.Millisecond
+
+// WithProgress calls fn with the bytes read so far and the total, taken from
+// the size hint (Stat, Content-Length, ...) or -1 when unknown. fn runs at
+// most once per progress interval (see WithProgressInterval) and once more
+// when the read ends, on the goroutine doing the reads.
+func WithProgress(fn func(read, total int64)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithProgressInterval sets how often WithProgress reports; zero reports
+// after every Read.
+func WithProgressInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.progressEvery = d
+		o.progressSet = true
+	}
+}
+
+// progressReader reports the progress of reading r.
+type progressReader struct {
+	r     io.Reader
+	fn    func(read, total int64)
+	total int64
+	every time.Duration
+	n     int64
+	last  time.Time
+	ended bool
+}
+
+// withProgress wraps r if o asks for progress reports.
+func (o *options) withProgress(r io.Reader) io.Reader {
+	if o.progress == nil {
+		return r
+	}
+	total := int64(-1)
+	if n, ok := sizeHint(r); ok {
+		total = n
+	}
+	every := DefaultProgressInterval
+	if o.progressSet {
+		every = o.progressEvery
+	}
+	return &progressReader{r: r, fn: o.progress, total: total, every: every, last: time.Now()}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	switch {
+	case err != nil:
+		if !p.ended {
+			p.ended = true
+			p.fn(p.n, p.total)
+		}
+	case n > 0:
+		if now := time.Now(); now.Sub(p.last) >= p.every {
+			p.last = now
+			p.fn(p.n, p.total)
+		}
+	}
+	return n, err
+}
+
+func (p *progressReader) Unwrap() io.Reader {
+	return p.r
+}
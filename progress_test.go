@@ -0,0 +1,38 @@
+package readall
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWithProgress(t *testing.T) {
+	data := strings.Repeat("p", 1000)
+	type report struct{ read, total int64 }
+	var got []report
+	progress := WithProgress(func(read, total int64) { got = append(got, report{read, total}) })
+
+	if _, err := ReadAll(iotest.HalfReader(strings.NewReader(data)), progress, WithProgressInterval(0)); err != nil {
+		t.Errorf("read err:%v", err)
+	}
+	if len(got) < 2 || got[len(got)-1] != (report{1000, -1}) {
+		t.Errorf("unsized reports:%v", got)
+	}
+
+	got = nil
+	if _, err := ReadAll(strings.NewReader(data), progress); err != nil {
+		t.Errorf("read err:%v", err)
+	}
+	if len(got) != 1 || got[0] != (report{1000, 1000}) {
+		t.Errorf("sized reports:%v", got)
+	}
+
+	got = nil
+	if _, err := From(BytesSource(data)).Options(progress).To(ioutil.Discard); err != nil {
+		t.Errorf("pipeline err:%v", err)
+	}
+	if len(got) != 1 || got[0] != (report{1000, 1000}) {
+		t.Errorf("pipeline reports:%v", got)
+	}
+}
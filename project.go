@@ -0,0 +1,84 @@
+package readall
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// Projector iterates over the rows of CSV or TSV data keeping only selected
+// columns, so wide files can be read holding just the fields that matter.
+//
+//	p := readall.NewProjector(f, '\t', 0, 3)
+//	for p.Next() {
+//		handle(p.Row())
+//	}
+//	if err := p.Err(); err != nil { ... }
+type Projector struct {
+	r    io.Reader
+	cr   *csv.Reader
+	cols []int
+	row  []string
+	err  error
+}
+
+// NewProjector returns a Projector over r keeping columns cols (0-based) in
+// the given order. comma is the field delimiter, ',' for CSV or '\t' for TSV.
+// Rows may have differing numbers of fields; a column a row lacks is empty.
+func NewProjector(r io.Reader, comma rune, cols ...int) *Projector {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &Projector{r: r, cr: cr, cols: cols, row: make([]string, len(cols))}
+}
+
+// Next advances to the next row, returning false at the end or on error.
+func (p *Projector) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	rec, err := p.cr.Read()
+	if err != nil {
+		p.err = err
+		return false
+	}
+	for i, c := range p.cols {
+		p.row[i] = ""
+		if c >= 0 && c < len(rec) {
+			p.row[i] = rec[c]
+		}
+	}
+	return true
+}
+
+// Row returns the selected fields of the current row. The slice is reused
+// by Next.
+func (p *Projector) Row() []string {
+	return p.row
+}
+
+// Err returns the first error other than the end of the data.
+func (p *Projector) Err() error {
+	if p.err == io.EOF {
+		return nil
+	}
+	return withSource(sourceName(p.r), p.err)
+}
+
+// ReadAllProject reads delimited data from r and returns only columns cols
+// of every row, encoded again with delimiter comma.
+func ReadAllProject(r io.Reader, comma rune, cols ...int) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	p := NewProjector(r, comma, cols...)
+	for p.Next() {
+		w.Write(p.Row())
+	}
+	w.Flush()
+	if err := p.Err(); err != nil {
+		return buf.Bytes(), err
+	}
+	return buf.Bytes(), w.Error()
+}
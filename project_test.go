@@ -0,0 +1,30 @@
+package readall
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProjector(t *testing.T) {
+	in := "id,name,blob,score\n1,alice,\"x,y\",9\n2,bob\n"
+	p := NewProjector(strings.NewReader(in), ',', 3, 1)
+	var rows []string
+	for p.Next() {
+		rows = append(rows, strings.Join(p.Row(), "|"))
+	}
+	if p.Err() != nil || strings.Join(rows, " ") != "score|name 9|alice |bob" {
+		t.Errorf("rows:%q, err:%v", rows, p.Err())
+	}
+
+	got, err := ReadAllProject(strings.NewReader("a\tb\tc\n1\t2\t3\n"), '\t', 2, 0)
+	if err != nil || string(got) != "c\ta\n3\t1\n" {
+		t.Errorf("project:%q, err:%v", got, err)
+	}
+	_, err = ReadAllProject(strings.NewReader("a,\"b\n"), ',', 0)
+	var pe *csv.ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("malformed err:%v", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package promexp exports the read statistics of package readall, per
+// WithLabel label, in the Prometheus text exposition format. It only
+// depends on the standard library, so services need no Prometheus client
+// to be scraped:
+//
+//	e := promexp.Register()
+//	http.Handle("/metrics/readall", e)
+package promexp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"readall"
+)
+
+// MaxLabels bounds how many distinct labels an Exporter keeps; reads with
+// further labels are counted under OtherLabel.
+const MaxLabels = 256
+
+// OtherLabel collects the reads whose label did not fit under MaxLabels.
+const OtherLabel = "other"
+
+// Bucket upper bounds of the histograms.
+var (
+	DurationBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5, 10}
+	BytesBuckets    = []float64{1 << 8, 1 << 10, 1 << 12, 1 << 14, 1 << 16, 1 << 18, 1 << 20, 1 << 22, 1 << 24, 1 << 26, 1 << 28, 1 << 30}
+	GrowsBuckets    = []float64{0, 1, 2, 4, 8, 16, 32}
+)
+
+// Exporter is a readall.Collector keeping histograms of read latency, bytes
+// read and buffer grows, and counters of Pool hits and misses, per label. It
+// serves them over HTTP and is safe for concurrent use.
+type Exporter struct {
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	duration, bytes, grows histogram
+	hits, misses           int64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(bounds []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+	if i := sort.SearchFloat64s(bounds, v); i < len(bounds) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// New returns an empty Exporter.
+func New() *Exporter {
+	return &Exporter{series: make(map[string]*series)}
+}
+
+// Register returns a new Exporter installed with readall.SetCollector.
+func Register() *Exporter {
+	e := New()
+	readall.SetCollector(e)
+	return e
+}
+
+// CollectRead implements readall.Collector.
+func (e *Exporter) CollectRead(label string, s readall.Stats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ss, ok := e.series[label]
+	if !ok {
+		if len(e.series) >= MaxLabels {
+			label = OtherLabel
+		}
+		if ss, ok = e.series[label]; !ok {
+			ss = new(series)
+			e.series[label] = ss
+		}
+	}
+	ss.duration.observe(DurationBuckets, s.Duration.Seconds())
+	ss.bytes.observe(BytesBuckets, float64(s.Bytes))
+	ss.grows.observe(GrowsBuckets, float64(s.Grows))
+	ss.hits += s.PoolHits
+	ss.misses += s.PoolMisses
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.Lock()
+	labels := make([]string, 0, len(e.series))
+	for label := range e.series {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	snap := make([]series, len(labels))
+	for i, label := range labels {
+		snap[i] = copySeries(e.series[label])
+	}
+	e.mu.Unlock()
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	hists := []struct {
+		name, help string
+		bounds     []float64
+		get        func(*series) *histogram
+	}{
+		{"readall_read_duration_seconds", "Wall time of reads.", DurationBuckets, func(s *series) *histogram { return &s.duration }},
+		{"readall_read_bytes", "Bytes returned by reads.", BytesBuckets, func(s *series) *histogram { return &s.bytes }},
+		{"readall_read_grows", "Buffer reallocations per read.", GrowsBuckets, func(s *series) *histogram { return &s.grows }},
+	}
+	for _, h := range hists {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+		for i, label := range labels {
+			writeHistogram(bw, h.name, label, h.bounds, h.get(&snap[i]))
+		}
+	}
+	counters := []struct {
+		name, help string
+		get        func(*series) int64
+	}{
+		{"readall_pool_hits_total", "Pool buffers reused.", func(s *series) int64 { return s.hits }},
+		{"readall_pool_misses_total", "Pool buffers allocated.", func(s *series) int64 { return s.misses }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for i, label := range labels {
+			fmt.Fprintf(bw, "%s{label=%s} %d\n", c.name, quote(label), c.get(&snap[i]))
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func copySeries(s *series) series {
+	c := *s
+	for _, h := range []*histogram{&c.duration, &c.bytes, &c.grows} {
+		h.counts = append([]uint64(nil), h.counts...)
+	}
+	return c
+}
+
+func writeHistogram(w io.Writer, name, label string, bounds []float64, h *histogram) {
+	l := quote(label)
+	var cum uint64
+	for i, b := range bounds {
+		if h.counts != nil {
+			cum += h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{label=%s,le=\"%s\"} %d\n", name, l, formatFloat(b), cum)
+	}
+	fmt.Fprintf(w, "%s_bucket{label=%s,le=\"+Inf\"} %d\n", name, l, h.count)
+	fmt.Fprintf(w, "%s_sum{label=%s} %s\n", name, l, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{label=%s} %d\n", name, l, h.count)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(label string) string {
+	return `"` + labelEscaper.Replace(label) + `"`
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
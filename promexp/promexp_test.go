@@ -0,0 +1,58 @@
+package promexp
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"readall"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExporter(t *testing.T) {
+	e := New()
+	e.CollectRead("api", readall.Stats{Bytes: 300, Grows: 1, Duration: 2 * time.Millisecond, PoolHits: 2, PoolMisses: 1})
+	e.CollectRead("api", readall.Stats{Bytes: 5000, Duration: time.Second})
+	e.CollectRead(`we"ird`, readall.Stats{Bytes: 1})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE readall_read_bytes histogram\n",
+		`readall_read_bytes_bucket{label="api",le="256"} 0`,
+		`readall_read_bytes_bucket{label="api",le="1024"} 1`,
+		`readall_read_bytes_bucket{label="api",le="+Inf"} 2`,
+		`readall_read_bytes_sum{label="api"} 5300`,
+		`readall_read_duration_seconds_bucket{label="api",le="0.005"} 1`,
+		`readall_read_grows_bucket{label="api",le="0"} 1`,
+		`readall_pool_hits_total{label="api"} 2`,
+		`readall_pool_misses_total{label="api"} 1`,
+		`readall_read_bytes_count{label="we\"ird"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%v", want, out)
+		}
+	}
+}
+
+func TestExporterLabelCap(t *testing.T) {
+	e := New()
+	for i := 0; i < MaxLabels+10; i++ {
+		e.CollectRead(strconv.Itoa(i), readall.Stats{})
+	}
+	if len(e.series) != MaxLabels+1 || e.series[OtherLabel].bytes.count != 10 {
+		t.Errorf("series:%v, other:%v", len(e.series), e.series[OtherLabel])
+	}
+}
+
+func TestRegister(t *testing.T) {
+	e := Register()
+	defer readall.SetCollector(nil)
+	readall.ReadAll(bytes.NewReader([]byte("payload")), readall.WithLabel("reg"))
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil || !strings.Contains(buf.String(), `readall_read_bytes_sum{label="reg"} 7`) {
+		t.Errorf("write err:%v, out:\n%v", err, buf.String())
+	}
+}
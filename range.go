@@ -0,0 +1,107 @@
+package readall
+
+import (
+	"errors"
+	"io"
+)
+
+// ReadRangeFrom skips the first off bytes of r and returns the following n
+// bytes. Seekable readers skip with Seek; others are discarded through a pooled
+// scratch buffer. If r ends early, the bytes read are returned with
+// io.ErrUnexpectedEOF. A negative off fails with ErrNegativeOffset and a
+// negative n with ErrNegativeLength.
+func ReadRangeFrom(r io.Reader, off, n int64) (_ []byte, err error) {
+	defer annotate(&err, r)
+	if off < 0 {
+		return nil, ErrNegativeOffset
+	}
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	if err := discard(r, off); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	buf := make([]byte, n)
+	m, err := io.ReadFull(r, buf)
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf[:m], err
+}
+
+// ErrNegativeLength is returned by ReadRangeFrom, ReadExactly, ReadAt and
+// ReadHeader for a negative n.
+var ErrNegativeLength = errors.New("readall: negative length")
+
+// ReadExactly reads exactly n bytes of r into a buffer allocated at exactly
+// that size, for the length-prefixed frames of binary protocols. If r ends
+// early, the bytes read are returned with io.ErrUnexpectedEOF.
+func ReadExactly(r io.Reader, n int64) (_ []byte, err error) {
+	defer annotate(&err, r)
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	buf := make([]byte, n)
+	m, err := io.ReadFull(r, buf)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf[:m], err
+}
+
+// ReadAt reads the n bytes of r at offset off into a buffer of exactly n
+// bytes. If r ends inside the window, the bytes read are returned with
+// io.ErrUnexpectedEOF.
+func ReadAt(r io.ReaderAt, off, n int64) (_ []byte, err error) {
+	defer annotate(&err, r)
+	if n < 0 {
+		return nil, ErrNegativeLength
+	}
+	buf := make([]byte, n)
+	m, err := r.ReadAt(buf, off)
+	if m == len(buf) {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf[:m], err
+}
+
+// discard consumes exactly n bytes of r, returning io.EOF if r ends first.
+func discard(r io.Reader, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if s, ok := r.(io.Seeker); ok {
+		if cur, err := s.Seek(0, io.SeekCurrent); err == nil {
+			if end, err := s.Seek(0, io.SeekEnd); err == nil {
+				if end-cur < n {
+					return io.EOF
+				}
+				_, err = s.Seek(cur+n, io.SeekStart)
+				return err
+			}
+		}
+	}
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	buf := *bp
+	for n > 0 {
+		if int64(len(buf)) > n {
+			buf = buf[:n]
+		}
+		m, err := r.Read(buf)
+		n -= int64(m)
+		if err != nil {
+			if err == io.EOF && n == 0 {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadRangeFrom(t *testing.T) {
+	data := strings.Repeat("0123456789", 10000)
+	readers := map[string]io.Reader{
+		"seeker": strings.NewReader(data),
+		"stream": struct{ io.Reader }{strings.NewReader(data)},
+	}
+	for name, r := range readers {
+		got, err := ReadRangeFrom(r, 50003, 5)
+		if err != nil || string(got) != "34567" {
+			t.Errorf("%v range:%q, err:%v", name, got, err)
+		}
+	}
+}
+
+func TestReadRangeFromShort(t *testing.T) {
+	got, err := ReadRangeFrom(struct{ io.Reader }{bytes.NewReader([]byte("abcdef"))}, 4, 10)
+	if err != io.ErrUnexpectedEOF || string(got) != "ef" {
+		t.Errorf("range:%q, err:%v", got, err)
+	}
+	if _, err := ReadRangeFrom(strings.NewReader("abc"), 10, 1); err != io.ErrUnexpectedEOF {
+		t.Errorf("skip past end err:%v", err)
+	}
+}
+
+func TestReadRangeFromNegative(t *testing.T) {
+	if _, err := ReadRangeFrom(strings.NewReader("abc"), 1, -1); !errors.Is(err, ErrNegativeLength) {
+		t.Errorf("negative err:%v, want:%v", err, ErrNegativeLength)
+	}
+	if _, err := ReadRangeFrom(strings.NewReader("abc"), -1, 1); !errors.Is(err, ErrNegativeOffset) {
+		t.Errorf("negative offset err:%v, want:%v", err, ErrNegativeOffset)
+	}
+}
+
+func TestReadExactly(t *testing.T) {
+	r := bytes.NewReader([]byte("0123456789"))
+	b, err := ReadExactly(r, 4)
+	if err != nil || string(b) != "0123" || cap(b) != 4 {
+		t.Errorf("read:%q, cap:%v, err:%v", b, cap(b), err)
+	}
+	if b, err = ReadExactly(r, 10); !errors.Is(err, io.ErrUnexpectedEOF) || string(b) != "456789" {
+		t.Errorf("short read:%q, err:%v", b, err)
+	}
+	if _, err = ReadExactly(r, 1); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("read at EOF err:%v", err)
+	}
+	if _, err = ReadExactly(r, -1); !errors.Is(err, ErrNegativeLength) {
+		t.Errorf("negative err:%v", err)
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	r := bytes.NewReader([]byte("0123456789"))
+	if b, err := ReadAt(r, 2, 3); err != nil || string(b) != "234" {
+		t.Errorf("read:%q, err:%v", b, err)
+	}
+	if b, err := ReadAt(r, 10, 0); err != nil || len(b) != 0 {
+		t.Errorf("empty read:%q, err:%v", b, err)
+	}
+	if b, err := ReadAt(r, 8, 5); !errors.Is(err, io.ErrUnexpectedEOF) || string(b) != "89" {
+		t.Errorf("short read:%q, err:%v", b, err)
+	}
+}
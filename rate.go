@@ -0,0 +1,160 @@
+package readall
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter paces reads. WaitN blocks until n more bytes may be read or ctx is
+// done. *rate.Limiter from golang.org/x/time/rate is a Limiter, as is
+// RateLimiter.
+type Limiter interface {
+	WaitN(ctx context.Context, n int) error
+}
+
+// rateSmoothSteps is how many pieces WithRateSmoothing splits a burst into.
+const rateSmoothSteps = 10
+
+// RateLimiter is a token bucket refilled at a fixed number of bytes per
+// second, holding at most its burst. One RateLimiter can be shared by many
+// reads to cap their combined bandwidth; it is safe for concurrent use.
+type RateLimiter struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSec bytes per second
+// with bursts of up to burst bytes; a burst that is not positive allows a
+// tenth of a second's worth. A bytesPerSec that is not positive means no
+// limit: WaitN never waits and Burst reports 0.
+func NewRateLimiter(bytesPerSec int64, burst int) *RateLimiter {
+	if bytesPerSec <= 0 {
+		return &RateLimiter{}
+	}
+	if burst <= 0 {
+		burst = int(bytesPerSec / 10)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: float64(bytesPerSec), burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// Burst returns the largest number of bytes that can be read at once.
+func (l *RateLimiter) Burst() int {
+	return l.burst
+}
+
+// WaitN takes n bytes worth of tokens, waiting for the bucket to refill. A
+// request larger than the burst is allowed and waits accordingly.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if max := float64(l.burst); l.tokens > max {
+		l.tokens = max
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+	if debt >= 0 {
+		return nil
+	}
+	t := time.NewTimer(time.Duration(-debt / l.rate * float64(time.Second)))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctxErr(ctx)
+	}
+}
+
+// WithRateLimit caps the read at bytesPerSec bytes per second with a
+// RateLimiter of its own; a bytesPerSec that is not positive disables rate
+// limiting. Use WithRateLimiter to share a budget between reads.
+func WithRateLimit(bytesPerSec int64) Option {
+	return func(o *options) {
+		if bytesPerSec <= 0 {
+			o.rate = nil
+			return
+		}
+		o.rate = NewRateLimiter(bytesPerSec, 0)
+	}
+}
+
+// WithRateLimiter paces the read with l. When l reports its burst, as
+// RateLimiter and *rate.Limiter do, no single Read asks for more.
+func WithRateLimiter(l Limiter) Option {
+	return func(o *options) {
+		o.rate = l
+	}
+}
+
+// WithRateSmoothing makes rate-limited reads take their tokens in pieces of
+// a tenth of the burst, spreading a large read across the interval instead
+// of reading a whole burst at once and then pausing, which saw-tooths the
+// network utilization.
+func WithRateSmoothing() Option {
+	return func(o *options) {
+		o.rateSmooth = true
+	}
+}
+
+// withRate wraps r if o sets a rate limit.
+func (o *options) withRate(r io.Reader) io.Reader {
+	if o.rate == nil {
+		return r
+	}
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	max := 0
+	if b, ok := o.rate.(interface{ Burst() int }); ok {
+		max = b.Burst()
+		if o.rateSmooth && max >= rateSmoothSteps {
+			max /= rateSmoothSteps
+		}
+	}
+	return &rateReader{r: r, l: o.rate, ctx: ctx, max: max}
+}
+
+// rateReader reads from r at the pace of l, at most max bytes at a time
+// when max is positive.
+type rateReader struct {
+	r   io.Reader
+	l   Limiter
+	ctx context.Context
+	max int
+}
+
+func (r *rateReader) Read(p []byte) (int, error) {
+	if r.max > 0 && len(p) > r.max {
+		p = p[:r.max]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (r *rateReader) Unwrap() io.Reader {
+	return r.r
+}
@@ -0,0 +1,88 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type recordLimiter struct {
+	burst int
+	waits []int
+}
+
+func (l *recordLimiter) WaitN(ctx context.Context, n int) error {
+	l.waits = append(l.waits, n)
+	return nil
+}
+
+func (l *recordLimiter) Burst() int {
+	return l.burst
+}
+
+func TestWithRateLimiter(t *testing.T) {
+	data := make([]byte, 1000)
+	for _, smooth := range []bool{false, true} {
+		l := &recordLimiter{burst: 100}
+		opts := []Option{WithRateLimiter(l)}
+		want := 100
+		if smooth {
+			opts, want = append(opts, WithRateSmoothing()), 10
+		}
+		got, err := ReadAll(bytes.NewReader(data), opts...)
+		if err != nil || len(got) != len(data) {
+			t.Fatalf("smooth %v len:%v, err:%v", smooth, len(got), err)
+		}
+		for _, n := range l.waits {
+			if n > want {
+				t.Errorf("smooth %v waited for %v bytes, want at most %v", smooth, n, want)
+			}
+		}
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	data := make([]byte, 30<<10)
+	start := time.Now()
+	// 100 KiB/s with a 10 KiB burst: the last 20 KiB take about 200ms.
+	got, err := ReadAll(bytes.NewReader(data), WithRateLimit(100<<10))
+	elapsed := time.Since(start)
+	if err != nil || len(got) != len(data) {
+		t.Fatalf("len:%v, err:%v", len(got), err)
+	}
+	if elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("elapsed:%v, want about 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := ReadAllContext(ctx, bytes.NewReader(data), WithRateLimit(1<<10)); err != context.DeadlineExceeded {
+		t.Errorf("context err:%v, want:%v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimitUnlimited(t *testing.T) {
+	data := make([]byte, 1<<20)
+	for _, rate := range []int64{0, -1} {
+		done := make(chan error, 2)
+		go func() {
+			_, err := ReadAll(bytes.NewReader(data), WithRateLimit(rate))
+			done <- err
+		}()
+		go func() {
+			_, err := ReadAll(bytes.NewReader(data), WithRateLimiter(NewRateLimiter(rate, 0)))
+			done <- err
+		}()
+		for i := 0; i < 2; i++ {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("rate %v err:%v", rate, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("rate %v read hung", rate)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"readall/internal/core"
+)
+
+// maxInt is the largest length a slice can have.
+const maxInt = int64(^uint(0) >> 1)
+
+// ReadAll reads r until EOF and returns the data, like ioutil.ReadAll. When r
+// reveals how much it holds (see below) the result is allocated once at its
+// final size instead of growing through repeated doubling, which for large
+// files avoids most of the copying and roughly halves peak memory.
+//
+// The size is taken from a Len method (bytes.Reader, strings.Reader,
+// bytes.Buffer), Stat on a regular *os.File, a Size method, or Seek on an
+// io.Seeker. A wrong hint only costs extra allocations; the data is always
+// read to EOF.
+//
+// The ReadAll variants accept the growth options (WithInitialCapacity,
+// WithGrowthFactor, WithMaxCapacity, WithChunkSize), WithSizer, WithLimit
+// and WithLabel.
+func ReadAll(r io.Reader, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts)
+	return readAllCap(r, o.capacity(r), o)
+}
+
+// readAllSize returns the initial buffer size for reading all of r.
+func readAllSize(r io.Reader) int64 {
+	if n, ok := sizeHint(r); ok && n < maxInt {
+		// One spare byte lets the final Read report EOF without growing.
+		return n + 1
+	}
+	return bytes.MinRead
+}
+
+func readOptions(opts []Option) *options {
+	o := new(options)
+	o.apply(opts)
+	return o
+}
+
+// readLimit returns the byte limit of a ReadAll variant, or 0 for none.
+func (o *options) readLimit() int64 {
+	n := o.limit
+	if m := o.growth.max; m > 0 && (n <= 0 || m < n) {
+		n = m
+	}
+	return n
+}
+
+// capacity returns the initial buffer size for reading all of r.
+func (o *options) capacity(r io.Reader) int64 {
+	size, ok := o.learnedCapacity(r)
+	if !ok {
+		size = o.growth.capacity(r, readAllSize(r))
+	}
+	if n := o.readLimit(); n > 0 && size-1 > n {
+		size = n + 1
+	}
+	if lr, ok := r.(*io.LimitedReader); ok && lr.N >= 0 && size-1 > lr.N {
+		size = lr.N + 1
+	}
+	return size
+}
+
+// readAllCap reads r to EOF into a buffer of initial capacity size,
+// following o, which may be nil.
+func readAllCap(r io.Reader, size int64, o *options) ([]byte, error) {
+	if o == nil {
+		o = new(options)
+	}
+	u := o.useBudget(context.Background())
+	defer u.release()
+	if err := u.grow(size); err != nil {
+		return nil, err
+	}
+	st := o.startStats()
+	defer st.finish()
+	st.alloc(size)
+	b, err := appendAll(make([]byte, 0, size), r, o, u, st)
+	if err == ErrReadKilled || err == ErrShutdown {
+		return nil, err
+	}
+	return b, o.partial(b, err)
+}
+
+// appendAll appends r to dst with the limit, growth policy and budget use u
+// of o, and tracks and records the read, in st too.
+func appendAll(dst []byte, r io.Reader, o *options, u *budgetUse, st *readStats) ([]byte, error) {
+	t := o.track(r)
+	defer t.done()
+	defer o.adviseSequential(r)()
+	defer o.watch(t)()
+	tr := o.readChain(r, t, dst, st)
+	start := len(dst)
+	var err error
+	if o.growth == (growth{}) && u == nil && st == nil {
+		dst, err = core.Append(dst, tr)
+	} else {
+		dst, err = o.growth.appendGrow(dst, tr, u, st)
+	}
+	if t.isKilled() {
+		return dst[:start], t.err()
+	}
+	observeSize(o.label, int64(len(dst)-start))
+	if err == nil {
+		o.learnSize(int64(len(dst) - start))
+	}
+	return dst, o.truncated(err, st)
+}
+
+// readChain wraps r, tracked as t, in the readers the options ask for, in
+// the order every read applies them. dst holds the data read before, which
+// WithIndex continues from.
+func (o *options) readChain(r io.Reader, t *trackedRead, dst []byte, st *readStats) io.Reader {
+	tr := o.withNewlines(trackedReader{o.withRate(o.withIdle(o.withProgress(o.withCoalesce(r)), r)), t})
+	if n := o.readLimit(); n > 0 {
+		tr = newLimitReader(tr, n)
+	}
+	return st.reader(o.withHash(o.withIndex(o.withSoftLimit(tr), dst)))
+}
+
+// AppendAll reads r until EOF and appends the data to dst, growing dst once
+// to the size r reveals, if any, like ReadAll.
+func AppendAll(dst []byte, r io.Reader, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts)
+	u := o.useBudget(context.Background())
+	defer u.release()
+	st := o.startStats()
+	defer st.finish()
+	if _, ok := sizeHint(r); ok || o.growth.initial > 0 {
+		if n := o.capacity(r); n < maxInt-int64(len(dst)) {
+			if need := len(dst) + int(n); need > cap(dst) {
+				if err := u.grow(int64(need)); err != nil {
+					return dst, err
+				}
+				dst = append(make([]byte, 0, need), dst...)
+				st.alloc(int64(need))
+			}
+		}
+	}
+	start := len(dst)
+	dst, err = appendAll(dst, r, o, u, st)
+	return dst, o.partial(dst[start:], err)
+}
+
+// ReadAllInto reads r until EOF into buf, starting at buf[0], and returns the
+// data. It reuses the capacity of buf and only allocates when r holds more, so
+// a worker that passes back the returned slice on every call stops
+// allocating once the buffer has grown to its largest input. It is
+// AppendAll(buf[:0], r).
+func ReadAllInto(r io.Reader, buf []byte, opts ...Option) ([]byte, error) {
+	return AppendAll(buf[:0], r, opts...)
+}
+
+// ReadAllContext is ReadAll that stops when ctx is done, returning the data
+// read so far and ctx.Err(), joined with the cause of the cancellation if
+// there is one (see Reason). The reads run on a separate goroutine so that a
+// Read blocked on a slow peer does not hold up the caller; if r has a
+// SetReadDeadline method (net.Conn, pipes) its deadline is moved to the past
+// to unblock that Read, otherwise the goroutine exits once Read returns.
+func ReadAllContext(ctx context.Context, r io.Reader, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	o := readOptions(opts).withContext(ctx)
+	if ctx.Done() == nil {
+		return readAllCap(r, o.capacity(r), &o)
+	}
+	if ctx.Err() != nil {
+		return nil, ctxErr(ctx)
+	}
+	size := o.capacity(r)
+	u := o.useBudget(ctx)
+	defer u.release()
+	if err := u.grow(size); err != nil {
+		return nil, err
+	}
+	t := o.track(r)
+	defer t.done()
+	defer o.adviseSequential(r)()
+	defer o.watch(t)()
+	src := r
+	st := o.startStats()
+	defer st.finish()
+	r = o.readChain(r, t, nil, st)
+	type result struct {
+		n   int
+		err error
+	}
+	killed := t.stopped()
+	reqs := make(chan []byte)
+	results := make(chan result, 1)
+	defer close(reqs)
+	go func() {
+		for p := range reqs {
+			n, err := r.Read(p)
+			results <- result{n, err}
+		}
+	}()
+	b := make([]byte, 0, size)
+	st.alloc(size)
+	for {
+		if len(b) == cap(b) {
+			if b, err = o.growth.grow(b, 0, u, st); err != nil {
+				return b, o.partial(b, err)
+			}
+		}
+		p := b[len(b):cap(b)]
+		if o.growth.chunk > 0 && len(p) > o.growth.chunk {
+			p = p[:o.growth.chunk]
+		}
+		reqs <- p
+		select {
+		case res := <-results:
+			b = b[:len(b)+res.n]
+			if t.isKilled() {
+				return nil, t.err()
+			}
+			if res.err != nil {
+				observeSize(o.label, int64(len(b)))
+				if res.err == io.EOF {
+					o.learnSize(int64(len(b)))
+					return b, nil
+				}
+				return b, o.partial(b, o.truncated(res.err, st))
+			}
+		case <-ctx.Done():
+			if d, ok := src.(interface{ SetReadDeadline(time.Time) error }); ok {
+				d.SetReadDeadline(time.Unix(1, 0))
+			}
+			// The pending Read may still write past len(b); clip the
+			// capacity so appends by the caller cannot race with it.
+			return b[:len(b):len(b)], o.partial(b[:len(b):len(b)], ctxErr(ctx))
+		case <-killed:
+			return nil, t.err()
+		}
+	}
+}
+
+// ReadAllLimit is ReadAll that fails once r yields more than max bytes,
+// instead of truncating silently like io.LimitReader. The first max bytes are
+// returned with a *LimitError matching ErrLimitExceeded. Preallocation from
+// the size hint is capped at max, so a reader claiming a huge size cannot
+// force a huge allocation.
+func ReadAllLimit(r io.Reader, max int64, opts ...Option) (_ []byte, err error) {
+	defer annotate(&err, r)
+	if max < 0 {
+		max = 0
+	}
+	o := readOptions(opts)
+	size := o.capacity(r)
+	if max < maxInt && size > max+1 {
+		size = max + 1
+	}
+	return readAllCap(newLimitReader(r, max), size, o)
+}
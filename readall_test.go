@@ -0,0 +1,174 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestReadAllSizeHint(t *testing.T) {
+	data := bytes.Repeat([]byte("readall"), 10000)
+	path := filepath.Join(t.TempDir(), "data")
+	ioutil.WriteFile(path, data, 0644)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Errorf("open err:%v", err)
+		return
+	}
+	defer f.Close()
+	f.Seek(7, io.SeekStart)
+	got, err := ReadAll(f)
+	if err != nil || !bytes.Equal(got, data[7:]) {
+		t.Errorf("file len:%v, err:%v", len(got), err)
+	}
+	if cap(got) != len(got)+1 {
+		t.Errorf("file cap:%v, len:%v", cap(got), len(got))
+	}
+
+	got, err = ReadAll(bytes.NewReader(data))
+	if err != nil || !bytes.Equal(got, data) || cap(got) != len(data)+1 {
+		t.Errorf("bytes len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+	got, err = ReadAll(iotest.HalfReader(strings.NewReader(string(data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("unsized len:%v, err:%v", len(got), err)
+	}
+	got, err = ReadAll(iotest.DataErrReader(strings.NewReader("")))
+	if err != nil || len(got) != 0 {
+		t.Errorf("empty len:%v, err:%v", len(got), err)
+	}
+	_, err = ReadAll(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("ab"))))
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("read err:%v", err)
+	}
+}
+
+func BenchmarkReadAllSizeHint(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(testName)
+		if err != nil {
+			b.Skipf("open err:%v", err)
+		}
+		ReadAll(f)
+		f.Close()
+	}
+}
+
+func BenchmarkReadAllSmall(b *testing.B) {
+	data := make([]byte, 200)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ReadAll(bytes.NewReader(data))
+	}
+}
+
+func TestAppendAll(t *testing.T) {
+	dst := append(make([]byte, 0, 4), "pre:"...)
+	got, err := AppendAll(dst, strings.NewReader("some data"))
+	if err != nil || string(got) != "pre:some data" || cap(got) != len(got)+1 {
+		t.Errorf("append:%q, cap:%v, err:%v", got, cap(got), err)
+	}
+	if string(dst) != "pre:" {
+		t.Errorf("dst changed:%q", dst)
+	}
+}
+
+func TestReadAllInto(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	got, err := ReadAllInto(strings.NewReader("first"), buf)
+	if err != nil || string(got) != "first" || &got[:1][0] != &buf[:1][0] {
+		t.Errorf("first:%q, err:%v, reused:%v", got, err, &got[:1][0] == &buf[:1][0])
+	}
+	got, err = ReadAllInto(strings.NewReader("two"), got)
+	if err != nil || string(got) != "two" || cap(got) != 64 {
+		t.Errorf("second:%q, cap:%v, err:%v", got, cap(got), err)
+	}
+	if got, err = ReadAllInto(strings.NewReader(strings.Repeat("x", 100)), got); err != nil || len(got) != 100 {
+		t.Errorf("grown len:%v, err:%v", len(got), err)
+	}
+}
+
+// blockingReader yields data once and then blocks until closed.
+type blockingReader struct {
+	data    []byte
+	release chan struct{}
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	if len(b.data) > 0 {
+		n := copy(p, b.data)
+		b.data = b.data[n:]
+		return n, nil
+	}
+	<-b.release
+	return 0, io.EOF
+}
+
+func TestReadAllContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := &blockingReader{data: []byte("partial"), release: make(chan struct{})}
+	defer close(r.release)
+	got, err := ReadAllContext(ctx, r)
+	if !errors.Is(err, context.DeadlineExceeded) || string(got) != "partial" || cap(got) != len(got) {
+		t.Errorf("read:%q, err:%v", got, err)
+	}
+
+	client, server := net.Pipe()
+	go server.Write([]byte("some"))
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel2)
+	got, err = ReadAllContext(ctx2, client)
+	if !errors.Is(err, context.Canceled) || string(got) != "some" {
+		t.Errorf("conn read:%q, err:%v", got, err)
+	}
+	client.Close()
+	server.Close()
+
+	got, err = ReadAllContext(context.Background(), strings.NewReader("all"))
+	if err != nil || string(got) != "all" {
+		t.Errorf("background read:%q, err:%v", got, err)
+	}
+	got, err = ReadAllContext(ctx, strings.NewReader("late"))
+	if err == nil || got != nil {
+		t.Errorf("expired read:%q, err:%v", got, err)
+	}
+}
+
+func TestReadAllLimit(t *testing.T) {
+	got, err := ReadAllLimit(strings.NewReader("0123456789"), 10)
+	if err != nil || string(got) != "0123456789" {
+		t.Errorf("at limit:%q, err:%v", got, err)
+	}
+	got, err = ReadAllLimit(iotest.HalfReader(strings.NewReader("0123456789")), 4)
+	var le *LimitError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &le) || le.Limit != 4 || le.Read != 5 {
+		t.Errorf("over limit err:%v", err)
+	}
+	if string(got) != "0123" {
+		t.Errorf("over limit data:%q", got)
+	}
+	got, _ = ReadAllLimit(bytes.NewReader(make([]byte, 1<<20)), 100)
+	if cap(got) > 1000 {
+		t.Errorf("prealloc cap:%v", cap(got))
+	}
+	for name, read := range map[string]func() ([]byte, error){
+		"ReadAllLimit": func() ([]byte, error) { return ReadAllLimit(strings.NewReader("hello"), math.MaxInt64) },
+		"WithLimit":    func() ([]byte, error) { return ReadAll(strings.NewReader("hello"), WithLimit(math.MaxInt64)) },
+		"Pipeline":     func() ([]byte, error) { return From(BytesSource("hello")).Limit(math.MaxInt64).Bytes() },
+	} {
+		if got, err := read(); err != nil || string(got) != "hello" {
+			t.Errorf("%v max limit:%q, err:%v", name, got, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package readalltest
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrInjected is a ready-made error for Chaos.Fail.
+var ErrInjected = errors.New("readalltest: injected fault")
+
+// Chaos describes faults to inject into a reader. The zero Chaos passes
+// reads through unchanged; every random choice comes from Seed, so a
+// failing test replays exactly as long as the code under test issues the
+// same Reads.
+type Chaos struct {
+	Seed int64
+
+	// ShortReads makes every Read return between 1 and len(p) bytes.
+	ShortReads bool
+	// MaxLatency delays every Read by a random duration up to MaxLatency.
+	MaxLatency time.Duration
+	// Fail, if set, is returned by every Read once FailAfter bytes have
+	// been delivered.
+	FailAfter int64
+	Fail      error
+	// StallAfter, with Stall set, is the number of bytes delivered before
+	// one Read blocks for Stall. A negative Stall blocks until Close or
+	// the read deadline.
+	StallAfter int64
+	Stall      time.Duration
+	// CorruptRate is the probability that a delivered byte has a random
+	// bit flipped.
+	CorruptRate float64
+}
+
+// Reader returns r with the faults of c injected. The reader has a
+// SetReadDeadline method ending stalls at the deadline with
+// os.ErrDeadlineExceeded, so idle timeouts can be tested against it, and a
+// Close method ending stalls and closing r if r is an io.Closer.
+func (c Chaos) Reader(r io.Reader) io.ReadCloser {
+	return &chaosReader{c: c, r: r, rnd: rand.New(rand.NewSource(c.Seed)), closed: make(chan struct{})}
+}
+
+type chaosReader struct {
+	c   Chaos
+	r   io.Reader
+	rnd *rand.Rand
+	n   int64
+
+	stalled bool
+	mu      sync.Mutex
+	dl      time.Time
+	dlSet   chan struct{} // closed when the deadline changes
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (r *chaosReader) Read(p []byte) (int, error) {
+	if r.c.MaxLatency > 0 {
+		time.Sleep(time.Duration(r.rnd.Int63n(int64(r.c.MaxLatency) + 1)))
+	}
+	if r.c.Fail != nil && r.n >= r.c.FailAfter {
+		return 0, r.c.Fail
+	}
+	if r.c.Stall != 0 && !r.stalled && r.n >= r.c.StallAfter {
+		r.stalled = true
+		if err := r.stall(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) == 0 {
+		return r.r.Read(p)
+	}
+	// Stop at the next fault so it hits at its exact offset.
+	if r.c.Fail != nil && int64(len(p)) > r.c.FailAfter-r.n {
+		p = p[:r.c.FailAfter-r.n]
+	}
+	if r.c.Stall != 0 && !r.stalled && int64(len(p)) > r.c.StallAfter-r.n {
+		p = p[:r.c.StallAfter-r.n]
+	}
+	if r.c.ShortReads && len(p) > 1 {
+		p = p[:1+r.rnd.Intn(len(p))]
+	}
+	n, err := r.r.Read(p)
+	if r.c.CorruptRate > 0 {
+		for i := 0; i < n; i++ {
+			if r.rnd.Float64() < r.c.CorruptRate {
+				p[i] ^= 1 << uint(r.rnd.Intn(8))
+			}
+		}
+	}
+	r.n += int64(n)
+	return n, err
+}
+
+// stall blocks for Stall, until Close or until the read deadline.
+func (r *chaosReader) stall() error {
+	var timeout <-chan time.Time
+	if r.c.Stall > 0 {
+		t := time.NewTimer(r.c.Stall)
+		defer t.Stop()
+		timeout = t.C
+	}
+	for {
+		r.mu.Lock()
+		dl, changed := r.dl, r.dlSet
+		if changed == nil {
+			changed = make(chan struct{})
+			r.dlSet = changed
+		}
+		r.mu.Unlock()
+		var expired <-chan time.Time
+		if !dl.IsZero() {
+			t := time.NewTimer(time.Until(dl))
+			defer t.Stop()
+			expired = t.C
+		}
+		select {
+		case <-timeout:
+			return nil
+		case <-expired:
+			return os.ErrDeadlineExceeded
+		case <-r.closed:
+			return os.ErrClosed
+		case <-changed:
+		}
+	}
+}
+
+func (r *chaosReader) SetReadDeadline(t time.Time) error {
+	r.mu.Lock()
+	r.dl = t
+	if r.dlSet != nil {
+		close(r.dlSet)
+		r.dlSet = nil
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *chaosReader) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.closed)
+		if c, ok := r.r.(io.Closer); ok {
+			err = c.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,75 @@
+package readalltest
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"readall"
+	"testing"
+	"time"
+)
+
+func TestChaosPassThrough(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	got, err := ioutil.ReadAll(Chaos{}.Reader(bytes.NewReader(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+	got, err = readall.ReadAll(Chaos{Seed: 7, ShortReads: true, MaxLatency: time.Microsecond}.Reader(bytes.NewReader(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("short reads len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestChaosFail(t *testing.T) {
+	data := make([]byte, 1000)
+	got, err := readall.ReadAll(Chaos{Seed: 1, ShortReads: true, FailAfter: 300, Fail: ErrInjected}.Reader(bytes.NewReader(data)))
+	if !errors.Is(err, ErrInjected) || len(got) != 300 {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestChaosCorruptDeterministic(t *testing.T) {
+	data := make([]byte, 10000)
+	c := Chaos{Seed: 42, CorruptRate: 0.01, ShortReads: true}
+	a, _ := ioutil.ReadAll(c.Reader(bytes.NewReader(data)))
+	b, _ := ioutil.ReadAll(c.Reader(bytes.NewReader(data)))
+	if !bytes.Equal(a, b) || bytes.Equal(a, data) {
+		t.Errorf("corruption not deterministic or missing")
+	}
+	var flipped int
+	for _, v := range a {
+		if v != 0 {
+			flipped++
+		}
+	}
+	if flipped < 50 || flipped > 200 {
+		t.Errorf("flipped:%v, want about 100", flipped)
+	}
+}
+
+func TestChaosStall(t *testing.T) {
+	data := make([]byte, 1000)
+	r := Chaos{StallAfter: 100, Stall: -1}.Reader(bytes.NewReader(data))
+	got, err := readall.ReadAll(r, readall.WithIdleTimeout(20*time.Millisecond))
+	if !errors.Is(err, readall.ErrStalled) || len(got) != 100 {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+
+	start := time.Now()
+	got, err = readall.ReadAll(Chaos{StallAfter: 10, Stall: 20 * time.Millisecond}.Reader(bytes.NewReader(data)))
+	if err != nil || len(got) != 1000 || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("timed stall len:%v, err:%v", len(got), err)
+	}
+
+	r = Chaos{Stall: -1}.Reader(bytes.NewReader(data))
+	done := make(chan error)
+	go func() {
+		_, err := r.Read(make([]byte, 10))
+		done <- err
+	}()
+	r.Close()
+	if err := <-done; err == nil {
+		t.Errorf("stall survived Close")
+	}
+}
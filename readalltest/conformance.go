@@ -0,0 +1,213 @@
+package readalltest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"readall"
+	"testing"
+	"testing/iotest"
+)
+
+// Backend is one way of reading a whole reader into memory, checked by
+// VerifyConformance.
+type Backend struct {
+	Name string
+	Read func(r io.Reader, opts ...readall.Option) ([]byte, error)
+}
+
+// Backends returns the reader-based backends of package readall, and one
+// reading through each registered strategy. Append a custom backend to
+// check it against them.
+func Backends() []Backend {
+	ctx := context.Background()
+	var pool readall.Pool
+	bs := []Backend{
+		{"ReadAll", readall.ReadAll},
+		{"ReadAllContext", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			return readall.ReadAllContext(ctx, r, opts...)
+		}},
+		{"ReadAllContextCancelable", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			return readall.ReadAllContext(ctx, r, opts...)
+		}},
+		{"AppendAll", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			b, err := readall.AppendAll([]byte("prefix"), r, opts...)
+			if len(b) < len("prefix") {
+				return nil, err
+			}
+			return b[len("prefix"):], err
+		}},
+		{"ReadAllInto", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			return readall.ReadAllInto(r, make([]byte, 100), opts...)
+		}},
+		{"ReadAllString", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			s, err := readall.ReadAllString(r, opts...)
+			return []byte(s), err
+		}},
+		{"Pool", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			b, release, err := pool.ReadAll(r, opts...)
+			defer release()
+			return append([]byte(nil), b...), err
+		}},
+		{"Copy", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			var buf bytes.Buffer
+			_, err := readall.Copy(&buf, r, opts...)
+			return buf.Bytes(), err
+		}},
+		{"Go", func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			return readall.Go(ctx, r, opts...).Bytes()
+		}},
+	}
+	for _, name := range readall.StrategyNames() {
+		s, _ := readall.LookupStrategy(name)
+		bs = append(bs, Backend{"strategy " + name, func(r io.Reader, opts ...readall.Option) ([]byte, error) {
+			res, err := s.ReadAll(ctx, onceSource{r}, opts...)
+			data := append([]byte(nil), res.Data...)
+			if res.Release != nil {
+				res.Release()
+			}
+			return data, err
+		}})
+	}
+	return bs
+}
+
+// onceSource is a Source opening a single reader.
+type onceSource struct {
+	r io.Reader
+}
+
+func (s onceSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(s.r), nil
+}
+
+func (s onceSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	return nil, errors.New("readalltest: source cannot be reopened")
+}
+
+func (s onceSource) Size(ctx context.Context) (int64, error) {
+	return -1, nil
+}
+
+// lenReader reports a Len that may be wrong.
+type lenReader struct {
+	io.Reader
+	n int
+}
+
+func (l lenReader) Len() int {
+	return l.n
+}
+
+// conformanceCase is one adversarial source and the outcome every backend
+// must agree on.
+type conformanceCase struct {
+	name string
+	open func() io.Reader
+	opts []readall.Option
+	want error // nil, or the error every backend must match
+}
+
+func conformanceCases(data []byte) []conformanceCase {
+	size := len(data)
+	cs := []conformanceCase{
+		{name: "sized", open: func() io.Reader { return bytes.NewReader(data) }},
+		{name: "unsized", open: func() io.Reader { return struct{ io.Reader }{bytes.NewReader(data)} }},
+		{name: "dataeof", open: func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) }},
+		{name: "halfread", open: func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) }},
+		{name: "overstated", open: func() io.Reader { return lenReader{struct{ io.Reader }{bytes.NewReader(data)}, 2*size + 100} }},
+		{name: "understated", open: func() io.Reader { return lenReader{struct{ io.Reader }{bytes.NewReader(data)}, size / 2} }},
+		{name: "failafter", open: func() io.Reader { return &failAfter{bytes.NewReader(data), errAfterData} }, want: errAfterData},
+	}
+	if size <= 4096 {
+		cs = append(cs, conformanceCase{name: "onebyte", open: func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) }})
+	}
+	if size > 0 {
+		cs = append(cs,
+			conformanceCase{name: "timeout", open: func() io.Reader { return iotest.TimeoutReader(bytes.NewReader(data)) }, want: iotest.ErrTimeout},
+			conformanceCase{name: "exactlimit", open: func() io.Reader { return bytes.NewReader(data) }, opts: []readall.Option{readall.WithLimit(int64(size))}},
+		)
+	}
+	// WithLimit(0) means unlimited, so only sizes above one can exceed.
+	if size > 1 {
+		for _, name := range []string{"overlimit", "overlimit unsized"} {
+			open := func() io.Reader { return bytes.NewReader(data) }
+			if name == "overlimit unsized" {
+				open = func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) }
+			}
+			cs = append(cs, conformanceCase{name: name, open: open, opts: []readall.Option{readall.WithLimit(int64(size - 1))}, want: readall.ErrLimitExceeded})
+		}
+	}
+	return cs
+}
+
+// VerifyConformance reads adversarial sources through every backend in bs,
+// or Backends() if bs is empty, and fails t unless all of them return
+// byte-identical data and errors matching the same sentinel. The sources
+// cover empty and power-of-two sized payloads, sized, unsized and wrongly
+// sized readers, one-byte and half reads, data returned with io.EOF,
+// errors after partial data, timeouts and WithLimit. The file backends
+// (ReadFile in every mmap mode and ParallelReadFile) read the same payloads
+// from temporary files.
+func VerifyConformance(t *testing.T, bs ...Backend) {
+	t.Helper()
+	if len(bs) == 0 {
+		bs = Backends()
+	}
+	rnd := rand.New(rand.NewSource(1))
+	dir := t.TempDir()
+	for _, size := range []int{0, 1, 511, 512, 513, 4096, 32<<10 + 1, 1 << 20} {
+		data := make([]byte, size)
+		rnd.Read(data)
+		for _, c := range conformanceCases(data) {
+			for _, b := range bs {
+				got, err := b.Read(c.open(), c.opts...)
+				checkConformance(t, fmt.Sprintf("%v size %v %v", b.Name, size, c.name), data, got, err, c.want)
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprint(size))
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write err:%v", err)
+		}
+		verifyFiles(t, path, data)
+	}
+}
+
+func verifyFiles(t *testing.T, path string, data []byte) {
+	t.Helper()
+	for _, mode := range []readall.MmapMode{readall.MmapAuto, readall.MmapAlways, readall.MmapNever} {
+		res, err := readall.ReadFile(path, readall.WithMmap(mode))
+		if mode == readall.MmapAlways && errors.Is(err, readall.ErrMmapUnsupported) {
+			continue
+		}
+		checkConformance(t, fmt.Sprintf("ReadFile mode %v size %v", mode, len(data)), data, res.Data, err, nil)
+		if res.Release != nil {
+			res.Release()
+		}
+	}
+	got, err := readall.ParallelReadFile(path, 4096, 4)
+	checkConformance(t, fmt.Sprintf("ParallelReadFile size %v", len(data)), data, got, err, nil)
+	if _, err := readall.ReadFile(path + ".missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadFile missing err:%v, want:%v", err, os.ErrNotExist)
+	}
+}
+
+func checkConformance(t *testing.T, name string, data, got []byte, err, want error) {
+	t.Helper()
+	switch {
+	case want != nil && !errors.Is(err, want):
+		t.Errorf("%v: err:%v, want:%v", name, err, want)
+	case want == nil && err != nil:
+		t.Errorf("%v: err:%v", name, err)
+	case want == nil && !bytes.Equal(got, data):
+		t.Errorf("%v: got %v bytes, data mismatch", name, len(got))
+	}
+}
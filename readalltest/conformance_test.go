@@ -0,0 +1,7 @@
+package readalltest
+
+import "testing"
+
+func TestVerifyConformance(t *testing.T) {
+	VerifyConformance(t)
+}
@@ -0,0 +1,72 @@
+// Package readalltest provides test helpers for code handling large byte
+// payloads.
+package readalltest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"readall"
+	"testing"
+)
+
+// UpdateEnv is the environment variable Update reads. Golden files are
+// switched on through the environment rather than a flag, since a test
+// package often defines its own -update flag and registering another one
+// here would make importing this package panic.
+const UpdateEnv = "READALLTEST_UPDATE"
+
+// Update reports whether UpdateEnv is set to a non-empty value, for passing
+// to AssertEqualsFile as its update argument.
+func Update() bool {
+	return os.Getenv(UpdateEnv) != ""
+}
+
+// diffWindow is the number of bytes shown on each side of the first difference.
+const diffWindow = 32
+
+// AssertEqualsFile fails t unless got equals the contents of goldenPath. With
+// update set, it writes got to goldenPath instead, creating directories as
+// needed. On mismatch it reports the first differing offset and a hexdump of
+// both sides around it rather than the whole payload.
+func AssertEqualsFile(t testing.TB, got []byte, goldenPath string, update bool) {
+	t.Helper()
+	if update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("mkdir err:%v", err)
+		}
+		if err := ioutil.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("write golden err:%v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("read golden err:%v (set "+UpdateEnv+"=1 to create it)", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	off := firstDiff(got, want)
+	start := off - diffWindow
+	if start < 0 {
+		start = 0
+	}
+	t.Errorf("%s: mismatch at offset %d (got %d bytes, want %d)\ngot:\n%swant:\n%s",
+		goldenPath, off, len(got), len(want),
+		readall.Hexdump(got, int64(start), 2*diffWindow), readall.Hexdump(want, int64(start), 2*diffWindow))
+}
+
+func firstDiff(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
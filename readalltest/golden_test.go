@@ -0,0 +1,49 @@
+package readalltest
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type recorder struct {
+	testing.TB
+	failed string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = format
+}
+
+func TestAssertEqualsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "out.golden")
+	got := []byte(strings.Repeat("golden ", 100))
+	AssertEqualsFile(t, got, path, true)
+	AssertEqualsFile(t, got, path, false)
+
+	rec := &recorder{TB: t}
+	changed := append([]byte(nil), got...)
+	changed[500] = 'X'
+	AssertEqualsFile(rec, changed, path, false)
+	if !strings.Contains(rec.failed, "mismatch at offset") {
+		t.Errorf("mismatch not reported, failed:%q", rec.failed)
+	}
+	if off := firstDiff(changed, got); off != 500 {
+		t.Errorf("first diff:%v, want:500", off)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	if flag.Lookup("update") != nil {
+		t.Errorf("update flag registered by the package")
+	}
+	t.Setenv(UpdateEnv, "")
+	if Update() {
+		t.Errorf("update without %v", UpdateEnv)
+	}
+	t.Setenv(UpdateEnv, "1")
+	if !Update() {
+		t.Errorf("no update with %v set", UpdateEnv)
+	}
+}
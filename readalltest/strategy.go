@@ -0,0 +1,103 @@
+package readalltest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"readall"
+	"testing"
+	"testing/iotest"
+)
+
+// errAfterData is returned by the failing sources of VerifyStrategy.
+var errAfterData = errors.New("readalltest: injected error after partial data")
+
+// readerSource is a Source handing out a fresh reader built by open.
+type readerSource struct {
+	open func() io.Reader
+}
+
+func (s readerSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(s.open()), nil
+}
+
+func (s readerSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	r := s.open()
+	if _, err := io.CopyN(ioutil.Discard, r, off); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+func (s readerSource) Size(ctx context.Context) (int64, error) {
+	return -1, nil
+}
+
+// failAfter returns data and then err instead of io.EOF.
+type failAfter struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failAfter) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		err = f.err
+	}
+	return n, err
+}
+
+// VerifyStrategy checks that s reads edge-case sources correctly: empty and
+// power-of-two sized payloads, sized and unsized sources, readers returning
+// one byte at a time or data together with io.EOF, errors after partial
+// data, and WithLimit.
+func VerifyStrategy(t *testing.T, s readall.Strategy) {
+	t.Helper()
+	ctx := context.Background()
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []int{0, 1, 511, 512, 513, 4096, 32 << 10, 1 << 20} {
+		data := make([]byte, size)
+		rnd.Read(data)
+		sources := map[string]readall.Source{
+			"bytes":    readall.BytesSource(data),
+			"unsized":  readerSource{func() io.Reader { return struct{ io.Reader }{bytes.NewReader(data)} }},
+			"dataeof":  readerSource{func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) }},
+			"halfread": readerSource{func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) }},
+		}
+		if size <= 4096 {
+			sources["onebyte"] = readerSource{func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) }}
+		}
+		for name, src := range sources {
+			res, err := s.ReadAll(ctx, src)
+			if err != nil {
+				t.Errorf("%v size %v: read err:%v", name, size, err)
+				continue
+			}
+			if !bytes.Equal(res.Data, data) {
+				t.Errorf("%v size %v: got %v bytes, data mismatch", name, size, len(res.Data))
+			}
+			if res.Release != nil {
+				res.Release()
+			}
+		}
+
+		failing := readerSource{func() io.Reader { return &failAfter{bytes.NewReader(data), errAfterData} }}
+		if _, err := s.ReadAll(ctx, failing); !errors.Is(err, errAfterData) {
+			t.Errorf("size %v: error after data:%v, want:%v", size, err, errAfterData)
+		}
+
+		// WithLimit(0) means unlimited, so start at two bytes.
+		if size > 1 {
+			limit := int64(size - 1)
+			if _, err := s.ReadAll(ctx, readall.BytesSource(data), readall.WithLimit(limit)); !errors.Is(err, readall.ErrLimitExceeded) {
+				t.Errorf("size %v: limit %v err:%v, want:%v", size, limit, err, readall.ErrLimitExceeded)
+			}
+			if _, err := s.ReadAll(ctx, readall.BytesSource(data), readall.WithLimit(int64(size))); err != nil {
+				t.Errorf("size %v: exact limit err:%v", size, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package readalltest
+
+import (
+	"readall"
+	"testing"
+)
+
+func TestVerifyDefaultStrategy(t *testing.T) {
+	VerifyStrategy(t, readall.DefaultStrategy)
+}
@@ -0,0 +1,111 @@
+package readall
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// Record is one record sent by RecordsChan, or the error that ended the
+// stream.
+type Record struct {
+	Data []byte
+	Err  error
+}
+
+// recordReader splits a stream into delimited records through a pooled
+// bufio.Reader, so only the current record is held in memory.
+type recordReader struct {
+	src   io.Reader
+	br    *bufio.Reader
+	delim byte
+	lines bool // also strip a "\r" before the "\n"
+	long  []byte
+	done  bool
+}
+
+func newRecordReader(r io.Reader, delim byte, lines bool) *recordReader {
+	br := bufioPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return &recordReader{src: r, br: br, delim: delim, lines: lines}
+}
+
+// next returns the next record without its delimiter, valid until the
+// following call. ok is false at the end of the stream; err is set, once,
+// if it ended with a read error.
+func (s *recordReader) next() (rec []byte, ok bool, err error) {
+	for !s.done {
+		line, err := s.br.ReadSlice(s.delim)
+		if err == bufio.ErrBufferFull {
+			// A record longer than the buffer is gathered in long.
+			s.long = append(s.long, line...)
+			continue
+		}
+		if len(s.long) > 0 {
+			s.long = append(s.long, line...)
+			line, s.long = s.long, s.long[:0]
+		}
+		if err != nil {
+			s.done = true
+			if err != io.EOF {
+				return nil, false, withSource(sourceName(s.src), err)
+			}
+			if len(line) == 0 {
+				break
+			}
+		}
+		line = bytes.TrimSuffix(line, []byte{s.delim})
+		if s.lines {
+			line = bytes.TrimSuffix(line, []byte{'\r'})
+		}
+		return line, true, nil
+	}
+	return nil, false, nil
+}
+
+func (s *recordReader) close() {
+	if s.br != nil {
+		s.br.Reset(nil)
+		bufioPool.Put(s.br)
+		s.br = nil
+	}
+}
+
+// RecordsChan streams the delim-separated records of r on a channel that is
+// closed at the end of r. Each Data is a copy the receiver may keep. A read
+// error is sent as a final Record with Err set. The goroutine filling the
+// channel stops when ctx is done. On Go 1.23 and later, Records avoids the
+// goroutine and the copies.
+func RecordsChan(ctx context.Context, r io.Reader, delim byte) <-chan Record {
+	return recordsChan(ctx, newRecordReader(r, delim, false))
+}
+
+// LinesChan is RecordsChan for lines, stripping "\n" or "\r\n".
+func LinesChan(ctx context.Context, r io.Reader) <-chan Record {
+	return recordsChan(ctx, newRecordReader(r, '\n', true))
+}
+
+func recordsChan(ctx context.Context, s *recordReader) <-chan Record {
+	ch := make(chan Record)
+	go func() {
+		defer close(ch)
+		defer s.close()
+		for {
+			rec, ok, err := s.next()
+			if !ok && err == nil {
+				return
+			}
+			r := Record{Data: append([]byte(nil), rec...), Err: err}
+			select {
+			case ch <- r:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
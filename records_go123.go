@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package readall
+
+import (
+	"io"
+	"iter"
+)
+
+// Records returns an iterator over the delim-separated records of r,
+// without their delimiter. Records are read through a reused buffer, so
+// only the current one is held in memory and each slice is only valid
+// until the next iteration. A read error is yielded once with a nil record
+// and ends the iteration.
+//
+//	for rec, err := range readall.Records(r, 0) {
+//		if err != nil { ... }
+//		handle(rec)
+//	}
+func Records(r io.Reader, delim byte) iter.Seq2[[]byte, error] {
+	return records(r, delim, false)
+}
+
+// Lines is Records for lines, stripping "\n" or "\r\n".
+func Lines(r io.Reader) iter.Seq2[[]byte, error] {
+	return records(r, '\n', true)
+}
+
+func records(r io.Reader, delim byte, lines bool) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		s := newRecordReader(r, delim, lines)
+		defer s.close()
+		for {
+			rec, ok, err := s.next()
+			if !ok {
+				if err != nil {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
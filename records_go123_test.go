@@ -0,0 +1,42 @@
+//go:build go1.23
+
+package readall
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLines(t *testing.T) {
+	var got []string
+	for line, err := range Lines(strings.NewReader("one\r\ntwo\n\nlast")) {
+		if err != nil {
+			t.Fatalf("err:%v", err)
+		}
+		got = append(got, string(line))
+	}
+	if strings.Join(got, "|") != "one|two||last" {
+		t.Errorf("lines:%q", got)
+	}
+
+	boom := errors.New("boom")
+	var recs []string
+	var last error
+	for rec, err := range Records(io.MultiReader(strings.NewReader("a,b,"), iotest.ErrReader(boom)), ',') {
+		if err != nil {
+			last = err
+			continue
+		}
+		recs = append(recs, string(rec))
+	}
+	if len(recs) != 2 || !errors.Is(last, boom) {
+		t.Errorf("records:%q, err:%v", recs, last)
+	}
+
+	for range Lines(strings.NewReader("a\nb\n")) {
+		break
+	}
+}
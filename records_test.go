@@ -0,0 +1,41 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLinesChan(t *testing.T) {
+	text := "one\r\ntwo\n\n" + strings.Repeat("x", 40<<10) + "\nlast"
+	var got []string
+	for rec := range LinesChan(context.Background(), strings.NewReader(text)) {
+		if rec.Err != nil {
+			t.Fatalf("err:%v", rec.Err)
+		}
+		got = append(got, string(rec.Data))
+	}
+	want := []string{"one", "two", "", strings.Repeat("x", 40<<10), "last"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lines:%d, want:%d", len(got), len(want))
+	}
+
+	boom := errors.New("boom")
+	var last Record
+	for rec := range RecordsChan(context.Background(), io.MultiReader(strings.NewReader("a\x00b\x00"), iotest.ErrReader(boom)), 0) {
+		last = rec
+	}
+	if !errors.Is(last.Err, boom) {
+		t.Errorf("last record:%+v, want err %v", last, boom)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := LinesChan(ctx, strings.NewReader("a\nb\nc\n"))
+	<-ch
+	cancel()
+	for range ch {
+	}
+}
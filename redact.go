@@ -0,0 +1,72 @@
+package readall
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Redactor rewrites a source identifier before it is exposed in errors and
+// audit events, e.g. to strip tokens from URLs or user names from paths.
+type Redactor func(source string) string
+
+var redactor atomic.Value // of Redactor
+
+// SetRedactor installs r for all sources; nil disables redaction, which is
+// the default. Combine several with Redactors.
+func SetRedactor(r Redactor) {
+	redactor.Store(r)
+}
+
+func redact(source string) string {
+	if source == "" {
+		return source
+	}
+	if r, _ := redactor.Load().(Redactor); r != nil {
+		return r(source)
+	}
+	return source
+}
+
+// Redactors chains rs in order.
+func Redactors(rs ...Redactor) Redactor {
+	return func(source string) string {
+		for _, r := range rs {
+			source = r(source)
+		}
+		return source
+	}
+}
+
+// RedactPattern replaces every match of re with repl, which may refer to
+// submatches as in regexp.Regexp.ReplaceAllString.
+func RedactPattern(re *regexp.Regexp, repl string) Redactor {
+	return func(source string) string {
+		return re.ReplaceAllString(source, repl)
+	}
+}
+
+// RedactURL masks the user info and all query parameter values of URL
+// sources, where credentials and signed-URL tokens usually live. Other
+// sources are left unchanged.
+func RedactURL(source string) string {
+	if !strings.Contains(source, "://") {
+		return source
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	if u.User != nil {
+		u.User = url.User("xxxxx")
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k := range q {
+			q[k] = []string{"xxxxx"}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
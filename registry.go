@@ -0,0 +1,89 @@
+package readall
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// TransformFactory creates a Transform from its stage configuration.
+type TransformFactory func(cfg StageConfig) (Transform, error)
+
+// SourceFactory creates a Source for a URL with a registered scheme.
+type SourceFactory func(u *url.URL) (Source, error)
+
+var (
+	registryMu        sync.RWMutex
+	transformRegistry = make(map[string]TransformFactory)
+	sourceRegistry    = make(map[string]SourceFactory)
+)
+
+var builtinStages = map[string]bool{"limit": true, "decompress": true, "hash": true}
+
+// builtinSchemes are resolved before the registry is consulted; data,
+// literal and stdin even before the URL is parsed.
+var builtinSchemes = map[string]bool{"file": true, "http": true, "https": true, "data": true, "literal": true, "stdin": true}
+
+// RegisterTransform makes a transform available by name to pipeline configs
+// and Pipeline.Transform, typically from an init function of the module
+// providing it. It panics if name is empty, built in or already registered.
+func RegisterTransform(name string, f TransformFactory) {
+	name = strings.ToLower(name)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || f == nil || builtinStages[name] {
+		panic("readall: invalid transform registration " + name)
+	}
+	if _, dup := transformRegistry[name]; dup {
+		panic("readall: RegisterTransform called twice for " + name)
+	}
+	transformRegistry[name] = f
+}
+
+// RegisterSource makes sources with the given URL scheme (e.g. "s3")
+// resolvable by pipeline configs and FromURL. It panics if scheme is empty,
+// built in or already registered.
+func RegisterSource(scheme string, f SourceFactory) {
+	scheme = strings.ToLower(scheme)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if scheme == "" || f == nil || builtinSchemes[scheme] {
+		panic("readall: invalid source registration " + scheme)
+	}
+	if _, dup := sourceRegistry[scheme]; dup {
+		panic("readall: RegisterSource called twice for " + scheme)
+	}
+	sourceRegistry[scheme] = f
+}
+
+func newTransform(name string, cfg StageConfig) (Transform, error) {
+	registryMu.RLock()
+	f, ok := transformRegistry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown transform %q", name)
+	}
+	return f(cfg)
+}
+
+// FromURL starts a pipeline reading the source named by raw, which may be a
+// file path or any URL understood by ReadURL.
+func FromURL(raw string) *Pipeline {
+	src, err := resolveURL(raw)
+	p := From(src)
+	p.err = err
+	return p
+}
+
+// Transform adds the registered transform name as the next stage.
+func (p *Pipeline) Transform(name string, params map[string]string) *Pipeline {
+	t, err := newTransform(name, StageConfig{Type: name, Params: params})
+	if err != nil {
+		if p.err == nil {
+			p.err = fmt.Errorf("readall: %w", err)
+		}
+		return p
+	}
+	return p.Apply(t)
+}
@@ -0,0 +1,49 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterTransform("upper", func(cfg StageConfig) (Transform, error) {
+		return func(r io.Reader) (io.Reader, error) {
+			b, err := io.ReadAll(r)
+			return bytes.NewReader(bytes.ToUpper(b)), err
+		}, nil
+	})
+	RegisterSource("mem", func(u *url.URL) (Source, error) {
+		return BytesSource(u.Opaque), nil
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	data, err := FromURL("mem:hello").Transform("upper", nil).Bytes()
+	if err != nil || string(data) != "HELLO" {
+		t.Errorf("registered pipeline:%q, err:%v", data, err)
+	}
+	p, err := BuildPipeline(PipelineConfig{Source: "mem:config", Stages: []StageConfig{{Type: "UPPER"}}})
+	if err != nil {
+		t.Errorf("build err:%v", err)
+		return
+	}
+	if data, err := p.Bytes(); err != nil || string(data) != "CONFIG" {
+		t.Errorf("configured pipeline:%q, err:%v", data, err)
+	}
+	if _, err := FromURL("mem:x").Transform("missing", nil).Bytes(); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("missing transform err:%v", err)
+	}
+	for _, scheme := range []string{"file", "data", "literal", "STDIN"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering built-in scheme %v did not panic", scheme)
+				}
+			}()
+			RegisterSource(scheme, func(u *url.URL) (Source, error) { return nil, nil })
+		}()
+	}
+}
@@ -0,0 +1,22 @@
+package readall
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// WriteAndRelease writes res.Data as the response body and then calls
+// res.Release, also when the write fails because the client went away, so
+// handlers cannot release a pooled buffer while net/http still uses it.
+// Content-Length is set unless the handler already set it or started the
+// response.
+func WriteAndRelease(w http.ResponseWriter, res Result) (int, error) {
+	if res.Release != nil {
+		defer res.Release()
+	}
+	h := w.Header()
+	if h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" {
+		h.Set("Content-Length", strconv.Itoa(len(res.Data)))
+	}
+	return w.Write(res.Data)
+}
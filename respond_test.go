@@ -0,0 +1,21 @@
+package readall
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteAndRelease(t *testing.T) {
+	rec := httptest.NewRecorder()
+	released := false
+	n, err := WriteAndRelease(rec, Result{Data: []byte("payload"), Release: func() { released = true }})
+	if err != nil || n != 7 {
+		t.Errorf("write n:%v, err:%v", n, err)
+	}
+	if !released {
+		t.Errorf("buffer not released")
+	}
+	if rec.Body.String() != "payload" || rec.Header().Get("Content-Length") != "7" {
+		t.Errorf("body:%q, content-length:%q", rec.Body.String(), rec.Header().Get("Content-Length"))
+	}
+}
@@ -0,0 +1,151 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrLengthMismatch is returned when a response body is shorter or longer
+// than its declared Content-Length, as from a truncating proxy or a buggy
+// server. ReadResponse returns it as a *LengthError, which matches it with
+// errors.Is.
+var ErrLengthMismatch = errors.New("readall: response body length differs from Content-Length")
+
+// ErrShortBody is matched by a *LengthError for a body that ended early.
+var ErrShortBody = errors.New("readall: response body shorter than Content-Length")
+
+// LengthError reports a body whose size differs from its Content-Length.
+type LengthError struct {
+	// Declared is the Content-Length of the response.
+	Declared int64
+	// Read is the number of body bytes received.
+	Read int64
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("readall: response body of %d bytes, Content-Length is %d", e.Read, e.Declared)
+}
+
+// Is reports whether target is ErrLengthMismatch, or ErrShortBody for a
+// short body.
+func (e *LengthError) Is(target error) bool {
+	return target == ErrLengthMismatch || target == ErrShortBody && e.Read < e.Declared
+}
+
+// WithLengthWarning makes a body length mismatch a warning: fn is called with
+// the *LengthError and the read succeeds with the bytes received.
+func WithLengthWarning(fn func(*LengthError)) Option {
+	return func(o *options) {
+		o.lengthWarn = fn
+	}
+}
+
+// maxResponsePrealloc caps how much of a Content-Length is allocated up
+// front, since the header comes from the peer.
+const maxResponsePrealloc = 64 << 20
+
+// maxResponseDrain bounds how much of an unread body is discarded to keep the
+// connection reusable; past that closing it is cheaper.
+const maxResponseDrain = 256 << 10
+
+// ReadResponse reads the body of rsp, allocating once from Content-Length when
+// the server sent one, and closes it. The body is drained first so the
+// connection can be reused. A body whose length differs from Content-Length
+// yields the bytes received and a *LengthError, unless WithLengthWarning
+// is given or, for a short body, TruncationAllowed. WithIntegrityCheck
+// verifies checksum headers and trailers.
+func ReadResponse(rsp *http.Response, opts ...Option) ([]byte, error) {
+	return readResponse(rsp, -1, false, false, opts)
+}
+
+// ReadResponseLimit is ReadResponse failing with a *LimitError once the body
+// exceeds max bytes. A Content-Length above max fails before anything is
+// read.
+func ReadResponseLimit(rsp *http.Response, max int64, opts ...Option) ([]byte, error) {
+	if max < 0 {
+		max = 0
+	}
+	return readResponse(rsp, max, false, false, opts)
+}
+
+// readResponse reads the body of rsp, limited to max bytes unless max is
+// negative, decoded according to Content-Encoding if decode is set and
+// transcoded to UTF-8 according to Content-Type if charset is set.
+func readResponse(rsp *http.Response, max int64, decode, charset bool, opts []Option) (_ []byte, err error) {
+	defer func() { err = withSource(responseSource(rsp), err) }()
+	defer Drain(rsp.Body, maxResponseDrain)
+	declared := rsp.ContentLength
+	if max >= 0 && declared > max && !decode && !charset {
+		return nil, &LimitError{Limit: max}
+	}
+	size := int64(bytes.MinRead)
+	if declared >= 0 {
+		size = min64(declared, maxResponsePrealloc) + 1
+	}
+	o := readOptions(opts)
+	var r io.Reader = rsp.Body
+	var check *integrityCheck
+	if o.integrity {
+		check = newIntegrityCheck(rsp)
+		r = check.reader(r)
+	}
+	var encoded int64
+	var raw io.Reader
+	if decode || charset {
+		raw = &countingReader{r: r, n: &encoded}
+		r = raw
+	}
+	if decode {
+		if r, err = decodeReader(r, rsp.Header.Get("Content-Encoding")); err != nil {
+			return nil, err
+		}
+	}
+	if charset {
+		if r, err = charsetReader(r, contentCharset(rsp.Header)); err != nil {
+			return nil, err
+		}
+	}
+	if max >= 0 {
+		r = newLimitReader(r, max)
+		if size-1 > max {
+			size = max + 1
+		}
+	}
+	b, err := readAllCap(r, size, o)
+	if raw == nil {
+		encoded = int64(len(b))
+	} else if err == nil {
+		// Decoders may stop before the end of the body; count (and hash)
+		// what follows the encoded data too.
+		_, err = io.Copy(ioutil.Discard, raw)
+	}
+	// net/http reports a short fixed-length body as io.ErrUnexpectedEOF.
+	if (err == nil || err == io.ErrUnexpectedEOF) && declared >= 0 && encoded != declared {
+		le := &LengthError{Declared: declared, Read: encoded}
+		switch {
+		case o.lengthWarn != nil:
+			o.lengthWarn(le)
+		case o.truncation == TruncationAllowed && le.Read < le.Declared:
+		default:
+			return b, o.partial(b, le)
+		}
+		err = nil
+	}
+	if err == nil && check != nil {
+		if err := check.verify(); err != nil {
+			return nil, o.partial(b, err)
+		}
+	}
+	return b, o.partial(b, err)
+}
+
+func responseSource(rsp *http.Response) string {
+	if rsp.Request != nil && rsp.Request.URL != nil {
+		return rsp.Request.URL.String()
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package readall
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type trackedBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func response(body string, length int64) (*http.Response, *trackedBody) {
+	b := &trackedBody{Reader: strings.NewReader(body)}
+	return &http.Response{StatusCode: http.StatusOK, Body: b, ContentLength: length}, b
+}
+
+func TestReadResponse(t *testing.T) {
+	rsp, body := response("payload", 7)
+	data, err := ReadResponse(rsp)
+	if err != nil || string(data) != "payload" || cap(data) != 8 {
+		t.Errorf("data:%q, cap:%v, err:%v", data, cap(data), err)
+	}
+	if !body.closed {
+		t.Errorf("body not closed")
+	}
+
+	rsp, _ = response("chunked", -1)
+	if data, err := ReadResponse(rsp); err != nil || string(data) != "chunked" {
+		t.Errorf("unknown length data:%q, err:%v", data, err)
+	}
+
+	rsp, _ = response("short", 10)
+	data, err = ReadResponse(rsp)
+	var le *LengthError
+	if !errors.Is(err, ErrShortBody) || !errors.As(err, &le) || le.Declared != 10 || le.Read != 5 || string(data) != "short" {
+		t.Errorf("short data:%q, err:%v", data, err)
+	}
+}
+
+func TestReadResponseLimit(t *testing.T) {
+	rsp, body := response("too large", 9)
+	if _, err := ReadResponseLimit(rsp, 4); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("declared err:%v, want:%v", err, ErrLimitExceeded)
+	}
+	if !body.closed || body.Len() != 0 {
+		t.Errorf("body closed:%v, unread:%v", body.closed, body.Len())
+	}
+
+	rsp, _ = response("too large", -1)
+	if data, err := ReadResponseLimit(rsp, 4); !errors.Is(err, ErrLimitExceeded) || string(data) != "too " {
+		t.Errorf("data:%q, err:%v", data, err)
+	}
+	rsp, _ = response("fits", 4)
+	if data, err := ReadResponseLimit(rsp, 4); err != nil || string(data) != "fits" {
+		t.Errorf("data:%q, err:%v", data, err)
+	}
+}
+
+func TestReadResponseLengthMismatch(t *testing.T) {
+	rsp, _ := response("longer than declared", 6)
+	if _, err := ReadResponse(rsp); !errors.Is(err, ErrLengthMismatch) || errors.Is(err, ErrShortBody) {
+		t.Errorf("long body err:%v", err)
+	}
+	var warned *LengthError
+	rsp, _ = response("short", 10)
+	data, err := ReadResponse(rsp, WithLengthWarning(func(e *LengthError) { warned = e }))
+	if err != nil || string(data) != "short" || warned == nil || warned.Read != 5 {
+		t.Errorf("warn data:%q, err:%v, warned:%v", data, err, warned)
+	}
+	rsp, _ = response("short", 10)
+	if data, err := ReadResponse(rsp, WithTruncationPolicy(TruncationAllowed)); err != nil || string(data) != "short" {
+		t.Errorf("truncation data:%q, err:%v", data, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		io.WriteString(w, "truncated")
+	}))
+	defer srv.Close()
+	data, err = From(&HTTPSource{URL: srv.URL}).Bytes()
+	var le *LengthError
+	if !errors.As(err, &le) || le.Declared != 100 || le.Read != 9 || string(data) != "truncated" {
+		t.Errorf("source data:%q, err:%v", data, err)
+	}
+}
@@ -0,0 +1,169 @@
+package readall
+
+import (
+	"encoding"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// DefaultCheckpointInterval is the number of bytes read between checkpoints.
+const DefaultCheckpointInterval = 8 << 20
+
+// ResumeOptions configures ReadAllResumable.
+type ResumeOptions struct {
+	// Interval is the number of bytes between checkpoints; zero means
+	// DefaultCheckpointInterval.
+	Interval int64
+	// Hash, if set, is fed all data. Its state is checkpointed too, so it
+	// must implement encoding.BinaryMarshaler and BinaryUnmarshaler, as the
+	// crypto/* and hash/* implementations do.
+	Hash hash.Hash
+}
+
+type checkpoint struct {
+	Offset int64  `json:"offset"`
+	Hash   []byte `json:"hash,omitempty"`
+}
+
+// ReadAllResumable reads src to EOF, spilling the data to statePath+".data"
+// and periodically recording the offset and hash state in statePath. If a
+// previous run crashed, it resumes from the last checkpoint instead of
+// re-reading src from the start. On success the data is returned and both
+// state files are removed.
+func ReadAllResumable(src io.ReadSeeker, statePath string, opts ResumeOptions) (_ []byte, err error) {
+	defer annotate(&err, src)
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	cp, err := loadCheckpoint(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Offset > 0 && opts.Hash != nil {
+		u, ok := opts.Hash.(encoding.BinaryUnmarshaler)
+		if !ok || len(cp.Hash) == 0 {
+			return nil, errors.New("readall: checkpointed hash state cannot be restored")
+		}
+		if err := u.UnmarshalBinary(cp.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	dataPath := statePath + ".data"
+	f, err := os.OpenFile(dataPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// Anything past the checkpoint may be torn; drop it and re-read.
+	if err := f.Truncate(cp.Offset); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := src.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = f
+	if opts.Hash != nil {
+		w = io.MultiWriter(f, opts.Hash)
+	}
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	off, next := cp.Offset, cp.Offset+interval
+	for {
+		n, rerr := src.Read(*bp)
+		if n > 0 {
+			if _, err := w.Write((*bp)[:n]); err != nil {
+				return nil, err
+			}
+			off += int64(n)
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			saveCheckpoint(f, statePath, off, opts.Hash)
+			return nil, rerr
+		}
+		if off >= next {
+			if err := saveCheckpoint(f, statePath, off, opts.Hash); err != nil {
+				return nil, err
+			}
+			next = off + interval
+		}
+	}
+
+	data := make([]byte, off)
+	if _, err := f.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	f.Close()
+	os.Remove(dataPath)
+	os.Remove(statePath)
+	return data, nil
+}
+
+func loadCheckpoint(path string) (checkpoint, error) {
+	var cp checkpoint
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, err
+	}
+	if err := checkpointFormat.unmarshal(b, &cp); err != nil {
+		return cp, err
+	}
+	return cp, nil
+}
+
+// saveCheckpoint syncs the spilled data before atomically replacing the
+// checkpoint, so a checkpoint never points past durable data.
+func saveCheckpoint(f *os.File, path string, off int64, h hash.Hash) error {
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	cp := checkpoint{Offset: off}
+	if m, ok := h.(encoding.BinaryMarshaler); ok {
+		state, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		cp.Hash = state
+	}
+	b, err := checkpointFormat.marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b)
+}
+
+// writeFileAtomic replaces path with b so that readers see either the old or
+// the new contents, even across a crash.
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
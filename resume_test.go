@@ -0,0 +1,64 @@
+package readall
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingSeeker fails reads once it has delivered failAt bytes.
+type failingSeeker struct {
+	*bytes.Reader
+	failAt int64
+}
+
+func (f *failingSeeker) Read(p []byte) (int, error) {
+	pos, _ := f.Reader.Seek(0, io.SeekCurrent)
+	if pos >= f.failAt {
+		return 0, errors.New("connection reset")
+	}
+	if int64(len(p)) > f.failAt-pos {
+		p = p[:f.failAt-pos]
+	}
+	return f.Reader.Read(p)
+}
+
+func TestReadAllResumable(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(3)).Read(data)
+	state := filepath.Join(t.TempDir(), "ingest.state")
+
+	h := sha256.New()
+	_, err := ReadAllResumable(&failingSeeker{bytes.NewReader(data), 700 << 10}, state, ResumeOptions{Interval: 64 << 10, Hash: h})
+	if err == nil {
+		t.Errorf("expected read failure")
+		return
+	}
+	cp, err := loadCheckpoint(state)
+	if err != nil || cp.Offset == 0 {
+		t.Errorf("checkpoint:%+v, err:%v", cp, err)
+		return
+	}
+
+	src := bytes.NewReader(data)
+	h = sha256.New()
+	got, err := ReadAllResumable(src, state, ResumeOptions{Interval: 64 << 10, Hash: h})
+	if err != nil {
+		t.Errorf("resume err:%v", err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("resumed data mismatch, len:%v", len(got))
+	}
+	if sum := sha256.Sum256(data); !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Errorf("resumed digest mismatch")
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Errorf("state file left behind, stat err:%v", err)
+	}
+}
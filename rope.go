@@ -0,0 +1,130 @@
+package readall
+
+import "io"
+
+// RopeSegment is the size of the segments Chunks reads into.
+const RopeSegment = 64 << 10
+
+// Rope holds data as a list of fixed-size segments, so reading it never
+// copies what was already read the way growing a single slice does.
+type Rope struct {
+	segs [][]byte
+	n    int64
+}
+
+// Chunks reads r until EOF into RopeSegment-sized segments. Use it when the
+// data is only streamed onward with WriteTo or Reader; Bytes still returns a
+// contiguous copy when one is needed.
+func Chunks(r io.Reader) (_ *Rope, err error) {
+	defer annotate(&err, r)
+	t := track(r, "", "")
+	defer t.done()
+	tr := trackedReader{r, t}
+	rp := new(Rope)
+	for {
+		seg := make([]byte, RopeSegment)
+		n, err := io.ReadFull(tr, seg)
+		if n > 0 && n < RopeSegment/4 {
+			// Do not pin a whole segment for a short tail.
+			seg = append([]byte(nil), seg[:n]...)
+		}
+		if n > 0 {
+			rp.segs = append(rp.segs, seg[:n:n])
+			rp.n += int64(n)
+		}
+		if t.isKilled() {
+			return nil, t.err()
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			observeSize("", rp.n)
+			return rp, nil
+		}
+		if err != nil {
+			return rp, err
+		}
+	}
+}
+
+// Len returns the number of bytes held.
+func (rp *Rope) Len() int64 {
+	return rp.n
+}
+
+// Segments returns the segments in order. They must not be modified.
+func (rp *Rope) Segments() [][]byte {
+	return rp.segs
+}
+
+// Bytes returns the data as one slice. With a single segment that is the
+// segment itself; otherwise it is a copy allocated once at the final size.
+func (rp *Rope) Bytes() []byte {
+	if len(rp.segs) == 1 {
+		return rp.segs[0]
+	}
+	b := make([]byte, 0, rp.n)
+	for _, seg := range rp.segs {
+		b = append(b, seg...)
+	}
+	return b
+}
+
+// WriteTo writes the segments to w in order.
+func (rp *Rope) WriteTo(w io.Writer) (n int64, err error) {
+	for _, seg := range rp.segs {
+		m, err := w.Write(seg)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Reader returns a reader over the data; each reader has its own position.
+func (rp *Rope) Reader() io.Reader {
+	return &ropeReader{segs: rp.segs}
+}
+
+type ropeReader struct {
+	segs [][]byte
+	off  int // within segs[0]
+}
+
+func (r *ropeReader) Read(p []byte) (n int, err error) {
+	for len(p) > 0 && len(r.segs) > 0 {
+		m := copy(p, r.segs[0][r.off:])
+		n += m
+		p = p[m:]
+		if r.off += m; r.off == len(r.segs[0]) {
+			r.segs, r.off = r.segs[1:], 0
+		}
+	}
+	if n == 0 && len(r.segs) == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (r *ropeReader) WriteTo(w io.Writer) (n int64, err error) {
+	for len(r.segs) > 0 {
+		m, err := w.Write(r.segs[0][r.off:])
+		n += int64(m)
+		if r.off += m; r.off == len(r.segs[0]) {
+			r.segs, r.off = r.segs[1:], 0
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Len reports the bytes not yet read, which also lets ReadAll size its
+// buffer from a rope reader.
+func (r *ropeReader) Len() int {
+	n := -r.off
+	for _, seg := range r.segs {
+		n += len(seg)
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestChunks(t *testing.T) {
+	data := strings.Repeat("rope", RopeSegment/2+10)
+	rp, err := Chunks(iotest.HalfReader(strings.NewReader(data)))
+	if err != nil {
+		t.Errorf("chunks err:%v", err)
+		return
+	}
+	if rp.Len() != int64(len(data)) || len(rp.Segments()) != 3 || string(rp.Bytes()) != data {
+		t.Errorf("len:%v, segments:%v", rp.Len(), len(rp.Segments()))
+	}
+	var buf bytes.Buffer
+	if n, err := rp.WriteTo(&buf); err != nil || n != int64(len(data)) || buf.String() != data {
+		t.Errorf("write n:%v, err:%v", n, err)
+	}
+	got, err := ioutil.ReadAll(iotest.OneByteReader(rp.Reader()))
+	if err != nil || string(got) != data {
+		t.Errorf("reader len:%v, err:%v", len(got), err)
+	}
+	r := rp.Reader()
+	io.CopyN(ioutil.Discard, r, 5)
+	if got, err := ReadAll(r); err != nil || string(got) != data[5:] || cap(got) != len(data)-4 {
+		t.Errorf("rest len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+
+	empty, err := Chunks(strings.NewReader(""))
+	if err != nil || empty.Len() != 0 || len(empty.Bytes()) != 0 {
+		t.Errorf("empty len:%v, err:%v", empty.Len(), err)
+	}
+}
@@ -0,0 +1,52 @@
+package readall
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidSample is returned by Sample when blockSize or stride is not
+// positive.
+var ErrInvalidSample = errors.New("readall: invalid sample block size or stride")
+
+// Sample reads one block of blockSize bytes out of every stride blocks of r,
+// starting at offset 0, where r holds size bytes. The last block may be shorter
+// than blockSize. All blocks share one backing allocation of at most
+// ceil(size/(blockSize*stride))*blockSize bytes, so large files can be
+// inspected without being read entirely.
+func Sample(r io.ReaderAt, size int64, blockSize, stride int) (_ [][]byte, err error) {
+	defer annotate(&err, r)
+	if blockSize <= 0 || stride <= 0 {
+		return nil, ErrInvalidSample
+	}
+	if size <= 0 {
+		return nil, nil
+	}
+	step := int64(blockSize) * int64(stride)
+	count := (size + step - 1) / step
+	total := (count-1)*int64(blockSize) + min64(int64(blockSize), size-(count-1)*step)
+	data := make([]byte, total)
+	blocks := make([][]byte, 0, count)
+	var pos int64
+	for off := int64(0); off < size; off += step {
+		n := min64(int64(blockSize), size-off)
+		block := data[pos : pos+n : pos+n]
+		m, err := r.ReadAt(block, off)
+		if int64(m) < n {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return blocks, err
+		}
+		blocks = append(blocks, block)
+		pos += n
+	}
+	return blocks, nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+	blocks, err := Sample(bytes.NewReader(data), int64(len(data)), 3, 2)
+	if err != nil {
+		t.Errorf("sample err:%v", err)
+		return
+	}
+	want := []string{"012", "678", "cde", "ij"}
+	if len(blocks) != len(want) {
+		t.Errorf("blocks len:%v, want:%v", len(blocks), len(want))
+		return
+	}
+	for i, b := range blocks {
+		if string(b) != want[i] {
+			t.Errorf("block %v:%q, want:%q", i, b, want[i])
+		}
+	}
+}
+
+func TestSampleShortSource(t *testing.T) {
+	data := []byte("0123456789")
+	blocks, err := Sample(bytes.NewReader(data), 20, 4, 2)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("sample err:%v, want:%v", err, io.ErrUnexpectedEOF)
+		return
+	}
+	if len(blocks) != 1 {
+		t.Errorf("blocks len:%v, want:1", len(blocks))
+	}
+}
+
+func TestSampleInvalid(t *testing.T) {
+	if _, err := Sample(bytes.NewReader(nil), 10, 0, 1); err != ErrInvalidSample {
+		t.Errorf("sample err:%v, want:%v", err, ErrInvalidSample)
+	}
+}
@@ -0,0 +1,57 @@
+package readall
+
+import "sync"
+
+// SizeClass selects the size of a Scratch buffer.
+type SizeClass int
+
+const (
+	Scratch4K   SizeClass = iota // 4KiB
+	Scratch32K                   // 32KiB, the size io.Copy uses
+	Scratch256K                  // 256KiB
+	Scratch1M                    // 1MiB
+)
+
+var scratchSizes = [...]int{4 << 10, 32 << 10, 256 << 10, 1 << 20}
+
+var scratchPools [len(scratchSizes)]sync.Pool
+
+func init() {
+	for i, n := range scratchSizes {
+		n := n
+		scratchPools[i].New = func() interface{} {
+			b := make([]byte, n)
+			return &b
+		}
+	}
+}
+
+// scratchSize and scratchPool are the class used by the package itself.
+const scratchSize = 32 << 10
+
+var scratchPool = &scratchPools[Scratch32K]
+
+// Scratch returns a temporary buffer of the size of class c, for read loops
+// built on this package, and a release function returning it for reuse.
+// Buffers come from a sync.Pool, which keeps them in per-processor caches,
+// so a goroutine usually gets back the buffer it just released without
+// allocating or contending:
+//
+//	buf, release := readall.Scratch(readall.Scratch32K)
+//	defer release()
+//
+// The buffer must not be used after release; calling release again is a
+// no-op. Unknown classes get Scratch32K.
+func Scratch(c SizeClass) (buf []byte, release func()) {
+	if c < 0 || int(c) >= len(scratchSizes) {
+		c = Scratch32K
+	}
+	pool := &scratchPools[c]
+	bp := pool.Get().(*[]byte)
+	return *bp, func() {
+		if bp != nil {
+			pool.Put(bp)
+			bp = nil
+		}
+	}
+}
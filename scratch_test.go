@@ -0,0 +1,17 @@
+package readall
+
+import "testing"
+
+func TestScratch(t *testing.T) {
+	for c, want := range map[SizeClass]int{Scratch4K: 4 << 10, Scratch32K: 32 << 10, Scratch256K: 256 << 10, Scratch1M: 1 << 20, 99: 32 << 10} {
+		buf, release := Scratch(c)
+		if len(buf) != want || cap(buf) != want {
+			t.Errorf("class %v len:%v, want:%v", c, len(buf), want)
+		}
+		release()
+		release()
+	}
+	if buf := *scratchPool.Get().(*[]byte); len(buf) != scratchSize {
+		t.Errorf("package scratch len:%v", len(buf))
+	}
+}
@@ -0,0 +1,110 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+)
+
+// searchProbe is how much is read per ReaderAt probe while looking for line
+// boundaries.
+const searchProbe = 4 << 10
+
+// SearchSorted bisects the newline-delimited, sorted data in r[0:size] for
+// the first line for which cmp returns >= 0, where cmp reports how the line
+// compares to the target (negative if it sorts before it). Only O(log size)
+// lines are read, so lookups in large sorted indexes need no ReadAll. It
+// returns the line without its "\n" and its offset, and found reports
+// whether cmp returned 0 for it. Without such a line off is size and line
+// nil. cmp may be called with slices of a pooled buffer that are only valid
+// during the call; the returned line is a copy.
+func SearchSorted(r io.ReaderAt, size int64, cmp func(line []byte) int) (line []byte, off int64, found bool, err error) {
+	defer annotate(&err, r)
+	bp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bp)
+	s := lineSearch{r: r, size: size, buf: *bp}
+	// Invariant: lines starting before lo sort before the target, and the
+	// first line starting at or after hi does not.
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, l, err := s.lineAfter(mid)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if start < hi && cmp(l) < 0 {
+			lo = start + 1
+		} else {
+			hi = mid
+		}
+	}
+	start, l, err := s.lineAfter(lo)
+	if err != nil || start >= size {
+		return nil, size, false, err
+	}
+	c := cmp(l)
+	return append([]byte(nil), l...), start, c == 0, nil
+}
+
+type lineSearch struct {
+	r    io.ReaderAt
+	size int64
+	buf  []byte
+	line []byte
+}
+
+// lineAfter returns the first line starting at or after pos, or size and nil
+// if there is none.
+func (s *lineSearch) lineAfter(pos int64) (int64, []byte, error) {
+	start := pos
+	if pos > 0 {
+		i, err := s.index(pos - 1)
+		if err != nil {
+			return 0, nil, err
+		}
+		start = i + 1
+	}
+	if start >= s.size {
+		return s.size, nil, nil
+	}
+	end, err := s.index(start)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.line = s.line[:0]
+	for off := start; off < end; {
+		n := int(min64(int64(len(s.buf)), end-off))
+		if err := s.readAt(s.buf[:n], off); err != nil {
+			return 0, nil, err
+		}
+		s.line = append(s.line, s.buf[:n]...)
+		off += int64(n)
+	}
+	return start, s.line, nil
+}
+
+// index returns the offset of the first '\n' at or after pos, or size.
+func (s *lineSearch) index(pos int64) (int64, error) {
+	for pos < s.size {
+		n := int(min64(searchProbe, s.size-pos))
+		p := s.buf[:n]
+		if err := s.readAt(p, pos); err != nil {
+			return 0, err
+		}
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			return pos + int64(i), nil
+		}
+		pos += int64(n)
+	}
+	return s.size, nil
+}
+
+func (s *lineSearch) readAt(p []byte, off int64) error {
+	n, err := s.r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package readall
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSearchSorted(t *testing.T) {
+	var lines []string
+	for i := 0; i < 5000; i += 2 {
+		lines = append(lines, fmt.Sprintf("key%05d\t%s", i, strings.Repeat("v", i%97)))
+	}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	r := bytes.NewReader(data)
+	search := func(key string) ([]byte, int64, bool, error) {
+		return SearchSorted(r, int64(len(data)), func(line []byte) int {
+			return strings.Compare(string(bytes.SplitN(line, []byte{'\t'}, 2)[0]), key)
+		})
+	}
+	for _, i := range []int{0, 2, 1234, 4998} {
+		key := fmt.Sprintf("key%05d", i)
+		line, off, found, err := search(key)
+		if err != nil || !found || string(line) != lines[i/2] || !bytes.HasPrefix(data[off:], line) {
+			t.Errorf("%v line:%.20q, off:%v, found:%v, err:%v", key, line, off, found, err)
+		}
+	}
+	if line, _, found, err := search("key01235"); err != nil || found || !strings.HasPrefix(string(line), "key01236") {
+		t.Errorf("missing key line:%.20q, found:%v, err:%v", line, found, err)
+	}
+	if line, off, found, err := search("key99999"); err != nil || found || line != nil || off != int64(len(data)) {
+		t.Errorf("past end line:%q, off:%v, found:%v, err:%v", line, off, found, err)
+	}
+	if _, _, found, err := SearchSorted(r, 0, func([]byte) int { return 0 }); err != nil || found {
+		t.Errorf("empty found:%v, err:%v", found, err)
+	}
+}
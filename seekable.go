@@ -0,0 +1,214 @@
+package readall
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// ErrNegativeOffset is returned by Seek and ReadRangeFrom for positions
+// before the start of the stream.
+var ErrNegativeOffset = errors.New("readall: negative seek offset")
+
+// MakeSeekable returns a ReadSeekCloser over r. Data is buffered only as far
+// as the consumer has read or seeked: the first memLimit bytes in memory and
+// anything beyond in a temporary file, which Close removes. Seeking relative
+// to the end reads the rest of r. Close also closes r if it is an io.Closer.
+//
+// If r is already an io.ReadSeeker that supports seeking (not a pipe), it is
+// used directly.
+func MakeSeekable(r io.Reader, memLimit int64) (io.ReadSeekCloser, error) {
+	if memLimit < 0 {
+		return nil, errors.New("readall: negative memory limit")
+	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if _, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return readSeekCloser{ReadSeeker: rs, src: r}, nil
+		}
+	}
+	return &seekable{src: r, memLimit: memLimit}, nil
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	src io.Reader
+}
+
+func (r readSeekCloser) Close() error {
+	if c, ok := r.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (r readSeekCloser) Unwrap() io.Reader {
+	return r.src
+}
+
+// ReadAllSpill reads r until EOF, holding the first memLimit bytes in memory
+// and spilling the rest to a temporary file in dir (os.TempDir if empty), and
+// returns a ReadSeekCloser over the data positioned at the start, together
+// with its size. Unexpectedly large inputs thus cost disk rather than memory.
+// Close removes the temporary file and closes r if it is an io.Closer. With
+// WithGroup, the file is removed by Group.Shutdown as well.
+func ReadAllSpill(r io.Reader, memLimit int64, dir string, opts ...Option) (_ io.ReadSeekCloser, _ int64, err error) {
+	if memLimit < 0 {
+		return nil, 0, errors.New("readall: negative memory limit")
+	}
+	o := readOptions(opts)
+	s := &seekable{src: r, memLimit: memLimit, dir: dir, group: o.group}
+	if n, ok := sizeHint(r); ok {
+		s.mem = make([]byte, 0, min64(n, memLimit))
+	}
+	if _, err := s.Seek(0, io.SeekEnd); err != nil {
+		s.Close()
+		return nil, 0, err
+	}
+	s.pos = 0
+	return s, s.size, nil
+}
+
+type seekable struct {
+	src      io.Reader
+	srcErr   error
+	memLimit int64
+	dir      string // for the spill file
+	group    *Group // removes the spill file on Shutdown
+	mem      []byte
+	spill    *os.File
+	size     int64 // bytes buffered so far
+	pos      int64
+	closed   bool
+}
+
+// fill buffers from src until at least want bytes are held or src ends.
+func (s *seekable) fill(want int64) error {
+	var chunk [32 << 10]byte
+	for s.size < want && s.srcErr == nil {
+		n, err := s.src.Read(chunk[:])
+		if n > 0 {
+			if werr := s.store(chunk[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			s.srcErr = err
+		}
+	}
+	if s.size < want && s.srcErr != io.EOF {
+		return s.srcErr
+	}
+	return nil
+}
+
+func (s *seekable) store(p []byte) error {
+	if room := s.memLimit - int64(len(s.mem)); room > 0 {
+		n := int(min64(room, int64(len(p))))
+		s.mem = append(s.mem, p[:n]...)
+		s.size += int64(n)
+		p = p[n:]
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	if s.spill == nil {
+		f, err := ioutil.TempFile(s.dir, "readall-seek-*")
+		if err != nil {
+			return err
+		}
+		s.spill = f
+		s.group.addSpill(f)
+	}
+	if _, err := s.spill.WriteAt(p, s.size-s.memLimit); err != nil {
+		return err
+	}
+	s.size += int64(len(p))
+	return nil
+}
+
+func (s *seekable) Unwrap() io.Reader {
+	return s.src
+}
+
+func (s *seekable) Read(p []byte) (int, error) {
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := s.fill(s.pos + int64(len(p))); err != nil && s.pos >= s.size {
+		return 0, withSource(sourceName(s.src), err)
+	}
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	p = p[:min64(int64(len(p)), s.size-s.pos)]
+	var n int
+	if s.pos < int64(len(s.mem)) {
+		n = copy(p, s.mem[s.pos:])
+	}
+	if n < len(p) {
+		m, err := s.spill.ReadAt(p[n:], s.pos+int64(n)-s.memLimit)
+		n += m
+		if err != nil && err != io.EOF {
+			s.pos += int64(n)
+			return n, err
+		}
+	}
+	s.pos += int64(n)
+	return n, nil
+}
+
+func (s *seekable) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.pos + offset
+	case io.SeekEnd:
+		for s.srcErr == nil {
+			if err := s.fill(s.size + 1); err != nil {
+				return s.pos, withSource(sourceName(s.src), err)
+			}
+		}
+		if s.srcErr != io.EOF {
+			return s.pos, withSource(sourceName(s.src), s.srcErr)
+		}
+		abs = s.size + offset
+	default:
+		return s.pos, errors.New("readall: invalid seek whence")
+	}
+	if abs < 0 {
+		return s.pos, ErrNegativeOffset
+	}
+	s.pos = abs
+	return abs, nil
+}
+
+func (s *seekable) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.mem = nil
+	var err error
+	// Shutdown may have removed the spill file already.
+	if s.spill != nil && s.group.removeSpill(s.spill) {
+		err = s.spill.Close()
+		if rerr := os.Remove(s.spill.Name()); err == nil {
+			err = rerr
+		}
+	}
+	if c, ok := s.src.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
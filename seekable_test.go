@@ -0,0 +1,99 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMakeSeekable(t *testing.T) {
+	data := make([]byte, 100<<10)
+	rand.New(rand.NewSource(2)).Read(data)
+	s, err := MakeSeekable(struct{ io.Reader }{bytes.NewReader(data)}, 10<<10)
+	if err != nil {
+		t.Errorf("seekable err:%v", err)
+		return
+	}
+	defer s.Close()
+
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(s, buf); err != nil || !bytes.Equal(buf, data[:16]) {
+		t.Errorf("first read err:%v", err)
+	}
+	if _, err := s.Seek(50<<10, io.SeekStart); err != nil {
+		t.Errorf("seek err:%v", err)
+		return
+	}
+	if _, err := io.ReadFull(s, buf); err != nil || !bytes.Equal(buf, data[50<<10:50<<10+16]) {
+		t.Errorf("spilled read err:%v", err)
+	}
+	if _, err := s.Seek(8, io.SeekStart); err != nil {
+		t.Errorf("seek err:%v", err)
+		return
+	}
+	if _, err := io.ReadFull(s, buf); err != nil || !bytes.Equal(buf, data[8:24]) {
+		t.Errorf("backward read err:%v", err)
+	}
+	end, err := s.Seek(-10, io.SeekEnd)
+	if err != nil || end != int64(len(data)-10) {
+		t.Errorf("seek end:%v, err:%v", end, err)
+		return
+	}
+	rest, err := ioutil.ReadAll(s)
+	if err != nil || !bytes.Equal(rest, data[len(data)-10:]) {
+		t.Errorf("tail read len:%v, err:%v", len(rest), err)
+	}
+
+	spill := s.(*seekable).spill.Name()
+	if err := s.Close(); err != nil {
+		t.Errorf("close err:%v", err)
+	}
+	if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Errorf("spill file not removed, stat err:%v", err)
+	}
+}
+
+func TestMakeSeekablePassthrough(t *testing.T) {
+	r := bytes.NewReader([]byte("seekable"))
+	s, err := MakeSeekable(r, 0)
+	if err != nil {
+		t.Errorf("seekable err:%v", err)
+		return
+	}
+	if _, ok := s.(*seekable); ok {
+		t.Errorf("seekable source was buffered")
+	}
+}
+
+func TestReadAllSpill(t *testing.T) {
+	dir := t.TempDir()
+	data := strings.Repeat("spill", 1000)
+	rs, n, err := ReadAllSpill(struct{ io.Reader }{strings.NewReader(data)}, 100, dir)
+	if err != nil || n != int64(len(data)) {
+		t.Errorf("spill n:%v, err:%v", n, err)
+		return
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("spill files:%v", len(files))
+	}
+	got, err := ioutil.ReadAll(rs)
+	if err != nil || string(got) != data {
+		t.Errorf("read len:%v, err:%v", len(got), err)
+	}
+	rs.Close()
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill file left after close")
+	}
+
+	rs, n, err = ReadAllSpill(strings.NewReader("small"), 100, dir)
+	if got, _ := ioutil.ReadAll(rs); err != nil || n != 5 || string(got) != "small" {
+		t.Errorf("small %q, n:%v, err:%v", got, n, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("small input spilled")
+	}
+}
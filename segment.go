@@ -0,0 +1,176 @@
+package readall
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// ErrCorruptSegment is returned when a complete record fails its CRC or
+// declares a length above the reader's limit.
+var ErrCorruptSegment = errors.New("readall: corrupt log segment record")
+
+// ErrSegmentTooLarge is returned by AppendSegment for a payload whose length
+// does not fit the uint32 length field.
+var ErrSegmentTooLarge = errors.New("readall: log segment record too large")
+
+// segmentHeaderSize is the length and CRC-32C preceding every record payload.
+const segmentHeaderSize = 8
+
+// DefaultMaxSegmentRecord is the default upper bound for a single record.
+const DefaultMaxSegmentRecord = 64 << 20
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SegmentReader iterates over the records of an append-only log where each
+// record is framed as little-endian uint32 length, uint32 CRC-32C of the
+// payload, and the payload itself.
+//
+//	sr := readall.ReadSegments(f)
+//	for sr.Next() {
+//		handle(sr.Record())
+//	}
+//	if err := sr.Err(); err != nil { ... }
+//
+// A partially written record at the end of the stream (a torn tail) ends the
+// iteration without an error; Torn reports it and Offset tells where the
+// next append should start.
+type SegmentReader struct {
+	// MaxRecord bounds the accepted record length; zero means
+	// DefaultMaxSegmentRecord.
+	MaxRecord int
+
+	r      io.Reader
+	hdr    [segmentHeaderSize]byte
+	buf    []byte
+	rec    []byte
+	off    int64
+	torn   bool
+	err    error
+	closed bool
+}
+
+// ReadSegments returns a SegmentReader over r.
+func ReadSegments(r io.Reader) *SegmentReader {
+	return &SegmentReader{r: r}
+}
+
+// Next advances to the next record, returning false at the end of the
+// stream, at a torn tail, or on error.
+func (s *SegmentReader) Next() bool {
+	if s.closed {
+		return false
+	}
+	n, err := io.ReadFull(s.r, s.hdr[:])
+	if err != nil {
+		return s.stop(n, err)
+	}
+	length := binary.LittleEndian.Uint32(s.hdr[0:4])
+	sum := binary.LittleEndian.Uint32(s.hdr[4:8])
+	max := s.MaxRecord
+	if max <= 0 {
+		max = DefaultMaxSegmentRecord
+	}
+	if uint64(length) > uint64(max) {
+		s.closed, s.err = true, ErrCorruptSegment
+		return false
+	}
+	if cap(s.buf) < int(length) {
+		s.buf = make([]byte, length)
+	}
+	s.rec = s.buf[:length]
+	m, err := io.ReadFull(s.r, s.rec)
+	if err != nil {
+		return s.stop(n+m, err)
+	}
+	if crc32.Checksum(s.rec, castagnoli) != sum {
+		s.closed, s.err = true, ErrCorruptSegment
+		return false
+	}
+	s.off += int64(segmentHeaderSize) + int64(length)
+	return true
+}
+
+func (s *SegmentReader) stop(n int, err error) bool {
+	s.closed = true
+	s.rec = nil
+	switch {
+	case (err == io.EOF || err == io.ErrUnexpectedEOF) && n > 0:
+		// A header without its payload ends in io.EOF from the payload
+		// read, and is torn just like a short header.
+		s.torn = true
+	case err == io.EOF:
+	default:
+		s.err = err
+	}
+	return false
+}
+
+// Record returns the current record. It is only valid until the next call
+// to Next.
+func (s *SegmentReader) Record() []byte {
+	return s.rec
+}
+
+// Offset returns the stream offset just past the last valid record.
+func (s *SegmentReader) Offset() int64 {
+	return s.off
+}
+
+// Torn reports whether iteration stopped at an incomplete trailing record.
+func (s *SegmentReader) Torn() bool {
+	return s.torn
+}
+
+// Err returns the first error other than a clean end or torn tail.
+func (s *SegmentReader) Err() error {
+	return withSource(sourceName(s.r), s.err)
+}
+
+// ResumeSegments seeks r to the offset stored under key in store, or to the
+// start if there is none, and returns a SegmentReader whose Offset continues
+// from there. Pair it with Commit to consume a log at least once across
+// restarts.
+func ResumeSegments(r io.ReadSeeker, store OffsetStore, key string) (*SegmentReader, error) {
+	off, _, err := store.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return nil, withSource(sourceName(r), err)
+	}
+	return &SegmentReader{r: r, off: off}, nil
+}
+
+// Commit records Offset under key in store. Call it once the records read so
+// far have been processed.
+func (s *SegmentReader) Commit(store OffsetStore, key string) error {
+	return store.Store(key, s.off)
+}
+
+// AppendSegment writes p to w as a single framed record. A payload of 4GiB
+// or more fails with ErrSegmentTooLarge before anything is written.
+func AppendSegment(w io.Writer, p []byte) error {
+	length, err := segmentLen(len(p))
+	if err != nil {
+		return err
+	}
+	var hdr [segmentHeaderSize]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], length)
+	binary.LittleEndian.PutUint32(hdr[4:8], crc32.Checksum(p, castagnoli))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// segmentLen returns n as a record length field.
+func segmentLen(n int) (uint32, error) {
+	if uint64(n) > math.MaxUint32 {
+		return 0, ErrSegmentTooLarge
+	}
+	return uint32(n), nil
+}
@@ -0,0 +1,85 @@
+package readall
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestReadSegments(t *testing.T) {
+	var log bytes.Buffer
+	records := []string{"first", "", "third record"}
+	for _, r := range records {
+		if err := AppendSegment(&log, []byte(r)); err != nil {
+			t.Errorf("append err:%v", err)
+			return
+		}
+	}
+	valid := int64(log.Len())
+	if err := AppendSegment(&log, []byte("torn tail")); err != nil {
+		t.Errorf("append err:%v", err)
+		return
+	}
+	log.Truncate(log.Len() - 3)
+
+	sr := ReadSegments(bytes.NewReader(log.Bytes()))
+	var got []string
+	for sr.Next() {
+		got = append(got, string(sr.Record()))
+	}
+	if sr.Err() != nil {
+		t.Errorf("segments err:%v", sr.Err())
+		return
+	}
+	if len(got) != len(records) || got[0] != records[0] || got[2] != records[2] {
+		t.Errorf("records:%q, want:%q", got, records)
+	}
+	if !sr.Torn() || sr.Offset() != valid {
+		t.Errorf("torn:%v, offset:%v, want offset:%v", sr.Torn(), sr.Offset(), valid)
+	}
+}
+
+func TestReadSegmentsMissingPayload(t *testing.T) {
+	var log bytes.Buffer
+	AppendSegment(&log, []byte("kept"))
+	valid := int64(log.Len())
+	AppendSegment(&log, []byte("lost in the crash"))
+	log.Truncate(int(valid) + segmentHeaderSize)
+
+	sr := ReadSegments(bytes.NewReader(log.Bytes()))
+	for sr.Next() {
+	}
+	if sr.Err() != nil || !sr.Torn() || sr.Offset() != valid {
+		t.Errorf("torn:%v, offset:%v, want offset:%v, err:%v", sr.Torn(), sr.Offset(), valid, sr.Err())
+	}
+}
+
+func TestReadSegmentsCorrupt(t *testing.T) {
+	var log bytes.Buffer
+	AppendSegment(&log, []byte("payload"))
+	AppendSegment(&log, []byte("next"))
+	data := log.Bytes()
+	data[segmentHeaderSize] ^= 0xff
+
+	sr := ReadSegments(bytes.NewReader(data))
+	if sr.Next() {
+		t.Errorf("corrupt record accepted:%q", sr.Record())
+	}
+	if sr.Err() != ErrCorruptSegment {
+		t.Errorf("segments err:%v, want:%v", sr.Err(), ErrCorruptSegment)
+	}
+}
+
+func TestSegmentLen(t *testing.T) {
+	if strconv.IntSize < 64 {
+		t.Skip("payloads of 4GiB need 64-bit ints")
+	}
+	max := uint64(math.MaxUint32)
+	if n, err := segmentLen(int(max)); err != nil || n != math.MaxUint32 {
+		t.Errorf("max length:%v, err:%v", n, err)
+	}
+	if _, err := segmentLen(int(max + 1)); err != ErrSegmentTooLarge {
+		t.Errorf("too large err:%v, want:%v", err, ErrSegmentTooLarge)
+	}
+}
@@ -0,0 +1,213 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrShutdown is returned by reads started after their Group, Pool or Budget
+// was shut down, and by reads Shutdown stopped because they did not finish in
+// time.
+var ErrShutdown = errors.New("readall: shut down")
+
+// Group is a set of reads that shut down together, such as the downloads,
+// followers and spill readers of one service component. Reads join it with
+// WithGroup; a Pool has a Group of its own and a Budget shuts down on its
+// own. The zero value is ready to use and a Group is safe for concurrent use.
+type Group struct {
+	mu     sync.Mutex
+	closed chan struct{} // closed by Shutdown
+	reads  map[*trackedRead]struct{}
+	idle   chan struct{} // closed once reads is empty, see Shutdown
+	spills map[*os.File]struct{}
+}
+
+// WithGroup makes the read a member of g, so g.Shutdown waits for it and
+// stops it. Follow and FollowChan return ErrShutdown once g is shut down and
+// they have delivered the data available; ReadAllSpill registers its spill
+// file with g.
+func WithGroup(g *Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// Shutdown stops g from accepting reads: reads joining g from now on fail
+// with ErrShutdown, and so do its followers once idle. Shutdown then waits
+// for the reads of g to finish. When ctx is done first, the remaining reads
+// are stopped like KillRead does, failing with ErrShutdown, and ctx.Err is
+// returned. Either way the spill files of ReadAllSpill readers of g that are
+// still open are removed. Reads outside g are not affected.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	done := g.closing()
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+	idle := g.idle
+	if len(g.reads) == 0 {
+		idle = nil
+	} else if idle == nil {
+		idle = make(chan struct{})
+		g.idle = idle
+	}
+	g.mu.Unlock()
+
+	var err error
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			err = ctx.Err()
+			g.mu.Lock()
+			for t := range g.reads {
+				t.stop(ErrShutdown)
+			}
+			g.mu.Unlock()
+		}
+	}
+	g.removeSpills()
+	return err
+}
+
+// closing returns the channel Shutdown closes. g.mu must be held.
+func (g *Group) closing() chan struct{} {
+	if g.closed == nil {
+		g.closed = make(chan struct{})
+	}
+	return g.closed
+}
+
+// done returns a channel closed once g is shut down, or nil for a nil g.
+func (g *Group) done() <-chan struct{} {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closing()
+}
+
+// join adds t to g, stopping it at once if g is shut down.
+func (g *Group) join(t *trackedRead) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.closing():
+		t.stop(ErrShutdown)
+	default:
+	}
+	if g.reads == nil {
+		g.reads = make(map[*trackedRead]struct{})
+	}
+	g.reads[t] = struct{}{}
+	t.groups = append(t.groups, g)
+}
+
+func (g *Group) leave(t *trackedRead) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.reads, t)
+	if len(g.reads) == 0 && g.idle != nil {
+		close(g.idle)
+		g.idle = nil
+	}
+}
+
+// addSpill registers a spill file for Shutdown to remove.
+func (g *Group) addSpill(f *os.File) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	if g.spills == nil {
+		g.spills = make(map[*os.File]struct{})
+	}
+	g.spills[f] = struct{}{}
+	g.mu.Unlock()
+}
+
+// removeSpill unregisters f, reporting whether Shutdown has not removed it.
+func (g *Group) removeSpill(f *os.File) bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.spills[f]
+	delete(g.spills, f)
+	return ok
+}
+
+func (g *Group) removeSpills() {
+	g.mu.Lock()
+	spills := g.spills
+	g.spills = nil
+	g.mu.Unlock()
+	for f := range spills {
+		f.Close()
+		os.Remove(f.Name())
+	}
+}
+
+// track registers a read of src as track does and adds it to the group of
+// the options, if any.
+func (o *options) track(src interface{}) *trackedRead {
+	t := track(src, o.label, o.tenant)
+	o.group.join(t)
+	return t
+}
+
+// Shutdown stops p from reading: Pool.ReadAll fails with ErrShutdown from
+// now on. It then waits for the reads in progress like Group.Shutdown,
+// stopping them when ctx is done first, and empties the pool.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	err := p.group.Shutdown(ctx)
+	p.Trim()
+	return err
+}
+
+// Shutdown stops b from granting memory: Acquire, TryAcquire and the reads
+// using b fail with ErrShutdown from now on, including those waiting. It
+// then waits until the memory already granted is released or ctx is done,
+// returning ctx.Err in the latter case.
+func (b *Budget) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed == nil {
+		b.closed = make(chan struct{})
+		b.drained = make(chan struct{})
+		for _, w := range b.waiters {
+			w.err = ErrShutdown
+			close(w.ready)
+		}
+		b.waiters = nil
+		b.checkDrained()
+	}
+	drained := b.drained
+	b.mu.Unlock()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkDrained signals Shutdown once a closed budget has no memory in use.
+func (b *Budget) checkDrained() {
+	if b.closed == nil || b.used > 0 {
+		return
+	}
+	select {
+	case <-b.drained:
+	default:
+		close(b.drained)
+	}
+}
@@ -0,0 +1,161 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroupShutdown(t *testing.T) {
+	var g Group
+	spill, _, err := ReadAllSpill(bytes.NewReader(make([]byte, 100)), 10, "", WithGroup(&g))
+	if err != nil {
+		t.Fatalf("spill err:%v", err)
+	}
+	name := spill.(*seekable).spill.Name()
+
+	finished, stuck := io.Pipe()
+	hung, hungW := io.Pipe()
+	results := make(chan error, 2)
+	for _, r := range []io.Reader{finished, hung} {
+		go func(r io.Reader) {
+			_, err := ReadAll(r, WithGroup(&g))
+			results <- err
+		}(r)
+	}
+	for groupReads(&g) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		stuck.Write([]byte("last words"))
+		stuck.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("shutdown err:%v, want:%v", err, context.DeadlineExceeded)
+	}
+	// Unblock the killed read.
+	hungW.Close()
+	got := []error{<-results, <-results}
+	if got[0] != nil && got[1] != nil || got[0] != ErrShutdown && got[1] != ErrShutdown {
+		t.Errorf("read errs:%v, want nil and %v", got, ErrShutdown)
+	}
+	if _, err := ReadAll(strings.NewReader("late"), WithGroup(&g)); err != ErrShutdown {
+		t.Errorf("late read err:%v, want:%v", err, ErrShutdown)
+	}
+	if _, err := ReadAll(strings.NewReader("outside")); err != nil {
+		t.Errorf("read outside the group err:%v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spill file left, stat err:%v", err)
+	}
+	if err := spill.Close(); err != nil {
+		t.Errorf("spill close err:%v", err)
+	}
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Errorf("idle shutdown err:%v", err)
+	}
+}
+
+func groupReads(g *Group) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.reads)
+}
+
+func TestPoolShutdown(t *testing.T) {
+	var p Pool
+	pr, pw := io.Pipe()
+	result := make(chan error, 1)
+	go func() {
+		data, release, err := p.ReadAll(pr)
+		if err == nil && string(data) != "pooled" {
+			err = errors.New("wrong data")
+		}
+		release()
+		result <- err
+	}()
+	for groupReads(&p.group) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		pw.Write([]byte("pooled"))
+		pw.Close()
+	}()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown err:%v", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("drained read err:%v", err)
+	}
+	if _, release, err := p.ReadAll(strings.NewReader("late")); err != ErrShutdown {
+		t.Errorf("late read err:%v, want:%v", err, ErrShutdown)
+	} else {
+		release()
+	}
+	var other Pool
+	if _, release, err := other.ReadAll(strings.NewReader("other")); err != nil {
+		t.Errorf("other pool err:%v", err)
+	} else {
+		release()
+	}
+}
+
+func TestFollowGroupShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	ioutil.WriteFile(path, []byte("line\n"), 0644)
+	var g Group
+	g.Shutdown(context.Background())
+	var got []byte
+	err := Follow(context.Background(), path, 0, func(off int64, data []byte) error {
+		got = append(got, data...)
+		return nil
+	}, WithGroup(&g), WithPollInterval(time.Millisecond))
+	if !errors.Is(err, ErrShutdown) || string(got) != "line\n" {
+		t.Errorf("follow:%q, err:%v, want:%v", got, err, ErrShutdown)
+	}
+}
+
+func TestBudgetShutdown(t *testing.T) {
+	b := NewBudget(10)
+	if err := b.Acquire(context.Background(), 8); err != nil {
+		t.Fatalf("acquire err:%v", err)
+	}
+	waiting := make(chan error)
+	go func() { waiting <- b.Acquire(context.Background(), 5) }()
+	for {
+		b.mu.Lock()
+		n := len(b.waiters)
+		b.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan error)
+	go func() { done <- b.Shutdown(context.Background()) }()
+	if err := <-waiting; err != ErrShutdown {
+		t.Errorf("waiter err:%v, want:%v", err, ErrShutdown)
+	}
+	if _, err := ReadAll(strings.NewReader("x"), WithBudgetFailFast(b)); err != ErrShutdown {
+		t.Errorf("read err:%v, want:%v", err, ErrShutdown)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown returned early err:%v", err)
+	default:
+	}
+	b.Release(8)
+	if err := <-done; err != nil {
+		t.Errorf("shutdown err:%v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package readall
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// sizeHint reports how many bytes r is expected to yield before EOF, if r
+// exposes it: Len (bytes and strings readers and buffers), a regular
+// *os.File, Size (HTTP bodies opened by this package), or an io.Seeker. An
+// *io.LimitedReader yields at most its remaining limit.
+func sizeHint(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case *io.LimitedReader:
+		if v.N <= 0 {
+			return 0, true
+		}
+		if n, ok := sizeHint(v.R); ok {
+			return min64(n, v.N), true
+		}
+		return 0, false
+	case interface{ Len() int }:
+		return int64(v.Len()), true
+	case *os.File:
+		fi, err := v.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false
+		}
+		cur, err := v.Seek(0, io.SeekCurrent)
+		if err != nil || cur > fi.Size() {
+			return 0, false
+		}
+		return fi.Size() - cur, true
+	case interface{ Size() int64 }:
+		n := v.Size()
+		return n, n >= 0
+	case io.Seeker:
+		cur, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false
+		}
+		if _, err := v.Seek(cur, io.SeekStart); err != nil || end < cur {
+			return 0, false
+		}
+		return end - cur, true
+	}
+	return 0, false
+}
+
+// Size reports the payload size of src without reading its data, using
+// Stat for files, Content-Length from a HEAD request (or a one-byte Range
+// request where HEAD is refused) for HTTP, and Stat or Seek for standard
+// input, so callers can choose between reading into memory and streaming
+// before committing memory. ok is false when the size cannot be known up
+// front.
+func Size(ctx context.Context, src Source) (n int64, ok bool, err error) {
+	n, err = src.Size(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	return n, n >= 0, nil
+}
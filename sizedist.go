@@ -0,0 +1,138 @@
+package readall
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// Size histogram buckets: 8 linear sub-buckets per power of two, so a
+// reported percentile is within 12.5% of the true size.
+const (
+	sizeSubBits    = 3
+	sizeSubBuckets = 1 << sizeSubBits
+	sizeBuckets    = (64 - sizeSubBits + 1) * sizeSubBuckets
+)
+
+// maxSizeLabels bounds how many labels get a histogram of their own.
+const maxSizeLabels = 1000
+
+// SizePercentiles summarizes the payload sizes of completed reads.
+type SizePercentiles struct {
+	Count int64
+	P50   int64
+	P90   int64
+	P99   int64
+	Max   int64
+}
+
+// SizeReport is returned by SizeDistribution.
+type SizeReport struct {
+	// All covers every read made through the package since start.
+	All SizePercentiles
+	// ByLabel covers reads made with WithLabel, keyed by label.
+	ByLabel map[string]SizePercentiles
+}
+
+type sizeHistogram struct {
+	counts [sizeBuckets]int64
+	max    int64
+}
+
+var (
+	allSizes   sizeHistogram
+	labelSizes sync.Map // label -> *sizeHistogram
+	labelCount int64
+)
+
+// SizeDistribution reports percentiles of the sizes read since the process
+// started, for capacity planning. Percentiles are bucket upper bounds.
+func SizeDistribution() SizeReport {
+	rep := SizeReport{All: allSizes.percentiles(), ByLabel: make(map[string]SizePercentiles)}
+	labelSizes.Range(func(k, v interface{}) bool {
+		rep.ByLabel[k.(string)] = v.(*sizeHistogram).percentiles()
+		return true
+	})
+	return rep
+}
+
+// observeSize records one completed read of n bytes.
+func observeSize(label string, n int64) {
+	allSizes.add(n)
+	if label == "" {
+		return
+	}
+	h, ok := labelSizes.Load(label)
+	if !ok {
+		if atomic.LoadInt64(&labelCount) >= maxSizeLabels {
+			return
+		}
+		var loaded bool
+		if h, loaded = labelSizes.LoadOrStore(label, new(sizeHistogram)); !loaded {
+			atomic.AddInt64(&labelCount, 1)
+		}
+	}
+	h.(*sizeHistogram).add(n)
+}
+
+func sizeBucket(n int64) int {
+	if n < sizeSubBuckets {
+		return int(n)
+	}
+	exp := bits.Len64(uint64(n)) - 1 - sizeSubBits
+	return (exp+1)*sizeSubBuckets + int(n>>uint(exp))&(sizeSubBuckets-1)
+}
+
+// sizeBucketMax returns the largest size falling into bucket i.
+func sizeBucketMax(i int) int64 {
+	if i < sizeSubBuckets {
+		return int64(i)
+	}
+	exp := uint(i/sizeSubBuckets - 1)
+	sub := int64(i%sizeSubBuckets + sizeSubBuckets)
+	return (sub+1)<<exp - 1
+}
+
+func (h *sizeHistogram) add(n int64) {
+	if n < 0 {
+		return
+	}
+	atomic.AddInt64(&h.counts[sizeBucket(n)], 1)
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if n <= max || atomic.CompareAndSwapInt64(&h.max, max, n) {
+			return
+		}
+	}
+}
+
+func (h *sizeHistogram) percentiles() SizePercentiles {
+	var counts [sizeBuckets]int64
+	var p SizePercentiles
+	for i := range counts {
+		counts[i] = atomic.LoadInt64(&h.counts[i])
+		p.Count += counts[i]
+	}
+	p.Max = atomic.LoadInt64(&h.max)
+	if p.Count == 0 {
+		return p
+	}
+	targets := []struct {
+		dst  *int64
+		rank int64
+	}{
+		{&p.P50, (p.Count*50 + 99) / 100},
+		{&p.P90, (p.Count*90 + 99) / 100},
+		{&p.P99, (p.Count*99 + 99) / 100},
+	}
+	var seen int64
+	t := 0
+	for i := 0; i < sizeBuckets && t < len(targets); i++ {
+		seen += counts[i]
+		for t < len(targets) && seen >= targets[t].rank {
+			*targets[t].dst = min64(sizeBucketMax(i), p.Max)
+			t++
+		}
+	}
+	return p
+}
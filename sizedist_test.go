@@ -0,0 +1,44 @@
+package readall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSizeBuckets(t *testing.T) {
+	for _, n := range []int64{0, 1, 7, 8, 9, 15, 16, 1000, 1 << 20, 1<<20 + 12345, 1<<62 + 5} {
+		i := sizeBucket(n)
+		if max := sizeBucketMax(i); n > max || (i > 0 && n <= sizeBucketMax(i-1)) {
+			t.Errorf("size %v in bucket %v with max %v", n, i, max)
+		}
+		if max := sizeBucketMax(i); max-n > n/8 {
+			t.Errorf("size %v bucket max %v too coarse", n, max)
+		}
+	}
+}
+
+func TestSizeDistribution(t *testing.T) {
+	var h sizeHistogram
+	for i := int64(1); i <= 100; i++ {
+		h.add(i * 100)
+	}
+	p := h.percentiles()
+	if p.Count != 100 || p.Max != 10000 {
+		t.Errorf("count:%v, max:%v", p.Count, p.Max)
+	}
+	within := func(got, want int64) bool { return got >= want && got <= want+want/8 }
+	if !within(p.P50, 5000) || !within(p.P90, 9000) || !within(p.P99, 9900) {
+		t.Errorf("percentiles:%+v", p)
+	}
+
+	before := SizeDistribution().All.Count
+	ReadAll(strings.NewReader("abc"))
+	labelReport := func() SizePercentiles { return SizeDistribution().ByLabel["sizedist-test"] }
+	From(BytesSource("hello")).Options(WithLabel("sizedist-test")).Bytes()
+	if got := SizeDistribution().All.Count; got < before+2 {
+		t.Errorf("all count:%v, before:%v", got, before)
+	}
+	if p := labelReport(); p.Count != 1 || p.Max != 5 {
+		t.Errorf("label percentiles:%+v", p)
+	}
+}
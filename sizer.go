@@ -0,0 +1,111 @@
+package readall
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// sizerRefresh is how many observations of a name pass between updates of
+// its estimate once it has that many.
+const sizerRefresh = 16
+
+// Sizer learns the payload sizes of reads by name, such as an endpoint, and
+// presizes the buffer of later reads of that name whose reader does not
+// reveal its size, so stable sizes stop costing grow-and-copy cycles. It
+// keeps a size histogram per name like SizeDistribution and presizes to
+// the 90th percentile, within 12.5% above it; larger payloads still grow.
+// A Sizer tracks at most 1000 names. The zero Sizer is ready to use and it
+// is safe for concurrent use.
+type Sizer struct {
+	names sync.Map // name -> *sizerEntry
+	count int64
+}
+
+type sizerEntry struct {
+	hist     sizeHistogram
+	n        int64
+	estimate int64 // 0 until the first observation
+}
+
+// WithSizer presizes the read from what s learned of name, and teaches s the
+// size of the read once it succeeds. An empty name uses the label of
+// WithLabel. WithInitialCapacity and a size the reader reveals take
+// precedence.
+func WithSizer(s *Sizer, name string) Option {
+	return func(o *options) {
+		o.sizer = s
+		o.sizerName = name
+	}
+}
+
+// Observe records a payload of n bytes read under name.
+func (s *Sizer) Observe(name string, n int64) {
+	e := s.entry(name, true)
+	if e == nil || n < 0 {
+		return
+	}
+	e.hist.add(n)
+	if c := atomic.AddInt64(&e.n, 1); c <= sizerRefresh || c%sizerRefresh == 0 {
+		atomic.StoreInt64(&e.estimate, e.hist.percentiles().P90)
+	}
+}
+
+// Estimate returns the buffer size reads of name are presized to, and
+// whether name was observed.
+func (s *Sizer) Estimate(name string) (int64, bool) {
+	e := s.entry(name, false)
+	if e == nil || atomic.LoadInt64(&e.n) == 0 {
+		return 0, false
+	}
+	return atomic.LoadInt64(&e.estimate), true
+}
+
+func (s *Sizer) entry(name string, create bool) *sizerEntry {
+	if s == nil {
+		return nil
+	}
+	e, ok := s.names.Load(name)
+	if !ok {
+		if !create || atomic.LoadInt64(&s.count) >= maxSizeLabels {
+			return nil
+		}
+		var loaded bool
+		if e, loaded = s.names.LoadOrStore(name, new(sizerEntry)); !loaded {
+			atomic.AddInt64(&s.count, 1)
+		}
+	}
+	return e.(*sizerEntry)
+}
+
+// sizerKey returns the name reads of o are learned under.
+func (o *options) sizerKey() string {
+	if o.sizerName != "" {
+		return o.sizerName
+	}
+	return o.label
+}
+
+// learnedCapacity returns the buffer size the Sizer of o suggests for r,
+// if r does not reveal its size.
+func (o *options) learnedCapacity(r io.Reader) (int64, bool) {
+	if o.sizer == nil || o.growth.initial > 0 {
+		return 0, false
+	}
+	est, ok := o.sizer.Estimate(o.sizerKey())
+	if !ok {
+		return 0, false
+	}
+	if _, known := sizeHint(r); known {
+		return 0, false
+	}
+	// One spare byte lets the final Read report EOF without growing.
+	return est + 1, true
+}
+
+// learnSize teaches the Sizer of o the size of a successful read.
+func (o *options) learnSize(n int64) {
+	if o.sizer != nil {
+		o.sizer.Observe(o.sizerKey(), n)
+	}
+}
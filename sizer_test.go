@@ -0,0 +1,58 @@
+package readall
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// unsized hides the size of a reader.
+type unsized struct{ io.Reader }
+
+func TestSizer(t *testing.T) {
+	var s Sizer
+	if _, ok := s.Estimate("api"); ok {
+		t.Errorf("estimate before observing")
+	}
+	body := strings.Repeat("x", 10000)
+	var st Stats
+	ReadAll(unsized{strings.NewReader(body)}, WithSizer(&s, "api"), WithStats(&st))
+	if st.Grows == 0 {
+		t.Errorf("first read did not grow:%+v", st)
+	}
+	est, ok := s.Estimate("api")
+	if !ok || est < 10000 || est > 10000*9/8 {
+		t.Errorf("estimate:%v, ok:%v", est, ok)
+	}
+	for _, read := range []func(opts ...Option) ([]byte, error){
+		func(opts ...Option) ([]byte, error) { return ReadAll(unsized{strings.NewReader(body)}, opts...) },
+		func(opts ...Option) ([]byte, error) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			return ReadAllContext(ctx, unsized{strings.NewReader(body)}, opts...)
+		},
+	} {
+		st = Stats{}
+		data, err := read(WithSizer(&s, "api"), WithStats(&st))
+		if err != nil || len(data) != 10000 || st.Grows != 0 {
+			t.Errorf("learned read len:%v, err:%v, stats:%+v", len(data), err, st)
+		}
+	}
+}
+
+func TestSizerLabelAndLimit(t *testing.T) {
+	var s Sizer
+	ReadAll(strings.NewReader(strings.Repeat("y", 5000)), WithSizer(&s, ""), WithLabel("users"))
+	if est, ok := s.Estimate("users"); !ok || est < 5000 {
+		t.Errorf("label estimate:%v, ok:%v", est, ok)
+	}
+	o := readOptions([]Option{WithSizer(&s, "users"), WithLimit(100)})
+	if n := o.capacity(unsized{strings.NewReader("")}); n != 101 {
+		t.Errorf("limited capacity:%v, want:101", n)
+	}
+	o = readOptions([]Option{WithSizer(&s, "users")})
+	if n := o.capacity(strings.NewReader("abc")); n != 4 {
+		t.Errorf("sized capacity:%v, want:4", n)
+	}
+}
@@ -0,0 +1,59 @@
+package readall
+
+import (
+	"os"
+)
+
+// ReadSmallFiles reads every file in paths into a Bundle keyed by path. It is
+// meant for many small files: one stat pass sizes a single slab for all of
+// them, so the contents need one allocation in total instead of one or more
+// per file. A file that grew since it was stated still reads completely, into
+// extra slab space. The first failure is returned with the path that caused
+// it; with WithErrorPolicy(CollectAll) failing files are left out of the
+// bundle instead and reported together in a *BatchError. WithStatCache
+// replaces the stat pass with cache lookups.
+func ReadSmallFiles(paths []string, opts ...Option) (*Bundle, error) {
+	var o options
+	o.apply(opts)
+	errs := batchErrors{policy: o.errPolicy}
+	sizes := make([]int64, len(paths))
+	failed := make([]bool, len(paths))
+	var total int64
+	for i, path := range paths {
+		fi, err := o.stat(path)
+		if errs.add(err) {
+			return nil, err
+		}
+		if err != nil {
+			failed[i] = true
+			continue
+		}
+		if fi.Mode().IsRegular() {
+			sizes[i] = fi.Size()
+			total += fi.Size()
+		}
+	}
+	slab := DefaultBundleSlab
+	if total > 0 && total < int64(^uint(0)>>1) {
+		slab = int(total)
+	}
+	b := NewBundle(slab)
+	for i, path := range paths {
+		if failed[i] {
+			continue
+		}
+		if err := readSmallFile(b, path, sizes[i]); errs.add(err) {
+			return nil, err
+		}
+	}
+	return b, errs.err()
+}
+
+func readSmallFile(b *Bundle, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return withSource(path, b.add(path, f, size))
+}
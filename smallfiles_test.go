@@ -0,0 +1,59 @@
+package readall
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSmallFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 200; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%03d", i))
+		ioutil.WriteFile(path, []byte(strings.Repeat("x", i)), 0644)
+		paths = append(paths, path)
+	}
+	b, err := ReadSmallFiles(paths)
+	if err != nil {
+		t.Errorf("read err:%v", err)
+		return
+	}
+	if b.Len() != len(paths) || len(b.slabs) != 1 {
+		t.Errorf("entries:%v, slabs:%v", b.Len(), len(b.slabs))
+	}
+	for i, path := range paths {
+		if got, _ := b.Get(path); len(got) != i {
+			t.Errorf("%v len:%v, want:%v", path, len(got), i)
+		}
+	}
+
+	_, err = ReadSmallFiles(append(paths, filepath.Join(dir, "missing")))
+	if !os.IsNotExist(err) || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("missing err:%v", err)
+	}
+}
+
+func TestReadSmallFilesCollectAll(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	ioutil.WriteFile(good, []byte("good"), 0644)
+	paths := []string{filepath.Join(dir, "missing1"), good, filepath.Join(dir, "missing2")}
+	b, err := ReadSmallFiles(paths, WithErrorPolicy(CollectAll))
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Errs) != 2 || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("collect err:%v", err)
+	}
+	// Is and As match the members without the Go 1.20 multi-error Unwrap.
+	var pe *os.PathError
+	if be == nil || !be.Is(os.ErrNotExist) || be.Is(os.ErrExist) || !be.As(&pe) || !strings.Contains(pe.Path, "missing1") {
+		t.Errorf("batch members not matched, err:%v", err)
+	}
+	if got, ok := b.Get(good); !ok || string(got) != "good" || b.Len() != 1 {
+		t.Errorf("good:%q, entries:%v", got, b.Len())
+	}
+}
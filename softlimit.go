@@ -0,0 +1,60 @@
+package readall
+
+import "io"
+
+// WithSoftLimit calls fn once, with the bytes read so far, when a read
+// passes n bytes, as an early warning before the hard limit of WithLimit is
+// reached. fn runs on the goroutine doing the reads and decides whether
+// the read goes on: returning false stops it with a *LimitError for n.
+//
+//	readall.WithSoftLimit(8<<20, func(read int64) bool {
+//		log.Printf("payload over 8MiB: %d bytes so far", read)
+//		return true
+//	})
+func WithSoftLimit(n int64, fn func(read int64) bool) Option {
+	return func(o *options) {
+		o.softLimit, o.softFn = n, fn
+	}
+}
+
+// withSoftLimit wraps r if o sets a soft limit.
+func (o *options) withSoftLimit(r io.Reader) io.Reader {
+	if o.softFn == nil {
+		return r
+	}
+	return &softLimitReader{r: r, limit: o.softLimit, fn: o.softFn}
+}
+
+type softLimitReader struct {
+	r      io.Reader
+	limit  int64
+	fn     func(read int64) bool
+	n      int64
+	passed bool
+	err    error
+}
+
+func (s *softLimitReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	if !s.passed && s.n > s.limit {
+		s.passed = true
+		if !s.fn(s.n) {
+			// Keep the bytes up to the limit, like the hard limit does.
+			keep := n - int(s.n-s.limit)
+			if keep < 0 {
+				keep = 0
+			}
+			s.err = &LimitError{Limit: s.limit, Read: s.n}
+			return keep, s.err
+		}
+	}
+	return n, err
+}
+
+func (s *softLimitReader) Unwrap() io.Reader {
+	return s.r
+}
@@ -0,0 +1,35 @@
+package readall
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWithSoftLimit(t *testing.T) {
+	data := make([]byte, 1000)
+	var calls []int64
+	warn := func(read int64) bool {
+		calls = append(calls, read)
+		return true
+	}
+	got, err := ReadAll(iotest.HalfReader(bytes.NewReader(data)), WithSoftLimit(100, warn), WithLimit(2000))
+	if err != nil || len(got) != len(data) {
+		t.Errorf("len:%v, err:%v", len(got), err)
+	}
+	if len(calls) != 1 || calls[0] <= 100 {
+		t.Errorf("calls:%v", calls)
+	}
+
+	got, err = ReadAll(bytes.NewReader(data), WithSoftLimit(100, func(int64) bool { return false }))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != 100 || len(got) != 100 {
+		t.Errorf("stop len:%v, err:%v", len(got), err)
+	}
+
+	calls = nil
+	if _, err := ReadAll(bytes.NewReader(data), WithSoftLimit(1000, warn)); err != nil || len(calls) != 0 {
+		t.Errorf("at limit calls:%v, err:%v", calls, err)
+	}
+}
@@ -0,0 +1,362 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrRangeUnsupported is returned by OpenAt when the backend cannot start
+// reading at an offset.
+var ErrRangeUnsupported = errors.New("readall: source does not support ranged reads")
+
+// Source is a re-openable data source. Features that need to retry, resume or
+// split a read operate over a Source rather than a one-shot io.Reader, so any
+// backend implementing it gets them.
+type Source interface {
+	// Open starts a read from the beginning.
+	Open(ctx context.Context) (io.ReadCloser, error)
+	// OpenAt starts a read at byte offset off.
+	OpenAt(ctx context.Context, off int64) (io.ReadCloser, error)
+	// Size returns the payload size, or -1 if it is not known up front.
+	Size(ctx context.Context) (int64, error)
+}
+
+// RangeSource is a Source that can also read a bounded range, which lets
+// DownloadSource split a read into concurrent parts.
+type RangeSource interface {
+	Source
+	// OpenRange starts a read of the n bytes at off.
+	OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error)
+}
+
+// FileSource is a Source reading the named file.
+type FileSource string
+
+func (f FileSource) String() string {
+	return string(f)
+}
+
+// Open implements Source.
+func (f FileSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return f.OpenAt(ctx, 0)
+}
+
+// OpenAt implements Source.
+func (f FileSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, err
+	}
+	if off > 0 {
+		if _, err := file.Seek(off, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// OpenRange implements RangeSource.
+func (f FileSource) OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, err
+	}
+	return sectionReadCloser{io.NewSectionReader(file, off, n), file}, nil
+}
+
+// sectionReadCloser reads a section of a file and closes the file.
+type sectionReadCloser struct {
+	*io.SectionReader
+	io.Closer
+}
+
+// Size implements Source.
+func (f FileSource) Size(ctx context.Context) (int64, error) {
+	fi, err := os.Stat(string(f))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// BytesSource is a Source over an in-memory payload.
+type BytesSource []byte
+
+// Open implements Source.
+func (b BytesSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return b.OpenAt(ctx, 0)
+}
+
+// OpenAt implements Source.
+func (b BytesSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, ErrNegativeOffset
+	}
+	if off > int64(len(b)) {
+		off = int64(len(b))
+	}
+	return bytesReadCloser{bytes.NewReader(b[off:])}, nil
+}
+
+// OpenRange implements RangeSource.
+func (b BytesSource) OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, ErrNegativeOffset
+	}
+	if off > int64(len(b)) {
+		off = int64(len(b))
+	}
+	if n > int64(len(b))-off {
+		n = int64(len(b)) - off
+	}
+	return bytesReadCloser{bytes.NewReader(b[off : off+n])}, nil
+}
+
+// bytesReadCloser keeps the Len method of bytes.Reader visible for size hints.
+type bytesReadCloser struct {
+	*bytes.Reader
+}
+
+func (bytesReadCloser) Close() error {
+	return nil
+}
+
+// Size implements Source.
+func (b BytesSource) Size(ctx context.Context) (int64, error) {
+	return int64(len(b)), nil
+}
+
+// HTTPSource is a RangeSource fetching URL with GET requests, using Range
+// requests for OpenAt and OpenRange and HEAD for Size. A nil Client means
+// http.DefaultClient.
+type HTTPSource struct {
+	Client *http.Client
+	URL    string
+	Header http.Header
+}
+
+func (h *HTTPSource) String() string {
+	return h.URL
+}
+
+// Open implements Source.
+func (h *HTTPSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return h.OpenAt(ctx, 0)
+}
+
+// OpenAt implements Source.
+func (h *HTTPSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	req, err := h.request(ctx, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	if off > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	}
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return nil, withSource(h.URL, err)
+	}
+	switch {
+	case off > 0 && rsp.StatusCode == http.StatusPartialContent:
+	case off > 0 && rsp.StatusCode == http.StatusOK:
+		rsp.Body.Close()
+		return nil, ErrRangeUnsupported
+	case rsp.StatusCode != http.StatusOK:
+		rsp.Body.Close()
+		return nil, withSource(h.URL, &statusError{code: rsp.StatusCode, status: rsp.Status})
+	}
+	return &httpBody{ReadCloser: rsp.Body, size: rsp.ContentLength}, nil
+}
+
+// OpenRange implements RangeSource. It returns ErrRangeUnsupported when the
+// server answers with the whole resource.
+func (h *HTTPSource) OpenRange(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+	req, err := h.request(ctx, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+n-1))
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return nil, withSource(h.URL, err)
+	}
+	switch rsp.StatusCode {
+	case http.StatusPartialContent:
+		return &httpBody{ReadCloser: rsp.Body, size: rsp.ContentLength}, nil
+	case http.StatusOK:
+		rsp.Body.Close()
+		return nil, ErrRangeUnsupported
+	}
+	rsp.Body.Close()
+	return nil, withSource(h.URL, &statusError{code: rsp.StatusCode, status: rsp.Status})
+}
+
+// httpBody exposes the response Content-Length as a size hint and reports a
+// body ending early as a *LengthError.
+type httpBody struct {
+	io.ReadCloser
+	size int64
+	read int64
+}
+
+func (b *httpBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if (err == io.EOF || err == io.ErrUnexpectedEOF) && b.size >= 0 && b.read != b.size {
+		err = &LengthError{Declared: b.size, Read: b.read}
+	}
+	return n, err
+}
+
+func (b *httpBody) Unwrap() io.Reader {
+	return b.ReadCloser
+}
+
+func (b *httpBody) Size() int64 {
+	return b.size
+}
+
+// Size implements Source. Servers refusing HEAD, or not sending a
+// Content-Length for it, are asked for the first byte with a Range request
+// and the size is taken from Content-Range.
+func (h *HTTPSource) Size(ctx context.Context) (int64, error) {
+	req, err := h.request(ctx, http.MethodHead)
+	if err != nil {
+		return 0, err
+	}
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return 0, withSource(h.URL, err)
+	}
+	rsp.Body.Close()
+	switch {
+	case rsp.StatusCode == http.StatusOK && rsp.ContentLength >= 0:
+		return rsp.ContentLength, nil
+	case rsp.StatusCode == http.StatusOK, rsp.StatusCode == http.StatusMethodNotAllowed, rsp.StatusCode == http.StatusNotImplemented:
+		return h.rangeSize(ctx)
+	}
+	return 0, withSource(h.URL, fmt.Errorf("readall: HEAD: %s", rsp.Status))
+}
+
+// rangeSize requests the first byte of the resource to learn its size from
+// Content-Range, or -1 if the server does not tell.
+func (h *HTTPSource) rangeSize(ctx context.Context) (int64, error) {
+	req, err := h.request(ctx, http.MethodGet)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	rsp, err := h.client().Do(req)
+	if err != nil {
+		return 0, withSource(h.URL, err)
+	}
+	defer rsp.Body.Close()
+	switch rsp.StatusCode {
+	case http.StatusPartialContent:
+		cr := rsp.Header.Get("Content-Range")
+		if i := strings.LastIndexByte(cr, '/'); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		return -1, nil
+	case http.StatusOK:
+		return rsp.ContentLength, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Only an empty resource has no first byte.
+		return 0, nil
+	}
+	return 0, withSource(h.URL, fmt.Errorf("readall: GET: %s", rsp.Status))
+}
+
+func (h *HTTPSource) request(ctx context.Context, method string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	return req, nil
+}
+
+func (h *HTTPSource) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	return http.DefaultClient
+}
+
+// SourceReader returns a ReadSeekCloser over src that opens it lazily and
+// reopens it at the new offset after a Seek, which lets io.ReadSeeker based
+// features such as ReadAllResumable run over any Source.
+func SourceReader(ctx context.Context, src Source) io.ReadSeekCloser {
+	return &sourceReader{ctx: ctx, src: src}
+}
+
+type sourceReader struct {
+	ctx context.Context
+	src Source
+	rc  io.ReadCloser
+	pos int64
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	if s.rc == nil {
+		rc, err := s.src.OpenAt(s.ctx, s.pos)
+		if err != nil {
+			return 0, withSource(sourceName(s.src), err)
+		}
+		s.rc = rc
+	}
+	n, err := s.rc.Read(p)
+	s.pos += int64(n)
+	return n, withSource(sourceName(s.src), err)
+}
+
+func (s *sourceReader) Seek(offset int64, whence int) (int64, error) {
+	abs := offset
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		abs += s.pos
+	case io.SeekEnd:
+		size, err := s.src.Size(s.ctx)
+		if err != nil {
+			return s.pos, err
+		}
+		if size < 0 {
+			return s.pos, errors.New("readall: source size unknown")
+		}
+		abs += size
+	default:
+		return s.pos, errors.New("readall: invalid seek whence")
+	}
+	if abs < 0 {
+		return s.pos, ErrNegativeOffset
+	}
+	if abs != s.pos && s.rc != nil {
+		s.rc.Close()
+		s.rc = nil
+	}
+	s.pos = abs
+	return abs, nil
+}
+
+func (s *sourceReader) Close() error {
+	if s.rc == nil {
+		return nil
+	}
+	err := s.rc.Close()
+	s.rc = nil
+	return err
+}
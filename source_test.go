@@ -0,0 +1,92 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSources(t *testing.T) {
+	data := []byte(strings.Repeat("source data ", 1000))
+	path := filepath.Join(t.TempDir(), "data")
+	ioutil.WriteFile(path, data, 0644)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	sources := map[string]Source{
+		"file":  FileSource(path),
+		"bytes": BytesSource(data),
+		"http":  &HTTPSource{URL: srv.URL},
+	}
+	for name, src := range sources {
+		size, err := src.Size(ctx)
+		if err != nil || size != int64(len(data)) {
+			t.Errorf("%v size:%v, err:%v", name, size, err)
+		}
+		rc, err := src.OpenAt(ctx, 100)
+		if err != nil {
+			t.Errorf("%v open err:%v", name, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil || !bytes.Equal(got, data[100:]) {
+			t.Errorf("%v ranged read len:%v, err:%v", name, len(got), err)
+		}
+	}
+}
+
+func TestSourceReader(t *testing.T) {
+	data := []byte("0123456789")
+	r := SourceReader(context.Background(), BytesSource(data))
+	defer r.Close()
+	buf := make([]byte, 3)
+	io.ReadFull(r, buf)
+	if _, err := r.Seek(-2, io.SeekEnd); err != nil {
+		t.Errorf("seek err:%v", err)
+		return
+	}
+	rest, _ := ioutil.ReadAll(r)
+	if string(buf) != "012" || string(rest) != "89" {
+		t.Errorf("read:%q, after seek:%q", buf, rest)
+	}
+
+	state := filepath.Join(t.TempDir(), "state")
+	got, err := ReadAllResumable(SourceReader(context.Background(), BytesSource(data)), state, ResumeOptions{})
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("resumable over source:%q, err:%v", got, err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	data := make([]byte, 1234)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.ServeContent(w, r, "data", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	if n, ok, err := Size(ctx, &HTTPSource{URL: srv.URL}); err != nil || !ok || n != 1234 {
+		t.Errorf("range size:%v, ok:%v, err:%v", n, ok, err)
+	}
+	if n, ok, err := Size(ctx, BytesSource(data)); err != nil || !ok || n != 1234 {
+		t.Errorf("bytes size:%v, ok:%v, err:%v", n, ok, err)
+	}
+	if _, _, err := Size(ctx, FileSource(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Errorf("missing file size without error")
+	}
+}
@@ -0,0 +1,94 @@
+package readall
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// StatCache caches os.Stat results for ttl, so size lookups on hot paths do
+// not reach the file system on every read. Stale sizes are only hints: reads
+// still run to EOF, so a file that changed within ttl is read correctly,
+// possibly with an extra allocation. A StatCache is safe for concurrent use.
+type StatCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]statEntry
+}
+
+type statEntry struct {
+	fi      os.FileInfo
+	expires time.Time
+}
+
+// NewStatCache returns a StatCache keeping results for ttl.
+func NewStatCache(ttl time.Duration) *StatCache {
+	return &StatCache{ttl: ttl, now: time.Now, entries: make(map[string]statEntry)}
+}
+
+// Stat returns the cached FileInfo of path, calling os.Stat if there is no
+// fresh entry. Errors are not cached.
+func (c *StatCache) Stat(path string) (os.FileInfo, error) {
+	now := c.now()
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.fi, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		c.Invalidate(path)
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[path] = statEntry{fi: fi, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return fi, nil
+}
+
+// Invalidate drops the entry for path, e.g. after writing the file.
+func (c *StatCache) Invalidate(path string) {
+	c.mu.Lock()
+	delete(c.entries, path)
+	c.mu.Unlock()
+}
+
+// InvalidateAll drops every entry.
+func (c *StatCache) InvalidateAll() {
+	c.mu.Lock()
+	c.entries = make(map[string]statEntry)
+	c.mu.Unlock()
+}
+
+// statEntrySize approximates the memory an entry holds besides its path.
+const statEntrySize = 160
+
+// Trim drops every entry and returns about how many bytes they held.
+func (c *StatCache) Trim() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for path := range c.entries {
+		n += int64(len(path)) + statEntrySize
+	}
+	c.entries = make(map[string]statEntry)
+	return n
+}
+
+// WithStatCache makes file reads look up sizes through c.
+func WithStatCache(c *StatCache) Option {
+	return func(o *options) {
+		o.stats = c
+	}
+}
+
+// stat calls os.Stat, or the configured StatCache.
+func (o *options) stat(path string) (os.FileInfo, error) {
+	if o.stats != nil {
+		return o.stats.Stat(path)
+	}
+	return os.Stat(path)
+}
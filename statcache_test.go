@@ -0,0 +1,44 @@
+package readall
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	ioutil.WriteFile(path, []byte("12345"), 0644)
+	now := time.Unix(0, 0)
+	c := NewStatCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	fi, err := c.Stat(path)
+	if err != nil || fi.Size() != 5 {
+		t.Errorf("stat fi:%v, err:%v", fi, err)
+		return
+	}
+	ioutil.WriteFile(path, []byte("1234567890"), 0644)
+	if fi, _ := c.Stat(path); fi.Size() != 5 {
+		t.Errorf("cached size:%v", fi.Size())
+	}
+	b, err := ReadSmallFiles([]string{path}, WithStatCache(c))
+	if got, _ := b.Get(path); err != nil || string(got) != "1234567890" {
+		t.Errorf("read with stale size:%q, err:%v", got, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if fi, _ := c.Stat(path); fi.Size() != 10 {
+		t.Errorf("expired size:%v", fi.Size())
+	}
+	os.Remove(path)
+	if fi, _ := c.Stat(path); fi == nil {
+		t.Errorf("entry dropped before expiry")
+	}
+	c.Invalidate(path)
+	if _, err := c.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("invalidated err:%v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package readall
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes one finished read of a ReadAll variant.
+type Stats struct {
+	// Bytes is how many bytes the read returned.
+	Bytes int64
+	// Reads is the number of Read calls made on the reader.
+	Reads int64
+	// Grows is how many times the buffer had to be reallocated.
+	Grows int64
+	// Allocated is the total capacity of the buffers allocated by the read,
+	// the initial one included.
+	Allocated int64
+	// Duration is the wall time of the read.
+	Duration time.Duration
+	// PoolHits and PoolMisses count the buffers of a Pool read that were
+	// reused from the pool and allocated afresh.
+	PoolHits   int64
+	PoolMisses int64
+	// Truncated reports that the source ended before its known length; see
+	// WithTruncationPolicy.
+	Truncated bool
+}
+
+// WithStats fills s with the Stats of the read once it finishes.
+func WithStats(s *Stats) Option {
+	return func(o *options) {
+		o.readStats = s
+	}
+}
+
+// Collector receives the Stats of every read of a ReadAll variant once it
+// finishes, together with its WithLabel label.
+type Collector interface {
+	CollectRead(label string, s Stats)
+}
+
+type collectorBox struct {
+	c Collector
+}
+
+var collector atomic.Value // of collectorBox
+
+// SetCollector installs c for all reads; nil disables collection, which is
+// the default.
+func SetCollector(c Collector) {
+	collector.Store(collectorBox{c})
+}
+
+// readStats gathers the Stats of one read. A nil *readStats, returned when
+// neither WithStats nor a Collector asks for them, records nothing.
+type readStats struct {
+	Stats
+	start time.Time
+	label string
+	dst   *Stats
+	c     Collector
+}
+
+func (o *options) startStats() *readStats {
+	box, _ := collector.Load().(collectorBox)
+	if o.readStats == nil && box.c == nil {
+		return nil
+	}
+	return &readStats{start: time.Now(), label: o.label, dst: o.readStats, c: box.c}
+}
+
+// alloc records the allocation of a buffer of capacity n.
+func (st *readStats) alloc(n int64) {
+	if st != nil {
+		st.Allocated += n
+	}
+}
+
+// grew records the reallocation of the buffer to capacity n.
+func (st *readStats) grew(n int64) {
+	if st != nil {
+		st.Grows++
+		st.Allocated += n
+	}
+}
+
+// reader returns r counting its Reads and bytes into st.
+func (st *readStats) reader(r io.Reader) io.Reader {
+	if st == nil {
+		return r
+	}
+	return statsReader{r, st}
+}
+
+// finish completes the Stats and hands them out.
+func (st *readStats) finish() {
+	if st == nil {
+		return
+	}
+	st.Duration = time.Since(st.start)
+	if st.dst != nil {
+		*st.dst = st.Stats
+	}
+	if st.c != nil {
+		st.c.CollectRead(st.label, st.Stats)
+	}
+}
+
+type statsReader struct {
+	r  io.Reader
+	st *readStats
+}
+
+func (s statsReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.st.Reads++
+	s.st.Bytes += int64(n)
+	return n, err
+}
+
+func (s statsReader) Unwrap() io.Reader {
+	return s.r
+}
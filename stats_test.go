@@ -0,0 +1,55 @@
+package readall
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWithStats(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 10000)
+	var s Stats
+	if _, err := ReadAll(bytes.NewReader(data), WithStats(&s)); err != nil {
+		t.Fatalf("readall err:%v", err)
+	}
+	if s.Bytes != 10000 || s.Grows != 0 || s.Allocated != 10001 || s.Reads != 2 {
+		t.Errorf("sized stats:%+v", s)
+	}
+
+	s = Stats{}
+	got, err := ReadAll(iotest.OneByteReader(bytes.NewReader(data)), WithStats(&s), WithInitialCapacity(512))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("readall len:%v, err:%v", len(got), err)
+	}
+	if s.Bytes != 10000 || s.Reads != 10001 || s.Grows == 0 || s.Allocated <= 10000 || s.Duration <= 0 {
+		t.Errorf("grown stats:%+v", s)
+	}
+
+	s = Stats{}
+	if _, err := ReadAllContext(context.Background(), iotest.HalfReader(bytes.NewReader(data)), WithStats(&s)); err != nil || s.Bytes != 10000 || s.Grows == 0 {
+		t.Errorf("context stats:%+v, err:%v", s, err)
+	}
+}
+
+type testCollector struct {
+	mu    sync.Mutex
+	stats map[string]Stats
+}
+
+func (c *testCollector) CollectRead(label string, s Stats) {
+	c.mu.Lock()
+	c.stats[label] = s
+	c.mu.Unlock()
+}
+
+func TestSetCollector(t *testing.T) {
+	c := &testCollector{stats: make(map[string]Stats)}
+	SetCollector(c)
+	defer SetCollector(nil)
+	AppendAll(nil, bytes.NewReader([]byte("hello")), WithLabel("greeting"))
+	if s := c.stats["greeting"]; s.Bytes != 5 || s.Allocated != 6 {
+		t.Errorf("collected:%+v", s)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build go1.19
+
+package readall
+
+import (
+	"errors"
+	"net/http"
+)
+
+// stdLimitError translates the *http.MaxBytesError of an
+// http.MaxBytesReader into a *LimitError that still unwraps to it.
+func stdLimitError(err error) error {
+	var mb *http.MaxBytesError
+	if err == nil || !errors.As(err, &mb) || errors.Is(err, ErrLimitExceeded) {
+		return err
+	}
+	return &LimitError{Limit: mb.Limit, Read: mb.Limit + 1, std: err}
+}
@@ -0,0 +1,23 @@
+//go:build go1.19
+
+package readall
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBytesReader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := http.MaxBytesReader(rec, ioutil.NopCloser(strings.NewReader(strings.Repeat("x", 100))), 10)
+	_, err := ReadAll(body)
+	var le *LimitError
+	var mb *http.MaxBytesError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &le) || le.Limit != 10 || !errors.As(err, &mb) {
+		t.Errorf("err:%v, want a *LimitError of 10 wrapping *http.MaxBytesError", err)
+	}
+}
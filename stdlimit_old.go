@@ -0,0 +1,9 @@
+//go:build !go1.19
+
+package readall
+
+// stdLimitError has nothing to translate before Go 1.19, which added
+// http.MaxBytesError.
+func stdLimitError(err error) error {
+	return err
+}
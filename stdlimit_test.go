@@ -0,0 +1,42 @@
+package readall
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLimitedReaderHint(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 10000)
+	if n, ok := sizeHint(&io.LimitedReader{R: bytes.NewReader(data), N: 4000}); !ok || n != 4000 {
+		t.Errorf("limited hint:%v, ok:%v", n, ok)
+	}
+	if n, ok := sizeHint(&io.LimitedReader{R: bytes.NewReader(data), N: 1 << 20}); !ok || n != 10000 {
+		t.Errorf("source hint:%v, ok:%v", n, ok)
+	}
+	if _, ok := sizeHint(&io.LimitedReader{R: iotest.HalfReader(bytes.NewReader(data)), N: 10}); ok {
+		t.Errorf("unsized source hinted")
+	}
+	got, err := ReadAll(&io.LimitedReader{R: bytes.NewReader(data), N: 4000})
+	if err != nil || len(got) != 4000 || cap(got) != 4001 {
+		t.Errorf("readall len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+	got, err = ReadAll(&io.LimitedReader{R: iotest.HalfReader(bytes.NewReader(data)), N: 100}, WithInitialCapacity(1<<20))
+	if err != nil || len(got) != 100 || cap(got) != 101 {
+		t.Errorf("capped len:%v, cap:%v, err:%v", len(got), cap(got), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "limited")
+	ioutil.WriteFile(path, data, 0644)
+	f, _ := os.Open(path)
+	f.Close()
+	_, err = ReadAll(&io.LimitedReader{R: f, N: 10})
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err:%v, want the file name", err)
+	}
+}
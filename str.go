@@ -0,0 +1,28 @@
+package readall
+
+import (
+	"io"
+	"unsafe"
+)
+
+// ReadAllString reads r until EOF and returns the data as a string without
+// copying it: the buffer, sized exactly from r when r reveals its size, is
+// never handed out as a []byte, so it can become the string as is. When the
+// size is unknown the string keeps the spare capacity of the last growth
+// step alive. It takes the options of ReadAll.
+func ReadAllString(r io.Reader, opts ...Option) (string, error) {
+	b, err := ReadAll(r, opts...)
+	return UnsafeString(b), err
+}
+
+// UnsafeString returns a string sharing the memory of b instead of copying
+// it like string(b) does. It is only correct if b is never modified
+// afterwards, by the caller or anything else holding b: strings are assumed
+// immutable, and changing b breaks maps keyed by the string, among others.
+// Use it for large read-only payloads where the copy is pure waste.
+func UnsafeString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
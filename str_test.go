@@ -0,0 +1,27 @@
+package readall
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadAllString(t *testing.T) {
+	want := strings.Repeat("string data ", 1000)
+	for _, r := range []*strings.Reader{strings.NewReader(want), strings.NewReader("")} {
+		n := r.Len()
+		s, err := ReadAllString(iotest.HalfReader(r))
+		if err != nil || len(s) != n || s != want[:n] {
+			t.Errorf("len:%v, want:%v, err:%v", len(s), n, err)
+		}
+	}
+	if _, err := ReadAllString(bytes.NewReader(make([]byte, 10)), WithLimit(5)); err == nil {
+		t.Errorf("limit not applied")
+	}
+
+	b := []byte("shared")
+	if s := UnsafeString(b); s != "shared" || UnsafeString(nil) != "" {
+		t.Errorf("unsafe string:%q", s)
+	}
+}
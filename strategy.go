@@ -0,0 +1,134 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownStrategy is returned for reads using an unregistered strategy name.
+var ErrUnknownStrategy = errors.New("readall: unknown strategy")
+
+// Strategy is one way of reading a whole Source into memory, such as into
+// pinned or off-heap memory. Strategies receive the read options and must
+// honor them, WithLimit in particular, and behave identically on every
+// source; readalltest.VerifyStrategy checks the invariants. The package
+// emits audit events for them.
+type Strategy interface {
+	ReadAll(ctx context.Context, src Source, opts ...Option) (Result, error)
+}
+
+// Result is data read into memory the package may own, such as a pooled
+// buffer or a file mapping, as returned by strategies and ReadFile.
+type Result struct {
+	Data []byte
+	// Release, if set, must be called once Data is no longer used so the
+	// strategy can reuse or unmap its memory.
+	Release func()
+	// Encoding reports the byte-order mark removed by WithStripBOM.
+	Encoding Encoding
+	// Plan is set by DownloadResult.
+	Plan *DownloadPlan
+}
+
+// DefaultStrategy reads through a Pipeline with pooled copy buffers,
+// presizing the result from the source size hint. It is registered as
+// "default".
+var DefaultStrategy Strategy = pipelineStrategy{}
+
+type pipelineStrategy struct{}
+
+func (pipelineStrategy) ReadAll(ctx context.Context, src Source, opts ...Option) (Result, error) {
+	data, err := From(src).Context(ctx).Options(opts...).Bytes()
+	return Result{Data: data}, err
+}
+
+var (
+	strategyMu sync.RWMutex
+	strategies = map[string]Strategy{"default": DefaultStrategy}
+)
+
+// RegisterStrategy makes s available by name to WithStrategyName. It panics
+// if name is empty or already registered.
+func RegisterStrategy(name string, s Strategy) {
+	name = strings.ToLower(name)
+	strategyMu.Lock()
+	defer strategyMu.Unlock()
+	if name == "" || s == nil {
+		panic("readall: invalid strategy registration " + name)
+	}
+	if _, dup := strategies[name]; dup {
+		panic("readall: RegisterStrategy called twice for " + name)
+	}
+	strategies[name] = s
+}
+
+// LookupStrategy returns the strategy registered as name.
+func LookupStrategy(name string) (Strategy, bool) {
+	strategyMu.RLock()
+	defer strategyMu.RUnlock()
+	s, ok := strategies[strings.ToLower(name)]
+	return s, ok
+}
+
+// StrategyNames returns the names of the registered strategies, sorted.
+func StrategyNames() []string {
+	strategyMu.RLock()
+	defer strategyMu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithStrategy makes ReadURL read with s instead of DefaultStrategy.
+// ReadURL hands Result.Data to its caller and never calls Release.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) {
+		o.strategy = s
+	}
+}
+
+// WithStrategyName is WithStrategy for a registered strategy; unknown names
+// make the read fail.
+func WithStrategyName(name string) Option {
+	return func(o *options) {
+		s, ok := LookupStrategy(name)
+		if !ok {
+			s = unknownStrategy(name)
+		}
+		o.strategy = s
+	}
+}
+
+type unknownStrategy string
+
+func (u unknownStrategy) ReadAll(ctx context.Context, src Source, opts ...Option) (Result, error) {
+	return Result{}, fmt.Errorf("%w: %q", ErrUnknownStrategy, string(u))
+}
+
+// runStrategy reads src with the strategy selected in opts, adding the
+// package's audit events around custom strategies.
+func runStrategy(ctx context.Context, src Source, opts []Option) (Result, error) {
+	var o options
+	o.apply(opts)
+	o = o.withContext(ctx)
+	s := o.strategy
+	if s == nil || s == DefaultStrategy {
+		res, err := DefaultStrategy.ReadAll(ctx, src, opts...)
+		return o.applyBOM(res), err
+	}
+	start := time.Now()
+	res, err := s.ReadAll(ctx, src, opts...)
+	err = withSource(sourceName(src), err)
+	if o.audit != nil {
+		emitAudit(&o, src, start, int64(len(res.Data)), nil, err)
+	}
+	return o.applyBOM(res), err
+}
@@ -0,0 +1,38 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type releaseStrategy struct {
+	released int
+}
+
+func (s *releaseStrategy) ReadAll(ctx context.Context, src Source, opts ...Option) (Result, error) {
+	res, err := DefaultStrategy.ReadAll(ctx, src, opts...)
+	res.Release = func() { s.released++ }
+	return res, err
+}
+
+func TestStrategyRegistration(t *testing.T) {
+	custom := &releaseStrategy{}
+	RegisterStrategy("custom-test", custom)
+	if s, ok := LookupStrategy("Custom-Test"); !ok || s != custom {
+		t.Errorf("lookup:%v, ok:%v", s, ok)
+	}
+
+	var audited int64
+	data, err := ReadURL(context.Background(), "literal:via strategy", WithStrategyName("custom-test"),
+		WithAudit(func(ev AuditEvent) { audited = ev.Bytes }))
+	if err != nil || string(data) != "via strategy" {
+		t.Errorf("read:%q, err:%v", data, err)
+	}
+	if audited != int64(len("via strategy")) {
+		t.Errorf("audited bytes:%v", audited)
+	}
+	if _, err := ReadURL(context.Background(), "literal:x", WithStrategyName("missing")); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("unknown strategy err:%v", err)
+	}
+}
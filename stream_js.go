@@ -0,0 +1,140 @@
+//go:build js && wasm
+// +build js,wasm
+
+package readall
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+// NewStreamReader adapts a JavaScript ReadableStream of Uint8Array chunks,
+// such as the body of a fetch Response, to an io.ReadCloser. Chunks that fit
+// are copied straight into the caller's buffer. Read blocks the calling
+// goroutine until the stream's read promise settles. Close cancels the
+// stream.
+func NewStreamReader(stream js.Value) io.ReadCloser {
+	return &streamReader{reader: stream.Call("getReader")}
+}
+
+// ReadStream reads stream to the end like ReadAll, honoring its options
+// such as WithLimit, WithProgress and WithLabel, and WithAudit.
+func ReadStream(stream js.Value, opts ...Option) (data []byte, err error) {
+	o := readOptions(opts)
+	r := NewStreamReader(stream)
+	defer r.Close()
+	defer annotate(&err, r)
+	if o.audit != nil {
+		start := time.Now()
+		defer func() { emitAudit(o, r, start, int64(len(data)), nil, err) }()
+	}
+	return readAllCap(r, o.capacity(r), o)
+}
+
+type streamReader struct {
+	reader js.Value
+	pend   []byte // rest of the last chunk that did not fit
+	chunk  []byte // backing array reused for pend
+	err    error
+}
+
+func (s *streamReader) String() string {
+	return "stream"
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(s.pend) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if n := s.next(p); n > 0 {
+			return n, nil
+		}
+	}
+	n := copy(p, s.pend)
+	s.pend = s.pend[n:]
+	return n, nil
+}
+
+// next waits for the next chunk. It copies the chunk into p if it fits and
+// returns its length, and otherwise keeps it in pend and returns 0.
+func (s *streamReader) next(p []byte) int {
+	type result struct {
+		value js.Value
+		done  bool
+		err   error
+	}
+	ch := make(chan result, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ch <- result{value: args[0].Get("value"), done: args[0].Get("done").Bool()}
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ch <- result{err: jsError(args[0])}
+		return nil
+	})
+	defer catch.Release()
+	s.reader.Call("read").Call("then", then, catch)
+
+	res := <-ch
+	switch {
+	case res.err != nil:
+		s.err = res.err
+		return 0
+	case res.done:
+		s.err = io.EOF
+		return 0
+	case !res.value.InstanceOf(js.Global().Get("Uint8Array")):
+		s.err = errors.New("readall: stream chunk is not a Uint8Array")
+		return 0
+	}
+	n := res.value.Get("length").Int()
+	if n <= len(p) {
+		return js.CopyBytesToGo(p, res.value)
+	}
+	if cap(s.chunk) < n {
+		s.chunk = make([]byte, n)
+	}
+	s.pend = s.chunk[:js.CopyBytesToGo(s.chunk[:n], res.value)]
+	return 0
+}
+
+func (s *streamReader) Close() error {
+	if s.err == nil {
+		s.err = errors.New("readall: read from closed stream")
+	}
+	// The cancel promise rejects if the stream already failed; swallow that
+	// so it is not reported as an unhandled rejection.
+	s.reader.Call("cancel").Call("catch", ignoreRejection())
+	s.reader.Call("releaseLock")
+	return nil
+}
+
+var (
+	ignoreOnce sync.Once
+	ignoreFunc js.Func
+)
+
+// ignoreRejection returns a shared no-op rejection handler. It is never
+// released because the promises using it may settle at any time.
+func ignoreRejection() js.Func {
+	ignoreOnce.Do(func() {
+		ignoreFunc = js.FuncOf(func(js.Value, []js.Value) interface{} { return nil })
+	})
+	return ignoreFunc
+}
+
+// jsError converts a rejection reason to a Go error.
+func jsError(v js.Value) error {
+	if v.Type() == js.TypeObject && v.Get("message").Type() == js.TypeString {
+		return errors.New("readall: stream: " + v.Get("message").String())
+	}
+	return errors.New("readall: stream: " + v.String())
+}
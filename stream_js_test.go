@@ -0,0 +1,70 @@
+//go:build js && wasm
+// +build js,wasm
+
+package readall
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"syscall/js"
+	"testing"
+)
+
+// newStream returns a ReadableStream yielding chunks, then erroring with
+// fail if it is not empty.
+func newStream(chunks []string, fail string) js.Value {
+	var start js.Func
+	start = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		c := args[0]
+		for _, s := range chunks {
+			a := js.Global().Get("Uint8Array").New(len(s))
+			js.CopyBytesToJS(a, []byte(s))
+			c.Call("enqueue", a)
+		}
+		if fail != "" {
+			c.Call("error", js.Global().Get("Error").New(fail))
+		} else {
+			c.Call("close")
+		}
+		start.Release()
+		return nil
+	})
+	src := js.Global().Get("Object").New()
+	src.Set("start", start)
+	return js.Global().Get("ReadableStream").New(src)
+}
+
+func TestReadStream(t *testing.T) {
+	chunks := []string{"hello ", strings.Repeat("x", 100000), "", "end"}
+	data, err := ReadStream(newStream(chunks, ""))
+	if err != nil || string(data) != strings.Join(chunks, "") {
+		t.Errorf("read len:%v, err:%v", len(data), err)
+	}
+
+	r := NewStreamReader(newStream([]string{"abcdef"}, ""))
+	buf := make([]byte, 4)
+	n, _ := r.Read(buf)
+	m, _ := r.Read(buf[n:])
+	if n+m != 4 || string(buf) != "abcd" {
+		t.Errorf("short reads:%q", buf)
+	}
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || string(rest) != "ef" {
+		t.Errorf("rest:%q, err:%v", rest, err)
+	}
+
+	if _, err := ReadStream(newStream([]string{"abc"}, "boom")); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("stream error:%v", err)
+	}
+	if _, err := ReadStream(newStream(chunks, ""), WithLimit(10)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limit err:%v", err)
+	}
+
+	var progress int64
+	var ev AuditEvent
+	data, err = ReadStream(newStream(chunks, ""), WithProgress(func(read, total int64) { progress = read }), WithAudit(func(e AuditEvent) { ev = e }))
+	if err != nil || progress != int64(len(data)) || ev.Source != "stream" || ev.Bytes != int64(len(data)) {
+		t.Errorf("progress:%v, audit:%+v, err:%v", progress, ev, err)
+	}
+}
@@ -0,0 +1,98 @@
+package readall
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrUnsafePath is returned for archive entries whose name or link target
+	// is absolute or escapes the extraction root.
+	ErrUnsafePath = errors.New("readall: unsafe archive path")
+	// ErrArchiveLimit is returned when an archive exceeds a configured limit.
+	ErrArchiveLimit = errors.New("readall: archive limit exceeded")
+)
+
+// TarOptions bounds what WalkTar accepts. Zero fields mean no limit.
+type TarOptions struct {
+	MaxEntries   int
+	MaxEntrySize int64
+	MaxTotalSize int64
+	// AllowLinks permits symlink and hardlink entries; their targets are
+	// still checked to stay inside the archive root.
+	AllowLinks bool
+}
+
+// WalkTar reads the tar stream r and calls fn for every entry with a reader
+// over its contents. Entries are rejected before fn is called if their path is
+// unsafe or their declared size breaks a limit.
+func WalkTar(r io.Reader, fn func(hdr *tar.Header, r io.Reader) error, opts TarOptions) (err error) {
+	defer annotate(&err, r)
+	br := bufioPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	defer func() {
+		br.Reset(nil)
+		bufioPool.Put(br)
+	}()
+
+	tr := tar.NewReader(br)
+	var entries int
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entries++
+		if opts.MaxEntries > 0 && entries > opts.MaxEntries {
+			return fmt.Errorf("%w: more than %d entries", ErrArchiveLimit, opts.MaxEntries)
+		}
+		if !safeArchivePath(hdr.Name) {
+			return fmt.Errorf("%w: %q", ErrUnsafePath, hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			if !opts.AllowLinks {
+				return fmt.Errorf("%w: link %q", ErrUnsafePath, hdr.Name)
+			}
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink && !path.IsAbs(target) {
+				target = path.Join(path.Dir(hdr.Name), target)
+			}
+			if !safeArchivePath(target) {
+				return fmt.Errorf("%w: %q links to %q", ErrUnsafePath, hdr.Name, hdr.Linkname)
+			}
+		}
+		if opts.MaxEntrySize > 0 && hdr.Size > opts.MaxEntrySize {
+			return fmt.Errorf("%w: entry %q is %d bytes", ErrArchiveLimit, hdr.Name, hdr.Size)
+		}
+		total += hdr.Size
+		if opts.MaxTotalSize > 0 && total > opts.MaxTotalSize {
+			return fmt.Errorf("%w: total size over %d bytes", ErrArchiveLimit, opts.MaxTotalSize)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// safeArchivePath reports whether name stays within the extraction root.
+func safeArchivePath(name string) bool {
+	if name == "" || path.IsAbs(name) || strings.Contains(name, "\\") {
+		return false
+	}
+	if len(name) >= 2 && name[1] == ':' {
+		// Windows volume name such as C:.
+		return false
+	}
+	clean := path.Clean(name)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
@@ -0,0 +1,75 @@
+package readall
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+type tarEntry struct {
+	name, link string
+	typ        byte
+	body       string
+}
+
+func buildTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typ := e.typ
+		if typ == 0 {
+			typ = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: e.name, Linkname: e.link, Typeflag: typ, Mode: 0644, Size: int64(len(e.body))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("write header err:%v", err)
+		}
+		io.WriteString(tw, e.body)
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func TestWalkTar(t *testing.T) {
+	data := buildTar(t, []tarEntry{{name: "a.txt", body: "hello"}, {name: "dir/b.txt", body: "world"}})
+	got := make(map[string]string)
+	err := WalkTar(bytes.NewReader(data), func(hdr *tar.Header, r io.Reader) error {
+		body, err := ioutil.ReadAll(r)
+		got[hdr.Name] = string(body)
+		return err
+	}, TarOptions{MaxEntrySize: 5, MaxTotalSize: 10})
+	if err != nil {
+		t.Errorf("walk err:%v", err)
+		return
+	}
+	if got["a.txt"] != "hello" || got["dir/b.txt"] != "world" {
+		t.Errorf("entries:%v", got)
+	}
+}
+
+func TestWalkTarRejects(t *testing.T) {
+	cases := []struct {
+		name    string
+		entries []tarEntry
+		opts    TarOptions
+		want    error
+	}{
+		{"traversal", []tarEntry{{name: "../etc/passwd"}}, TarOptions{}, ErrUnsafePath},
+		{"absolute", []tarEntry{{name: "/etc/passwd"}}, TarOptions{}, ErrUnsafePath},
+		{"link", []tarEntry{{name: "l", link: "a", typ: tar.TypeSymlink}}, TarOptions{}, ErrUnsafePath},
+		{"link escape", []tarEntry{{name: "d/l", link: "../../x", typ: tar.TypeSymlink}}, TarOptions{AllowLinks: true}, ErrUnsafePath},
+		{"entry size", []tarEntry{{name: "a", body: "toolong"}}, TarOptions{MaxEntrySize: 3}, ErrArchiveLimit},
+		{"total size", []tarEntry{{name: "a", body: "abc"}, {name: "b", body: "abc"}}, TarOptions{MaxTotalSize: 5}, ErrArchiveLimit},
+		{"entries", []tarEntry{{name: "a"}, {name: "b"}}, TarOptions{MaxEntries: 1}, ErrArchiveLimit},
+	}
+	for _, c := range cases {
+		data := buildTar(t, c.entries)
+		err := WalkTar(bytes.NewReader(data), func(*tar.Header, io.Reader) error { return nil }, c.opts)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%v err:%v, want:%v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package readall
+
+import (
+	"errors"
+	"io"
+)
+
+// TruncationPolicy selects how the ReadAll variants treat a source of known
+// length that ends early, reported as io.ErrUnexpectedEOF (compressed
+// streams, io.ReadFull based readers) or as ErrShortBody (HTTP bodies).
+type TruncationPolicy int
+
+const (
+	// TruncationFails returns the bytes received with the error.
+	TruncationFails TruncationPolicy = iota
+	// TruncationAllowed returns the bytes received as a successful read.
+	TruncationAllowed
+)
+
+// WithTruncationPolicy sets the TruncationPolicy of a read; the default is
+// TruncationFails. Stats.Truncated tells whether a read was cut short under
+// either policy.
+func WithTruncationPolicy(p TruncationPolicy) Option {
+	return func(o *options) {
+		o.truncation = p
+	}
+}
+
+// truncated applies the truncation policy to the error err that ended a
+// read, recording a truncation in st.
+func (o *options) truncated(err error, st *readStats) error {
+	if err == nil || !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, ErrShortBody) {
+		return err
+	}
+	if st != nil {
+		st.Truncated = true
+	}
+	if o.truncation == TruncationAllowed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package readall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadAllDataWithEOF(t *testing.T) {
+	data := strings.Repeat("eof", 5000)
+	for _, r := range []io.Reader{
+		iotest.DataErrReader(strings.NewReader(data)),
+		iotest.DataErrReader(iotest.HalfReader(strings.NewReader(data))),
+	} {
+		if got, err := ReadAll(r, WithInitialCapacity(100)); err != nil || string(got) != data {
+			t.Errorf("readall len:%v, err:%v", len(got), err)
+		}
+	}
+	got, err := ReadAllContext(context.Background(), iotest.DataErrReader(strings.NewReader(data)))
+	if err != nil || string(got) != data {
+		t.Errorf("context len:%v, err:%v", len(got), err)
+	}
+}
+
+func TestWithTruncationPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(bytes.Repeat([]byte("truncated"), 1000))
+	zw.Close()
+	short := buf.Bytes()[:buf.Len()/2]
+
+	var s Stats
+	_, err := ReadAll(gzipReader(t, short), WithStats(&s))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !s.Truncated {
+		t.Errorf("fails err:%v, truncated:%v", err, s.Truncated)
+	}
+	s = Stats{}
+	got, err := ReadAll(gzipReader(t, short), WithStats(&s), WithTruncationPolicy(TruncationAllowed))
+	if err != nil || !s.Truncated || len(got) == 0 {
+		t.Errorf("allowed len:%v, err:%v, truncated:%v", len(got), err, s.Truncated)
+	}
+	s = Stats{}
+	if _, err := ReadAll(strings.NewReader("whole"), WithStats(&s), WithTruncationPolicy(TruncationAllowed)); err != nil || s.Truncated {
+		t.Errorf("whole err:%v, truncated:%v", err, s.Truncated)
+	}
+}
+
+func gzipReader(t *testing.T, data []byte) io.Reader {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip err:%v", err)
+	}
+	return zr
+}
@@ -0,0 +1,41 @@
+package readall
+
+import "io"
+
+// Unwrapper is implemented by readers that wrap another reader, such as the
+// limit, tee and decompression stages of a Pipeline. Readers written outside
+// this package can implement it too, so that Root and the package's source
+// detection see through them.
+type Unwrapper interface {
+	Unwrap() io.Reader
+}
+
+// maxUnwrap bounds Root against wrappers that unwrap to themselves.
+const maxUnwrap = 100
+
+// Root follows Unwrap from r to the innermost reader, for example the
+// *os.File under a chain of wrappers. It returns r if r does not unwrap.
+func Root(r io.Reader) io.Reader {
+	for i := 0; i < maxUnwrap; i++ {
+		u, ok := r.(Unwrapper)
+		if !ok {
+			break
+		}
+		inner := u.Unwrap()
+		if inner == nil {
+			break
+		}
+		r = inner
+	}
+	return r
+}
+
+// wrappedReader reads from Reader, which was derived from src.
+type wrappedReader struct {
+	io.Reader
+	src io.Reader
+}
+
+func (w wrappedReader) Unwrap() io.Reader {
+	return w.src
+}
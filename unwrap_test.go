@@ -0,0 +1,60 @@
+package readall
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type userWrapper struct {
+	r io.Reader
+}
+
+func (u userWrapper) Read(p []byte) (int, error) { return u.r.Read(p) }
+func (u userWrapper) Unwrap() io.Reader          { return u.r }
+
+func TestRoot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	ioutil.WriteFile(path, []byte("data"), 0644)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Errorf("open err:%v", err)
+		return
+	}
+	defer f.Close()
+
+	var r io.Reader = userWrapper{newLimitReader(userWrapper{f}, 10)}
+	if Root(r) != f {
+		t.Errorf("root:%T, want *os.File", Root(r))
+	}
+	if sourceName(r) != path {
+		t.Errorf("source name:%q, want:%q", sourceName(r), path)
+	}
+	plain := strings.NewReader("x")
+	if Root(plain) != plain {
+		t.Errorf("root of unwrapped reader changed")
+	}
+
+	d, err := decompress(f)
+	if err != nil || Root(d) != f {
+		t.Errorf("decompress root:%T, err:%v", Root(d), err)
+	}
+	rs, _ := MakeSeekable(userWrapper{f}, 0)
+	if Root(rs) != f {
+		t.Errorf("seekable root:%T", Root(rs))
+	}
+	for _, opts := range [][]Option{{WithHash(md5.New())}, {WithHash(md5.New()), WithHash(sha1.New())}} {
+		if h := readOptions(opts).withHash(f); Root(h) != f {
+			t.Errorf("hash root:%T", Root(h))
+		}
+	}
+	var st Stats
+	if r := readOptions([]Option{WithStats(&st)}).startStats().reader(f); Root(r) != f {
+		t.Errorf("stats root:%T", Root(r))
+	}
+}
@@ -0,0 +1,101 @@
+package readall
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ReadURL reads whatever rawurl points at and returns its contents, applying
+// opts. Supported forms are plain file paths, file://, http:// and https://
+// URLs, "-" or "stdin:" for standard input, data: URIs (base64 or
+// percent-encoded), literal: followed by the content itself, and any scheme
+// added with RegisterSource. Inline content is subject to the same limits as
+// any other source.
+func ReadURL(ctx context.Context, rawurl string, opts ...Option) (_ []byte, err error) {
+	defer func() { err = withSource(urlName(rawurl), err) }()
+	src, err := resolveURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runStrategy(ctx, src, opts)
+	return res.Data, err
+}
+
+func resolveURL(rawurl string) (Source, error) {
+	switch {
+	case rawurl == "-" || rawurl == "stdin:" || rawurl == "stdin:-":
+		return stdinSource{}, nil
+	case strings.HasPrefix(rawurl, "data:"):
+		return parseDataURL(rawurl)
+	case strings.HasPrefix(rawurl, "literal:"):
+		return BytesSource(rawurl[len("literal:"):]), nil
+	}
+	return sourceForURL(rawurl)
+}
+
+// urlName identifies rawurl in errors without repeating inline content.
+func urlName(rawurl string) string {
+	for _, scheme := range []string{"data:", "literal:"} {
+		if strings.HasPrefix(rawurl, scheme) {
+			return scheme
+		}
+	}
+	return rawurl
+}
+
+// stdinSource reads standard input once.
+type stdinSource struct{}
+
+func (stdinSource) String() string {
+	return "stdin"
+}
+
+func (stdinSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(os.Stdin), nil
+}
+
+func (stdinSource) OpenAt(ctx context.Context, off int64) (io.ReadCloser, error) {
+	if off != 0 {
+		return nil, ErrRangeUnsupported
+	}
+	return ioutil.NopCloser(os.Stdin), nil
+}
+
+func (stdinSource) Size(ctx context.Context) (int64, error) {
+	if n, ok := sizeHint(os.Stdin); ok {
+		return n, nil
+	}
+	return -1, nil
+}
+
+// parseDataURL decodes an RFC 2397 data: URI. The media type is ignored.
+func parseDataURL(rawurl string) (Source, error) {
+	comma := strings.IndexByte(rawurl, ',')
+	if comma < 0 {
+		return nil, errors.New("readall: malformed data URI")
+	}
+	meta, payload := rawurl[len("data:"):comma], rawurl[comma+1:]
+	if !strings.HasSuffix(meta, ";base64") {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, fmt.Errorf("readall: data URI: %w", err)
+		}
+		return BytesSource(data), nil
+	}
+	enc := base64.StdEncoding
+	if !strings.HasSuffix(payload, "=") && len(payload)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	data, err := enc.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("readall: data URI: %w", err)
+	}
+	return BytesSource(data), nil
+}
@@ -0,0 +1,59 @@
+package readall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	ioutil.WriteFile(path, []byte("from file"), 0644)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "from http")
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	cases := map[string]string{
+		path:                                  "from file",
+		"file://" + filepath.ToSlash(path):    "from file",
+		srv.URL:                               "from http",
+		"data:text/plain;base64,ZnJvbSBkYXRh": "from data",
+		"data:;base64,ZnJvbSBkYXRhIQ":         "from data!",
+		"data:,from%20data%2C%20escaped":      "from data, escaped",
+		"literal:inline value":                "inline value",
+	}
+	for u, want := range cases {
+		got, err := ReadURL(ctx, u)
+		if err != nil || string(got) != want {
+			t.Errorf("read %v:%q, err:%v", u, got, err)
+		}
+	}
+	if _, err := ReadURL(ctx, srv.URL, WithLimit(4)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limited read err:%v", err)
+	}
+	if _, err := ReadURL(ctx, "literal:"+strings.Repeat("x", 100), WithLimit(10)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("limited literal err:%v", err)
+	}
+	if _, err := ReadURL(ctx, "data:;base64,!!!"); err == nil {
+		t.Errorf("invalid base64 accepted")
+	}
+	if _, err := ReadURL(ctx, "gopher://host/x"); err == nil {
+		t.Errorf("unknown scheme accepted")
+	}
+}
+
+func TestReadURLLyingLength(t *testing.T) {
+	srv := lyingServer()
+	defer srv.Close()
+	if _, err := ReadURL(context.Background(), srv.URL, WithLimit(1<<20)); err == nil {
+		t.Errorf("lying length err:%v", err)
+	}
+}
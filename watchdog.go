@@ -0,0 +1,134 @@
+package readall
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// watchdogChunks is how many recent Reads a Diagnostics bundle lists.
+const watchdogChunks = 32
+
+// ChunkTiming describes one Read of the source.
+type ChunkTiming struct {
+	At    time.Time
+	Bytes int
+	Took  time.Duration
+}
+
+// Diagnostics is the bundle a watchdog hands out for a read that runs too
+// long.
+type Diagnostics struct {
+	// Read identifies the read and its source as InFlight does.
+	Read InFlightRead
+	// Stack is the stack of the goroutine that started the read.
+	Stack []byte
+	// Stats holds Bytes, Reads and Duration so far.
+	Stats Stats
+	// Chunks are the most recent Reads of the source, oldest first.
+	Chunks []ChunkTiming
+}
+
+// WithWatchdog calls fn, on a goroutine of its own, with a Diagnostics
+// bundle once the read has been running for d, turning a read that hangs
+// into a report of where it is stuck. fn is called at most once per read
+// and the read goes on; kill it with KillRead(Diagnostics.Read.ID) if
+// needed. It applies to the ReadAll variants, Copy, Pool.ReadAll and
+// pipelines.
+func WithWatchdog(d time.Duration, fn func(Diagnostics)) Option {
+	return func(o *options) {
+		o.watchdog = d
+		o.watchFn = fn
+	}
+}
+
+// watch starts the watchdog of o for t, before its first Read, and returns
+// the function stopping it.
+func (o *options) watch(t *trackedRead) func() {
+	if o.watchdog <= 0 || o.watchFn == nil {
+		return func() {}
+	}
+	g := goroutineID()
+	t.chunks = new(chunkRing)
+	fn := o.watchFn
+	timer := time.AfterFunc(o.watchdog, func() { fn(t.diagnose(g)) })
+	return func() { timer.Stop() }
+}
+
+func (t *trackedRead) diagnose(g string) Diagnostics {
+	now := time.Now()
+	d := Diagnostics{Read: t.snapshot(now), Stack: goroutineStack(g)}
+	d.Chunks, d.Stats.Reads = t.chunks.recent()
+	d.Stats.Bytes = d.Read.Bytes
+	d.Stats.Duration = d.Read.Age
+	return d
+}
+
+// chunkRing keeps the last watchdogChunks Read timings.
+type chunkRing struct {
+	mu    sync.Mutex
+	ring  [watchdogChunks]ChunkTiming
+	count int64
+}
+
+func (c *chunkRing) add(ct ChunkTiming) {
+	c.mu.Lock()
+	c.ring[c.count%watchdogChunks] = ct
+	c.count++
+	c.mu.Unlock()
+}
+
+// recent returns the kept timings, oldest first, and the number of Reads.
+func (c *chunkRing) recent() ([]ChunkTiming, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.count
+	if n > watchdogChunks {
+		n = watchdogChunks
+	}
+	out := make([]ChunkTiming, 0, n)
+	for i := c.count - n; i < c.count; i++ {
+		out = append(out, c.ring[i%watchdogChunks])
+	}
+	return out, c.count
+}
+
+// goroutineID returns the ID of the calling goroutine, as printed in stack
+// dumps.
+func goroutineID() string {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	if _, err := strconv.ParseUint(string(b), 10, 64); err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// goroutineStack returns the stack of goroutine g, or nil if it is gone.
+func goroutineStack(g string) []byte {
+	if g == "" {
+		return nil
+	}
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	head := []byte("goroutine " + g + " [")
+	for _, st := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(st, head) {
+			return st
+		}
+	}
+	return nil
+}
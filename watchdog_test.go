@@ -0,0 +1,60 @@
+package readall
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithWatchdog(t *testing.T) {
+	r := namedBlockingReader{&blockingReader{data: []byte("partial"), release: make(chan struct{})}}
+	diags := make(chan Diagnostics, 1)
+	done := make(chan struct{})
+	go func() {
+		ReadAll(r, WithWatchdog(20*time.Millisecond, func(d Diagnostics) { diags <- d }))
+		close(done)
+	}()
+	var d Diagnostics
+	select {
+	case d = <-diags:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watchdog did not fire")
+	}
+	close(r.release)
+	<-done
+
+	if d.Read.Source != "stuck-source" || d.Stats.Bytes != 7 || d.Stats.Duration < 20*time.Millisecond {
+		t.Errorf("read:%+v, stats:%+v", d.Read, d.Stats)
+	}
+	if !bytes.Contains(d.Stack, []byte("blockingReader")) {
+		t.Errorf("stack:\n%s", d.Stack)
+	}
+	if d.Stats.Reads != 1 || len(d.Chunks) != 1 || d.Chunks[0].Bytes != 7 {
+		t.Errorf("reads:%v, chunks:%+v", d.Stats.Reads, d.Chunks)
+	}
+}
+
+func TestWithWatchdogQuietRead(t *testing.T) {
+	fired := make(chan struct{}, 1)
+	data, err := ReadAll(strings.NewReader("quick"), WithWatchdog(50*time.Millisecond, func(Diagnostics) { fired <- struct{}{} }))
+	if err != nil || string(data) != "quick" {
+		t.Fatalf("read:%q, err:%v", data, err)
+	}
+	select {
+	case <-fired:
+		t.Errorf("watchdog fired for a finished read")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestChunkRing(t *testing.T) {
+	var c chunkRing
+	for i := 0; i < watchdogChunks+5; i++ {
+		c.add(ChunkTiming{Bytes: i})
+	}
+	chunks, reads := c.recent()
+	if reads != watchdogChunks+5 || len(chunks) != watchdogChunks || chunks[0].Bytes != 5 || chunks[len(chunks)-1].Bytes != watchdogChunks+4 {
+		t.Errorf("reads:%v, chunks:%+v", reads, chunks)
+	}
+}
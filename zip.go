@@ -0,0 +1,371 @@
+package readall
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+var (
+	// ErrZipFormat is returned for malformed local file headers, data
+	// descriptors, CRC or size mismatches in a streamed zip.
+	ErrZipFormat = errors.New("readall: malformed zip stream")
+	// ErrZipUnsupported is returned for encrypted entries and compression
+	// methods other than store and deflate.
+	ErrZipUnsupported = errors.New("readall: unsupported zip entry")
+)
+
+const (
+	zipLocalSig      = 0x04034b50
+	zipDescriptorSig = 0x08074b50
+	zipCentralSig    = 0x02014b50
+	zipEndSig        = 0x06054b50
+	zip64EndSig      = 0x06064b50
+	zip64ExtraID     = 0x0001
+
+	zipFlagEncrypted  = 0x1
+	zipFlagDescriptor = 0x8
+)
+
+// ZipEntry describes a zip entry as found in its local file header. For
+// entries using a data descriptor, CRC32 and the sizes are only filled in
+// after the entry has been read.
+type ZipEntry struct {
+	Name             string
+	Method           uint16
+	Flags            uint16
+	Modified         time.Time
+	CRC32            uint32
+	CompressedSize   uint64
+	UncompressedSize uint64
+	Zip64            bool
+}
+
+// ZipOptions bounds what WalkZip accepts. Zero fields mean no limit.
+// Sizes are measured on uncompressed data.
+type ZipOptions struct {
+	MaxEntries   int
+	MaxEntrySize int64
+	MaxTotalSize int64
+}
+
+// WalkZip reads the zip archive r front to back, without the central
+// directory, and calls fn for each entry with a reader over its uncompressed
+// contents. This works for non-seekable inputs such as uploads and for
+// streaming zips whose entries are followed by data descriptors, including
+// zip64 ones. Entry paths are checked like WalkTar does; CRCs and sizes are
+// verified once each entry has been fully consumed, whether or not fn read it
+// to the end. Iteration stops at the central directory.
+func WalkZip(r io.Reader, fn func(e *ZipEntry, r io.Reader) error, opts ZipOptions) (err error) {
+	defer annotate(&err, r)
+	br := bufioPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	defer func() {
+		br.Reset(nil)
+		bufioPool.Put(br)
+	}()
+
+	var entries int
+	var total int64
+	var hdr [30]byte
+	for {
+		n, err := io.ReadFull(br, hdr[:4])
+		if err == io.EOF && n == 0 {
+			return nil
+		}
+		if err != nil {
+			return zipErr(err)
+		}
+		switch binary.LittleEndian.Uint32(hdr[:4]) {
+		case zipLocalSig:
+		case zipCentralSig, zipEndSig, zip64EndSig:
+			return nil
+		default:
+			return ErrZipFormat
+		}
+		if _, err := io.ReadFull(br, hdr[4:]); err != nil {
+			return zipErr(err)
+		}
+		e, err := readZipEntry(br, hdr[:])
+		if err != nil {
+			return err
+		}
+		entries++
+		if opts.MaxEntries > 0 && entries > opts.MaxEntries {
+			return fmt.Errorf("%w: more than %d entries", ErrArchiveLimit, opts.MaxEntries)
+		}
+		if !safeArchivePath(e.Name) {
+			return fmt.Errorf("%w: %q", ErrUnsafePath, e.Name)
+		}
+		if e.Flags&zipFlagEncrypted != 0 || (e.Method != zipStore && e.Method != zipDeflate) {
+			return fmt.Errorf("%w: %q", ErrZipUnsupported, e.Name)
+		}
+		descriptor := e.Flags&zipFlagDescriptor != 0
+		if !descriptor {
+			if opts.MaxEntrySize > 0 && e.UncompressedSize > uint64(opts.MaxEntrySize) {
+				return fmt.Errorf("%w: entry %q is %d bytes", ErrArchiveLimit, e.Name, e.UncompressedSize)
+			}
+		}
+
+		var compressed io.Reader
+		var counter *countingByteReader
+		switch {
+		case !descriptor:
+			compressed = io.LimitReader(br, int64(e.CompressedSize))
+		case e.Method == zipDeflate:
+			// flate reads exactly up to the end of the deflate stream from
+			// an io.ByteReader, so the descriptor right after stays unread.
+			counter = &countingByteReader{r: br}
+			compressed = counter
+		default:
+			compressed = &storedScanner{br: br, crc: crc32.NewIEEE(), zip64: e.Zip64}
+		}
+		var data io.Reader = compressed
+		var fr io.ReadCloser
+		if e.Method == zipDeflate {
+			fr = flate.NewReader(compressed)
+			data = fr
+		}
+		entryMax := int64(-1)
+		if opts.MaxEntrySize > 0 {
+			entryMax = opts.MaxEntrySize
+		}
+		if opts.MaxTotalSize > 0 && (entryMax < 0 || opts.MaxTotalSize-total < entryMax) {
+			entryMax = opts.MaxTotalSize - total
+		}
+		er := &zipEntryReader{r: data, crc: crc32.NewIEEE(), max: entryMax}
+		err = fn(e, er)
+		if err == nil {
+			_, err = io.Copy(ioutil.Discard, er)
+		}
+		if fr != nil {
+			fr.Close()
+		}
+		if err != nil {
+			return err
+		}
+		total += er.n
+		if !descriptor {
+			if _, err := io.Copy(ioutil.Discard, compressed); err != nil {
+				return zipErr(err)
+			}
+		} else {
+			if err := readZipDescriptor(br, e); err != nil {
+				return err
+			}
+			csize := uint64(er.n)
+			if counter != nil {
+				csize = uint64(counter.n)
+			}
+			if e.CompressedSize != csize {
+				return fmt.Errorf("%w: %q compressed size mismatch", ErrZipFormat, e.Name)
+			}
+		}
+		if er.crc.Sum32() != e.CRC32 || uint64(er.n) != e.UncompressedSize {
+			return fmt.Errorf("%w: %q checksum or size mismatch", ErrZipFormat, e.Name)
+		}
+	}
+}
+
+const (
+	zipStore   = 0
+	zipDeflate = 8
+)
+
+func readZipEntry(br *bufio.Reader, hdr []byte) (*ZipEntry, error) {
+	le := binary.LittleEndian
+	e := &ZipEntry{
+		Flags:            le.Uint16(hdr[6:]),
+		Method:           le.Uint16(hdr[8:]),
+		Modified:         msDosTime(le.Uint16(hdr[12:]), le.Uint16(hdr[10:])),
+		CRC32:            le.Uint32(hdr[14:]),
+		CompressedSize:   uint64(le.Uint32(hdr[18:])),
+		UncompressedSize: uint64(le.Uint32(hdr[22:])),
+	}
+	nameLen, extraLen := int(le.Uint16(hdr[26:])), int(le.Uint16(hdr[28:]))
+	buf := make([]byte, nameLen+extraLen)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, zipErr(err)
+	}
+	e.Name = string(buf[:nameLen])
+	extra := buf[nameLen:]
+	for len(extra) >= 4 {
+		id, size := le.Uint16(extra), int(le.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			return nil, ErrZipFormat
+		}
+		field := extra[:size]
+		extra = extra[size:]
+		if id != zip64ExtraID {
+			continue
+		}
+		e.Zip64 = true
+		// The local header zip64 field holds the uncompressed then the
+		// compressed size, each only when its 32-bit field is saturated.
+		if e.UncompressedSize == 0xffffffff && len(field) >= 8 {
+			e.UncompressedSize = le.Uint64(field)
+			field = field[8:]
+		}
+		if e.CompressedSize == 0xffffffff && len(field) >= 8 {
+			e.CompressedSize = le.Uint64(field)
+		}
+	}
+	return e, nil
+}
+
+func readZipDescriptor(br *bufio.Reader, e *ZipEntry) error {
+	var buf [24]byte
+	if _, err := io.ReadFull(br, buf[:4]); err != nil {
+		return zipErr(err)
+	}
+	if binary.LittleEndian.Uint32(buf[:4]) == zipDescriptorSig {
+		if _, err := io.ReadFull(br, buf[:4]); err != nil {
+			return zipErr(err)
+		}
+	}
+	e.CRC32 = binary.LittleEndian.Uint32(buf[:4])
+	if e.Zip64 {
+		if _, err := io.ReadFull(br, buf[:16]); err != nil {
+			return zipErr(err)
+		}
+		e.CompressedSize = binary.LittleEndian.Uint64(buf[:])
+		e.UncompressedSize = binary.LittleEndian.Uint64(buf[8:])
+		return nil
+	}
+	if _, err := io.ReadFull(br, buf[:8]); err != nil {
+		return zipErr(err)
+	}
+	e.CompressedSize = uint64(binary.LittleEndian.Uint32(buf[:]))
+	e.UncompressedSize = uint64(binary.LittleEndian.Uint32(buf[4:]))
+	return nil
+}
+
+func zipErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrZipFormat
+	}
+	return err
+}
+
+func msDosTime(date, tm uint16) time.Time {
+	return time.Date(
+		int(date>>9+1980), time.Month(date>>5&0xf), int(date&0x1f),
+		int(tm>>11), int(tm>>5&0x3f), int(tm&0x1f*2),
+		0, time.UTC)
+}
+
+// zipEntryReader hashes and counts uncompressed entry data and enforces the
+// per-entry limit.
+type zipEntryReader struct {
+	r   io.Reader
+	crc hash.Hash32
+	n   int64
+	max int64 // negative means unlimited
+}
+
+func (z *zipEntryReader) Unwrap() io.Reader {
+	return z.r
+}
+
+func (z *zipEntryReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.crc.Write(p[:n])
+	z.n += int64(n)
+	if z.max >= 0 && z.n > z.max {
+		return n, fmt.Errorf("%w: entry over %d bytes", ErrArchiveLimit, z.max)
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = ErrZipFormat
+	}
+	return n, err
+}
+
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// storedScanner reads a stored entry of unknown length by looking for a data
+// descriptor whose CRC and size match the bytes seen so far.
+type storedScanner struct {
+	br    *bufio.Reader
+	crc   hash.Hash32
+	n     int64
+	zip64 bool
+	done  bool
+}
+
+func (s *storedScanner) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+	descLen := 16
+	if s.zip64 {
+		descLen = 24
+	}
+	var sig [4]byte
+	binary.LittleEndian.PutUint32(sig[:], zipDescriptorSig)
+	want := len(p) + descLen
+	if want > s.br.Size() {
+		want = s.br.Size()
+	}
+	win, err := s.br.Peek(want)
+	if len(win) < descLen {
+		if err == nil || err == io.EOF {
+			err = ErrZipFormat
+		}
+		return 0, err
+	}
+	i := bytes.Index(win, sig[:])
+	if i == 0 {
+		if s.isDescriptor(win[:descLen]) {
+			s.done = true
+			return 0, io.EOF
+		}
+		i = 1
+	} else if i < 0 {
+		// The signature may start in the last descLen-1 bytes.
+		i = len(win) - descLen + 1
+	}
+	if i > len(p) {
+		i = len(p)
+	}
+	n, _ := s.br.Read(p[:i])
+	s.crc.Write(p[:n])
+	s.n += int64(n)
+	return n, nil
+}
+
+func (s *storedScanner) isDescriptor(d []byte) bool {
+	le := binary.LittleEndian
+	if le.Uint32(d[4:]) != s.crc.Sum32() {
+		return false
+	}
+	if s.zip64 {
+		return le.Uint64(d[8:]) == uint64(s.n) && le.Uint64(d[16:]) == uint64(s.n)
+	}
+	return uint64(le.Uint32(d[8:])) == uint64(s.n) && uint64(le.Uint32(d[12:])) == uint64(s.n)
+}
@@ -0,0 +1,106 @@
+package readall
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func buildZip(t *testing.T, files map[string]string, method uint16) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatalf("create err:%v", err)
+		}
+		io.WriteString(w, body)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// localZipEntry encodes a stored entry whose sizes are in the local header.
+func localZipEntry(name string, body []byte) []byte {
+	hdr := make([]byte, 30)
+	le := binary.LittleEndian
+	le.PutUint32(hdr, zipLocalSig)
+	le.PutUint16(hdr[4:], 20)
+	le.PutUint32(hdr[14:], crc32.ChecksumIEEE(body))
+	le.PutUint32(hdr[18:], uint32(len(body)))
+	le.PutUint32(hdr[22:], uint32(len(body)))
+	le.PutUint16(hdr[26:], uint16(len(name)))
+	return append(append(hdr, name...), body...)
+}
+
+func walkZipAll(data []byte, opts ZipOptions) (map[string]string, error) {
+	got := make(map[string]string)
+	err := WalkZip(bytes.NewReader(data), func(e *ZipEntry, r io.Reader) error {
+		body, err := ioutil.ReadAll(r)
+		got[e.Name] = string(body)
+		return err
+	}, opts)
+	return got, err
+}
+
+func TestWalkZip(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "hello hello hello hello",
+		"dir/b.bin": string(bytes.Repeat([]byte{0x50, 0x4b, 0x07, 0x08, 1, 2}, 1000)),
+		"empty":     "",
+	}
+	for _, method := range []uint16{zip.Store, zip.Deflate} {
+		got, err := walkZipAll(buildZip(t, files, method), ZipOptions{})
+		if err != nil {
+			t.Errorf("method %v walk err:%v", method, err)
+			continue
+		}
+		for name, body := range files {
+			if got[name] != body {
+				t.Errorf("method %v entry %v len:%v, want:%v", method, name, len(got[name]), len(body))
+			}
+		}
+	}
+
+	data := append(localZipEntry("c.txt", []byte("known size")), localZipEntry("d.txt", []byte("next"))...)
+	got, err := walkZipAll(data, ZipOptions{})
+	if err != nil || got["c.txt"] != "known size" || got["d.txt"] != "next" {
+		t.Errorf("local entries:%v, err:%v", got, err)
+	}
+}
+
+func TestWalkZipSkipsUnreadEntries(t *testing.T) {
+	data := buildZip(t, map[string]string{"a": "first", "b": "second"}, zip.Deflate)
+	var names []string
+	err := WalkZip(bytes.NewReader(data), func(e *ZipEntry, r io.Reader) error {
+		names = append(names, e.Name)
+		return nil
+	}, ZipOptions{})
+	if err != nil || len(names) != 2 {
+		t.Errorf("names:%v, err:%v", names, err)
+	}
+}
+
+func TestWalkZipRejects(t *testing.T) {
+	if _, err := walkZipAll(buildZip(t, map[string]string{"../x": "x"}, zip.Deflate), ZipOptions{}); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("traversal err:%v, want:%v", err, ErrUnsafePath)
+	}
+	big := map[string]string{"big": string(make([]byte, 1000))}
+	if _, err := walkZipAll(buildZip(t, big, zip.Deflate), ZipOptions{MaxEntrySize: 100}); !errors.Is(err, ErrArchiveLimit) {
+		t.Errorf("entry size err:%v, want:%v", err, ErrArchiveLimit)
+	}
+	if _, err := walkZipAll(buildZip(t, big, zip.Store), ZipOptions{MaxTotalSize: 999}); !errors.Is(err, ErrArchiveLimit) {
+		t.Errorf("total size err:%v, want:%v", err, ErrArchiveLimit)
+	}
+
+	data := localZipEntry("c.txt", []byte("known size"))
+	data[len(data)-1] ^= 0xff
+	if _, err := walkZipAll(data, ZipOptions{}); !errors.Is(err, ErrZipFormat) {
+		t.Errorf("crc err:%v, want:%v", err, ErrZipFormat)
+	}
+}